@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/importer"
+)
+
+// runImport handles the import command with the arguments following it.
+// Unlike the API it imports synchronously, from the file where it is.
+func runImport(models *database.Models, args []string) {
+	if len(args) == 2 && args[0] == "resume" {
+		resumeImport(models, args[1])
+		return
+	}
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	mapping := fs.String("map", "", "columns of the fields, as field=column,...")
+	matchUsers := fs.Bool("match-users", false, "identify attendees by email")
+	dryRun := fs.Bool("dry-run", false, "only validate the file")
+	chunkSize := fs.Int("chunk", importer.DefaultChunkSize, "rows imported per transaction")
+	fs.Parse(args)
+
+	if fs.NArg() != 3 || *chunkSize < 1 {
+		log.Fatal(usage)
+	}
+	id, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		log.Fatalf("invalid id %q", fs.Arg(1))
+	}
+	path, err := filepath.Abs(fs.Arg(2))
+	if err != nil {
+		log.Fatalf("invalid file %q: %v", fs.Arg(2), err)
+	}
+
+	job := &database.ImportJob{
+		Kind:       fs.Arg(0),
+		Mapping:    map[string]string{},
+		MatchUsers: *matchUsers,
+		ChunkSize:  *chunkSize,
+		FilePath:   path,
+	}
+	switch job.Kind {
+	case database.ImportEvents:
+		job.UserId = id
+		user, err := models.Users.Get(id)
+		if err != nil {
+			log.Fatalf("error retrieving user %d: %v", id, err)
+		}
+		if user == nil {
+			log.Fatalf("user %d not found", id)
+		}
+	case database.ImportAttendees:
+		job.EventId = &id
+		event, err := models.Events.Get(id)
+		if err != nil {
+			log.Fatalf("error retrieving event %d: %v", id, err)
+		}
+		if event == nil {
+			log.Fatalf("event %d not found", id)
+		}
+		job.UserId = event.OwnerID
+	default:
+		log.Fatal(usage)
+	}
+
+	if *mapping != "" {
+		for _, pair := range strings.Split(*mapping, ",") {
+			field, column, ok := strings.Cut(pair, "=")
+			if !ok {
+				log.Fatalf("invalid mapping %q, expected field=column", pair)
+			}
+			job.Mapping[strings.TrimSpace(field)] = strings.TrimSpace(column)
+		}
+	}
+
+	report, err := importer.Validate(models, job)
+	if err != nil {
+		log.Fatalf("error reading %s: %v", path, err)
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	fmt.Printf("%d rows, %d invalid\n", report.Rows, report.InvalidRows)
+	if report.InvalidRows > 0 {
+		log.Fatal("nothing was imported")
+	}
+	if *dryRun || report.Rows == 0 {
+		return
+	}
+
+	job.TotalRows = report.Rows
+	if err := models.Imports.Insert(job); err != nil {
+		log.Fatalf("error starting import: %v", err)
+	}
+
+	finishImport(models, job)
+}
+
+// resumeImport carries on with a failed import.
+func resumeImport(models *database.Models, arg string) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		log.Fatalf("invalid import id %q", arg)
+	}
+
+	job := &database.ImportJob{Id: id}
+	if err := models.Imports.Resume(job); err != nil {
+		log.Fatalf("error resuming import %d: %v", id, err)
+	}
+
+	finishImport(models, job)
+}
+
+func finishImport(models *database.Models, job *database.ImportJob) {
+	err := importer.Run(models, job)
+	fmt.Printf("import %d: %d of %d rows done, %d imported, %d skipped\n",
+		job.Id, job.NextRow, job.TotalRows, job.Imported, job.Skipped)
+	if err != nil {
+		log.Fatalf("import %d failed, resume it with: admin import resume %d: %v",
+			job.Id, job.Id, err)
+	}
+
+	audit(models, "data.import", job.UserId,
+		fmt.Sprintf("imported %d %s from %s", job.Imported, job.Kind, job.FilePath))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/gdpr"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `usage:
+  admin export <userId> <file>   write a data export of the user to file
+  admin erase <userId>           anonymize the user's personal data
+  admin import [flags] events <ownerId> <file>
+                                 create the events of a CSV file
+  admin import [flags] attendees <eventId> <file>
+                                 add the attendees of a CSV file to the event
+  admin import resume <importId> resume a failed import
+
+import flags:
+  -map field=column,...          read fields from differently named columns
+  -match-users                   identify attendees by email instead of user id
+  -dry-run                       only validate the file
+  -chunk n                       rows imported per transaction (default 500)`
+
+func main() {
+	if len(os.Args) < 3 {
+		log.Fatal(usage)
+	}
+
+	db, err := sql.Open("sqlite3", "./data.db")
+	if err != nil {
+		log.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+
+	models := database.NewModels(db)
+
+	command := os.Args[1]
+	if command == "import" {
+		runImport(&models, os.Args[2:])
+		return
+	}
+
+	userId, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("invalid user id %q", os.Args[2])
+	}
+
+	switch command {
+	case "export":
+		if len(os.Args) < 4 {
+			log.Fatal(usage)
+		}
+
+		f, err := os.Create(os.Args[3])
+		if err != nil {
+			log.Fatalf("error creating export file: %v", err)
+		}
+		defer f.Close()
+
+		if err := gdpr.WriteExport(&models, userId, f); err != nil {
+			log.Fatalf("error exporting user %d: %v", userId, err)
+		}
+
+		audit(&models, "data.export", userId, "exported to "+os.Args[3])
+	case "erase":
+		if err := gdpr.Erase(&models, userId); err != nil {
+			log.Fatalf("error erasing user %d: %v", userId, err)
+		}
+
+		audit(&models, "data.erase", userId, "erased by an administrator")
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func audit(models *database.Models, action string, userId int, details string) {
+	actor := "cli"
+	if u, err := user.Current(); err == nil {
+		actor = fmt.Sprintf("cli:%s", u.Username)
+	}
+
+	entry := &database.AuditEntry{
+		Actor:   actor,
+		Action:  action,
+		UserId:  userId,
+		Details: details,
+	}
+
+	if err := models.Audit.Insert(entry); err != nil {
+		log.Fatalf("error writing audit entry: %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Aergiaaa/gin-event/internal/broker"
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+type announcementRequest struct {
+	Title string `json:"title" binding:"required,max=200"`
+	Body  string `json:"body" binding:"required,max=5000"`
+}
+
+// deliveryReport lists the deliveries of an announcement and counts them per
+// status.
+type deliveryReport struct {
+	AnnouncementId int                  `json:"announcementId"`
+	Counts         map[string]int       `json:"counts"`
+	Deliveries     []*database.Delivery `json:"deliveries"`
+}
+
+// GetAnnouncements returns the announcements of an event
+//
+//	@Summary			Returns the announcements of an event
+//	@Description	Returns the announcements the organizers made to the attendees, newest first.
+//	@Tags				announcements
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.Announcement
+//	@Router			/api/v1/events/{id}/announcements [get]
+func (app *app) getAnnouncements(c *gin.Context) {
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	announcements, err := app.models.Announcements.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve announcements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, announcements)
+}
+
+// CreateAnnouncement announces something to the attendees of an event
+//
+//	@Summary			Announces something to the attendees of an event
+//	@Description	Posts an announcement on the event and sends it to every attendee through the
+//	@Description	channels they enabled for announcements. Delivery happens in the background and
+//	@Description	is tracked per attendee. Requires the owner or a co-owner.
+//	@Tags				announcements
+//	@Accept			json
+//	@Produce			json
+//	@Param			id				path		int					true	"Event ID"
+//	@Param			announcement	body		announcementRequest	true	"Announcement"
+//	@Success			201	{object}	database.Announcement
+//	@Router			/api/v1/events/{id}/announcements [post]
+//	@Security		BearerAuth
+func (app *app) createAnnouncement(c *gin.Context) {
+	var req announcementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermAnnounce,
+		"You do not have permission to make announcements for this event")
+	if !ok {
+		return
+	}
+
+	announcement := &database.Announcement{
+		EventId:  event.Id,
+		AuthorId: app.getUserFromContext(c).Id,
+		Title:    strings.TrimSpace(req.Title),
+		Body:     strings.TrimSpace(req.Body),
+	}
+	if err := app.announce(event, announcement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create announcement"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, announcement)
+}
+
+// GetAnnouncementDeliveries returns the delivery status of an announcement
+//
+//	@Summary			Returns the delivery status of an announcement
+//	@Description	Returns whether the announcement reached each attendee: pending, sent, failed
+//	@Description	with the error, or skipped for attendees who turned off every channel, along
+//	@Description	with the channels it went through. Requires the owner or a co-owner.
+//	@Tags				announcements
+//	@Produce			json
+//	@Param			id				path		int		true	"Event ID"
+//	@Param			announcementId	path		int		true	"Announcement ID"
+//	@Param			status			query		string	false	"Only deliveries in this status"	Enums(pending, sent, failed, skipped)
+//	@Success			200	{object}	deliveryReport
+//	@Router			/api/v1/events/{id}/announcements/{announcementId}/deliveries [get]
+//	@Security		BearerAuth
+func (app *app) getAnnouncementDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != database.DeliveryPending && status != database.DeliverySent &&
+		status != database.DeliveryFailed && status != database.DeliverySkipped {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermAnnounce,
+		"You do not have permission to view the deliveries of this event's announcements")
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("announcementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid announcement ID"})
+		return
+	}
+
+	announcement, err := app.models.Announcements.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve announcement"})
+		return
+	}
+	if announcement == nil || announcement.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
+		return
+	}
+
+	counts, err := app.models.Announcements.CountDeliveries(announcement.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries"})
+		return
+	}
+
+	deliveries, err := app.models.Announcements.GetDeliveries(announcement.Id, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveryReport{
+		AnnouncementId: announcement.Id,
+		Counts:         counts,
+		Deliveries:     deliveries,
+	})
+}
+
+// announce records an announcement for every attendee of event and delivers
+// it in the background.
+func (app *app) announce(event *database.Event, announcement *database.Announcement) error {
+	attendees, err := app.models.Attendees.GetByEvent(event.Id)
+	if err != nil {
+		return err
+	}
+
+	recipients := make([]int, len(attendees))
+	for i, a := range attendees {
+		recipients[i] = a.Id
+	}
+
+	if err := app.models.Announcements.Insert(announcement, recipients); err != nil {
+		return err
+	}
+	app.broker.Publish(broker.EventTopic(event.Id), streamAnnouncementCreated, announcement)
+
+	app.background(func() {
+		if err := app.deliverAnnouncement(announcement); err != nil {
+			log.Printf("error delivering announcement %d: %v", announcement.Id, err)
+		}
+	})
+	return nil
+}
+
+// eventChanges describes the changes to the date and location of an event
+// for its attendees. what names the changed details and is empty if neither
+// changed.
+func eventChanges(old, updated *database.Event) (what, changes string) {
+	var details, sentences []string
+	if !old.StartsAt.Equal(updated.StartsAt) || !old.EndsAt.Equal(updated.EndsAt) ||
+		old.Timezone != updated.Timezone || old.AllDay != updated.AllDay {
+		details = append(details, "date")
+		sentences = append(sentences, fmt.Sprintf("It now starts %s instead of %s.",
+			formatEventStart(updated), formatEventStart(old)))
+	}
+	if old.Location != updated.Location {
+		details = append(details, "location")
+		sentences = append(sentences, fmt.Sprintf("It now takes place at %s instead of %s.",
+			updated.Location, old.Location))
+	}
+
+	return strings.Join(details, " and "), strings.Join(sentences, " ")
+}
+
+// announceChanges announces the changes to the date and location of an
+// event, if there are any.
+func (app *app) announceChanges(old, updated *database.Event, authorId int) error {
+	what, changes := eventChanges(old, updated)
+	if what == "" {
+		return nil
+	}
+
+	return app.announce(updated, &database.Announcement{
+		EventId:   updated.Id,
+		AuthorId:  authorId,
+		Title:     "New " + what,
+		Body:      "The organizers changed " + updated.Name + ". " + changes,
+		Automatic: true,
+	})
+}
+
+// deliverAnnouncement sends an announcement to the recipients it is pending
+// for, through the channels each of them enabled.
+func (app *app) deliverAnnouncement(announcement *database.Announcement) error {
+	deliveries, err := app.models.Announcements.GetDeliveries(announcement.Id,
+		database.DeliveryPending)
+	if err != nil {
+		return err
+	}
+
+	event, err := app.models.Events.Get(announcement.EventId)
+	if err != nil {
+		return err
+	}
+	name := "a deleted event"
+	if event != nil {
+		name = event.Name
+	}
+	subject := name + ": " + announcement.Title
+	body := fmt.Sprintf("%s\n\nSee every announcement of %s with GET /api/v1/events/%d/announcements.",
+		announcement.Body, name, announcement.EventId)
+
+	for _, d := range deliveries {
+		user, err := app.models.Users.Get(d.UserId)
+		if err != nil {
+			return err
+		}
+
+		d.Status = database.DeliverySkipped
+		if user != nil {
+			to, channels, err := app.recipient(user, database.NotifyAnnouncement)
+			if err != nil {
+				return err
+			}
+
+			n := &database.Notification{
+				UserId:    user.Id,
+				Type:      database.NotifyAnnouncement,
+				EventId:   announcement.EventId,
+				Title:     subject,
+				Body:      body,
+				CreatedAt: announcement.CreatedAt,
+			}
+			d.Status = database.DeliverySent
+			var errs []string
+			for _, channel := range channels {
+				if err := app.send(to, channel, n); err != nil {
+					d.Status = database.DeliveryFailed
+					errs = append(errs, channel+": "+err.Error())
+					continue
+				}
+				d.Channels = append(d.Channels, channel)
+			}
+			d.Error = strings.Join(errs, "; ")
+			if len(d.Channels) == 0 && len(errs) == 0 {
+				d.Status = database.DeliverySkipped
+			}
+		}
+
+		if err := app.models.Announcements.FinishDelivery(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resumeAnnouncements carries on delivering the announcements the server
+// stopped delivering.
+func (app *app) resumeAnnouncements() {
+	ids, err := app.models.Announcements.GetPendingAnnouncements()
+	if err != nil {
+		log.Printf("error retrieving pending announcements: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		announcement, err := app.models.Announcements.Get(id)
+		if err == nil && announcement != nil {
+			err = app.deliverAnnouncement(announcement)
+		}
+		if err != nil {
+			log.Printf("error delivering announcement %d: %v", id, err)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -12,17 +15,69 @@ import (
 // GetAttendeesForEvent returns all attendees for a given event
 //
 //	@Summary			Returns all attendees for a given event
-//	@Description	Returns all attendees for a given event
+//	@Description	Returns all attendees for a given event, subject to the event's attendee visibility.
+//	@Description	The owner, co-owners and admins receive full user records including emails,
+//	@Description	with each attendee's tickets and answers to the registration form, everyone
+//	@Description	else receives public profiles with anonymous attendees masked.
 //	@Tags				attendees
 //	@Accept			json
 //	@Produce			json
 //	@Param			id		path		int	true	"Event ID"
-//	@Success			200	{object}	[]database.User
+//	@Success			200	{object}	[]database.PublicUser
+//	@Success			200	{object}	[]database.Registration
 //	@Router			/api/v1/events/{id}/attendees [get]
 func (app *app) getAttendeesForEvent(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event Id"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	visible, err := app.canViewEvent(user, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	allowed, err := app.canListAttendees(user, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "You are not allowed to view the attendees of this event"})
+		return
+	}
+
+	full, err := app.can(user, event, database.PermViewRegistrations)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+		return
+	}
+	if full {
+		registrations, err := app.models.Attendees.GetRegistrations(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendees"})
+			return
+		}
+		c.JSON(http.StatusOK, registrations)
+		return
 	}
 
 	users, err := app.models.Attendees.GetByEvent(id)
@@ -31,18 +86,43 @@ func (app *app) getAttendeesForEvent(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	publicUsers := make([]*database.PublicUser, 0, len(users))
+	for _, u := range users {
+		if u.Id == user.Id {
+			u.Anonymous = false
+		}
+		publicUsers = append(publicUsers, u.Public())
+	}
+
+	c.JSON(http.StatusOK, publicUsers)
+}
+
+type rsvpRequest struct {
+	ticketChoice
+	Message string `json:"message" binding:"max=1000"`
+	// Answers are the answers to the event's registration form.
+	Answers database.Answers `json:"answers"`
 }
 
 // AddAttendeeToEvent adds an attendee to an event
 // @Summary			Adds an attendee to an event
-// @Description	Adds an attendee to an event
+// @Description	Adds an attendee to an event. The owner, co-owners and editors may add anyone,
+// @Description	other users may RSVP to published events they can see by adding themselves.
+// @Description	RSVPs to events that require approval create a pending join request instead,
+// @Description	optionally with a message for the organizers. Events with ticket types need a
+// @Description	ticketTypeId and optionally a quantity; tickets are taken when the attendee is
+// @Description	added. Paid tickets are bought with an order instead, except by organizers.
+// @Description	Answers to the event's registration form are checked against its rules. Organizers
+// @Description	adding someone else may leave required questions out.
 // @Tags				attendees
 // @Accept			json
 // @Produce			json
-// @Param			id			path		int	true	"Event ID"
-// @Param			userId	path		int	true	"User ID"
+// @Param			id			path		int			true	"Event ID"
+// @Param			userId	path		int			true	"User ID"
+// @Param			rsvp		body		rsvpRequest	false	"Tickets, answers and message for the organizers"
 // @Success			201		{object}	database.Attendee
+// @Success			202		{object}	database.JoinRequest
+// @Failure			400		{object}	invalidAnswersResponse
 // @Router			/api/v1/events/{id}/attendees/{userId} [post]
 // @Security		BearerAuth
 func (app *app) addAttendeeToEvent(c *gin.Context) {
@@ -68,10 +148,42 @@ func (app *app) addAttendeeToEvent(c *gin.Context) {
 		return
 	}
 
+	var req rsvpRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	user := app.getUserFromContext(c)
-	if event.OwnerID != user.Id {
-		c.JSON(http.StatusForbidden,
-			gin.H{"error": "You are not allowed to add attendees to this event"})
+	manager, err := app.can(user, event, database.PermManageAttendees)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+		return
+	}
+	if !manager {
+		if userId != user.Id {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "You are not allowed to add attendees to this event"})
+			return
+		}
+
+		visible, err := app.canViewEvent(user, event)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+			return
+		}
+		if !visible {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		if event.Status == database.EventDraft {
+			c.JSON(http.StatusConflict, gin.H{"error": "Draft events cannot be joined"})
+			return
+		}
+	}
+	if event.IsFinal() {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Attendees cannot be added to cancelled or completed events"})
 		return
 	}
 
@@ -97,24 +209,79 @@ func (app *app) addAttendeeToEvent(c *gin.Context) {
 		return
 	}
 
+	ticketType, ok := app.checkTicketChoice(c, event, &req.ticketChoice, manager)
+	if !ok {
+		return
+	}
+	if ticketType != nil && ticketType.Price > 0 && !manager && !event.ApprovalRequired {
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "Paid tickets must be bought with an order"})
+		return
+	}
+
+	answers, ok := app.checkAnswers(c, event, req.Answers, manager && userToAdd.Id != user.Id)
+	if !ok {
+		return
+	}
+
+	if event.ApprovalRequired && !manager {
+		joinRequest := &database.JoinRequest{
+			EventId:      event.Id,
+			UserId:       userToAdd.Id,
+			Message:      req.Message,
+			TicketTypeId: req.TicketTypeId,
+			Quantity:     req.Quantity,
+			Answers:      answers,
+		}
+
+		err := app.models.JoinRequests.Insert(joinRequest)
+		if errors.Is(err, database.ErrJoinRequestPending) {
+			c.JSON(http.StatusConflict,
+				gin.H{"error": "You have already asked to join this event"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError,
+				gin.H{"error": "Error creating join request"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, joinRequest)
+		return
+	}
+
 	attendee := &database.Attendee{
-		EventId: event.Id,
-		UserId:  userToAdd.Id,
+		EventId:      event.Id,
+		UserId:       userToAdd.Id,
+		TicketTypeId: req.TicketTypeId,
+		Quantity:     req.Quantity,
+		Answers:      answers,
 	}
 
 	_, err = app.models.Attendees.Insert(attendee)
+	if errors.Is(err, database.ErrSoldOut) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Not enough tickets left"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			gin.H{"error": "Error adding attendee to event"})
 		return
 	}
 
+	app.publishAttendees(event.Id)
+	if userToAdd.Id != user.Id {
+		app.notifyUser(userToAdd, database.NotifyAttendeeAdded, event, "You were added to "+event.Name,
+			fmt.Sprintf("%s added you to %s on %s at %s.", user.Name, event.Name,
+				formatEventStart(event), event.Location))
+	}
+
 	c.JSON(http.StatusCreated, attendee)
 }
 
 // DeleteAttendeeFromEvent deletes an attendee from an event
 // @Summary			Deletes an attendee from an event
-// @Description	Deletes an attendee from an event
+// @Description	Deletes an attendee from an event. Users may always remove themselves, otherwise
+// @Description	the owner, a co-owner or an editor is required.
 // @Tags				attendees
 // @Accept			json
 // @Produce			json
@@ -146,23 +313,41 @@ func (app *app) deleteAttendeeFromEvent(c *gin.Context) {
 		return
 	}
 
+	user := app.getUserFromContext(c)
+	if user.Id != userId {
+		allowed, err := app.can(user, event, database.PermManageAttendees)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "You are not allowed to remove attendees from this event"})
+			return
+		}
+	}
+
 	err = app.models.Attendees.Delete(userId, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attendee"})
 		return
 	}
 
+	app.publishAttendees(id)
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
 // GetEventsByAttendee returns all events for a given attendee
 //
 //	@Summary			Returns all events for a given attendee
-//	@Description	Returns all events for a given attendee
+//	@Description	Returns all events for a given attendee. Other users only see public events with a
+//	@Description	public attendee list, and nothing at all for users who attend anonymously.
 //	@Tags				attendees
 //	@Accept			json
 //	@Produce			json
-//	@Param			id		path		int	true	"Attendee ID"
+//	@Param			id		path		int		true	"Attendee ID"
+//	@Param			tz		query		string	false	"IANA time zone to also render the event times in"
 //	@Success			200	{object}	[]database.Event
 //	@Router			/api/v1/attendees/{id}/events [get]
 func (app *app) getEventsByAttendee(c *gin.Context) {
@@ -172,11 +357,82 @@ func (app *app) getEventsByAttendee(c *gin.Context) {
 		return
 	}
 
+	viewer, err := viewerLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone"})
+		return
+	}
+
+	attendee, err := app.models.Users.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving user"})
+		return
+	}
+	if attendee == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
 	events, err := app.models.Attendees.GetEventsByUserId(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving events"})
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	for _, e := range events {
+		e.Localize(viewer)
+	}
+
+	user := app.getUserFromContext(c)
+	if user.Id == attendee.Id || user.IsAdmin {
+		c.JSON(http.StatusOK, events)
+		return
+	}
+
+	visible := []*database.Event{}
+	if !attendee.Anonymous {
+		for _, e := range events {
+			if e.AttendeeVisibility != database.AttendeeVisibilityPublic ||
+				e.Visibility != database.VisibilityPublic {
+				continue
+			}
+
+			ok, err := app.canViewEvent(user, e)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+				return
+			}
+			if ok {
+				visible = append(visible, e)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, visible)
+}
+
+// canListAttendees reports whether user may see who attends event. user is
+// the empty user for anonymous requests.
+func (app *app) canListAttendees(user *database.User, event *database.Event) (bool, error) {
+	allowed, err := app.can(user, event, database.PermViewAttendees)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	switch event.AttendeeVisibility {
+	case database.AttendeeVisibilityPublic:
+		return true, nil
+	case database.AttendeeVisibilityAttendees:
+		if user.Id == 0 {
+			return false, nil
+		}
+
+		attendee, err := app.models.Attendees.GetByEventAndUser(event.Id, user.Id)
+		if err != nil {
+			return false, err
+		}
+		return attendee != nil, nil
+	default:
+		return false, nil
+	}
 }
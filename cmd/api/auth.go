@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -8,18 +9,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type registerRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
 	Name     string `json:"name" binding:"required,min=2"`
 }
 
 type loginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
+	// Device is an optional name for the signed-in device, such as "Work laptop".
+	Device string `json:"device" binding:"max=100"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
 
 type loginResponse struct {
@@ -44,21 +50,27 @@ func (app *app) register(c *gin.Context) {
 		return
 	}
 
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err := app.passwordPolicy.Validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashPassword, err := app.hasher.Hash(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
 	}
 
-	req.Password = string(hashPassword)
 	user := database.User{
 		Email:    req.Email,
-		Password: req.Password,
+		Password: hashPassword,
 		Name:     req.Name,
 	}
 
 	err = app.models.Users.Insert(&user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully", "user": user})
@@ -91,22 +103,140 @@ func (app *app) login(c *gin.Context) {
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(req.Password))
-	if err != nil {
+	match, rehash := app.verifyPassword(existingUser, req.Password)
+	if !match {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
+	if rehash {
+		app.rehashPassword(existingUser, req.Password)
+	}
 
+	session := &database.Session{
+		UserId:    existingUser.Id,
+		Device:    req.Device,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+	if session.Device == "" {
+		session.Device = describeDevice(session.UserAgent)
+	}
+
+	if err := app.models.Sessions.Insert(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	tokenString, err := app.issueToken(existingUser, session.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	login := &database.Login{
+		UserId:    existingUser.Id,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if err := app.models.LoginHistory.Insert(login); err != nil {
+		log.Printf("error recording login of user %d: %v", existingUser.Id, err)
+	}
+
+	c.JSON(http.StatusOK, loginResponse{Token: tokenString, UserId: existingUser.Id})
+}
+
+// VerifyEmail confirms a pending email address change
+//
+//	@Summary			Confirms a pending email address change
+//	@Description	Confirms a pending email address change using the token sent to the new address
+//	@Tags				auth
+//	@Accept			json
+//	@Produce			json
+//	@Param			token	body		verifyEmailRequest	true	"Verification token"
+//	@Success			200	{object}	database.User
+//	@Router			/api/v1/auth/verify-email [post]
+func (app *app) verifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	verification, err := app.models.EmailVerifications.Consume(req.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if verification == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	existingUser, err := app.models.Users.GetByEmail(verification.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if existingUser != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already in use"})
+		return
+	}
+
+	user, err := app.models.Users.Get(verification.UserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	user.Email = verification.Email
+	if err := app.models.Users.Update(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (app *app) issueToken(user *database.User, sessionId string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": existingUser.Id,
+		"userId": user.Id,
+		"sid":    sessionId,
+		"ver":    user.TokenVersion,
 		"expr":   time.Now().Add(time.Hour * 72).Unix(),
 	})
 
-	tokenString, err := token.SignedString([]byte(app.jwtSecret))
+	return token.SignedString([]byte(app.jwtSecret))
+}
+
+// verifyPassword reports whether plaintext is the user's password and, if it
+// is, whether the stored hash uses an outdated algorithm or cost.
+func (app *app) verifyPassword(user *database.User, plaintext string) (match, rehash bool) {
+	match, rehash, err := app.hasher.Verify(plaintext, user.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		log.Printf("error verifying password of user %d: %v", user.Id, err)
+		return false, false
+	}
+
+	return match, rehash
+}
+
+// rehashPassword replaces an outdated hash with one from the current hasher.
+// Failures are only logged, the old hash keeps working.
+func (app *app) rehashPassword(user *database.User, plaintext string) {
+	hash, err := app.hasher.Hash(plaintext)
+	if err != nil {
+		log.Printf("error rehashing password of user %d: %v", user.Id, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, loginResponse{Token: tokenString, UserId: existingUser.Id})
+	if err := app.models.Users.UpdatePasswordHash(user.Id, user.Password, hash); err != nil {
+		log.Printf("error storing rehashed password of user %d: %v", user.Id, err)
+		return
+	}
+
+	user.Password = hash
 }
@@ -0,0 +1,17 @@
+package main
+
+import "log"
+
+// background runs fn in its own goroutine so a panic in a task that outlives
+// its request cannot take the server down.
+func (app *app) background(fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("background task panicked: %v", err)
+			}
+		}()
+
+		fn()
+	}()
+}
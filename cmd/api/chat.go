@@ -0,0 +1,631 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Aergiaaa/gin-event/internal/broker"
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Types of the frames sent to chat clients.
+const (
+	chatMessage = "message"
+	chatDeleted = "deleted"
+	chatMuted   = "muted"
+	chatUnmuted = "unmuted"
+	chatError   = "error"
+)
+
+const (
+	// chatMaxBody is how many characters a chat message may have, and
+	// chatMaxFrame how many bytes a frame from a client may have.
+	chatMaxBody  = 2000
+	chatMaxFrame = 16 * 1024
+	// chatWriteWait is how long writing a frame may take, and chatPongWait
+	// how long a client may stay silent before it is disconnected. Clients
+	// are pinged often enough to answer in time.
+	chatWriteWait  = 10 * time.Second
+	chatPongWait   = time.Minute
+	chatPingPeriod = chatPongWait * 9 / 10
+	// chatReplies is how many replies to a client may wait to be written
+	// before further ones are dropped.
+	chatReplies = 16
+	// chatResume is how many missed messages a reconnecting client receives.
+	chatResume = 100
+)
+
+// chatUpgrader accepts WebSockets from any origin like the CORS settings do,
+// as clients authenticate with their token rather than with cookies.
+var chatUpgrader = websocket.Upgrader{
+	Subprotocols: []string{"bearer"},
+	CheckOrigin:  func(r *http.Request) bool { return true },
+}
+
+// chatFrame is a frame sent to chat clients.
+type chatFrame struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// chatInput is a frame sent by chat clients to post a message.
+type chatInput struct {
+	Body string `json:"body"`
+}
+
+// chatPage is a page of chat messages. NextCursor is the cursor of the next
+// page and unset on the last one.
+type chatPage struct {
+	Messages   []*database.ChatMessage `json:"messages"`
+	NextCursor *int                    `json:"nextCursor,omitempty"`
+}
+
+type muteRequest struct {
+	UserId int `json:"userId" binding:"required"`
+	// Minutes is how long the user stays muted. They stay muted until they
+	// are unmuted if it is zero.
+	Minutes int `json:"minutes" binding:"min=0,max=525600"`
+}
+
+// JoinChat joins the chat room of an event
+//
+//	@Summary			Joins the chat room of an event
+//	@Description	Upgrades to a WebSocket in the chat room of the event, open to its attendees and
+//	@Description	organizers. Authenticate with the Authorization header, or from browsers by
+//	@Description	offering the subprotocols "bearer" and the token. Send {"body": "..."} frames to
+//	@Description	post messages. The server sends {"type": ..., "data": ...} frames: message with a
+//	@Description	new message, deleted with the id of a deleted message, muted and unmuted with the
+//	@Description	user concerned, and error when a message could not be posted, for instance because
+//	@Description	the user posts too often or is muted. Pass the id of the last message received as
+//	@Description	after when reconnecting to receive what was missed first. Clients that fall behind
+//	@Description	are disconnected with close code 1013 and should reconnect. Users who may no
+//	@Description	longer chat, or whose session was revoked, are disconnected with close code 1008
+//	@Description	within a minute.
+//	@Tags				chat
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			after	query	int	false	"Id of the last message received"
+//	@Success			101
+//	@Router			/api/v1/events/{id}/chat [get]
+//	@Security		BearerAuth
+func (app *app) joinChat(c *gin.Context) {
+	user, ok := app.chatUser(c)
+	if !ok {
+		return
+	}
+
+	event, ok := app.chatRoom(c, user)
+	if !ok {
+		return
+	}
+
+	after := 0
+	if s := c.Query("after"); s != "" {
+		var err error
+		after, err = strconv.Atoi(s)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after"})
+			return
+		}
+	}
+
+	// Subscribe before loading the missed messages so that none are lost in
+	// between. The writer skips the ones it receives twice.
+	sub := app.broker.Subscribe(broker.ChatTopic(event.Id), 0)
+	defer sub.Close()
+
+	missed := []*database.ChatMessage{}
+	if after > 0 {
+		var err error
+		missed, err = app.models.Chat.GetAfter(event.Id, after, chatResume)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
+			return
+		}
+	}
+
+	// The upgrader replies itself when the handshake fails.
+	conn, err := chatUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	replies := make(chan chatFrame, chatReplies)
+	done := make(chan struct{})
+	written := make(chan struct{})
+
+	session := app.getSessionFromContext(c)
+	allowed := func() bool { return app.stillInChat(user, session, event) }
+
+	go func() {
+		app.writeChat(conn, sub, missed, replies, done, allowed)
+		close(written)
+	}()
+
+	app.readChat(conn, user, event, replies)
+	close(done)
+	<-written
+}
+
+// chatUser authenticates the user joining a chat room with the Authorization
+// header, or with the subprotocols "bearer" and the token. It writes the error
+// response and returns false if that fails.
+func (app *app) chatUser(c *gin.Context) (*database.User, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if protocols := websocket.Subprotocols(c.Request); authHeader == "" &&
+		len(protocols) == 2 && protocols[0] == "bearer" {
+		authHeader = "Bearer " + protocols[1]
+	}
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+		return nil, false
+	}
+
+	user, session, err := app.authenticate(authHeader)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	app.touchSession(session, c.ClientIP())
+
+	c.Set("user", user)
+	c.Set("session", session)
+	return user, true
+}
+
+// chatRoom loads the event named by the id parameter and checks that user may
+// join its chat room. It writes the error response and returns false if the
+// event cannot be used.
+func (app *app) chatRoom(c *gin.Context, user *database.User) (*database.Event, bool) {
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return nil, false
+	}
+
+	allowed, err := app.canChat(user, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only attendees and organizers can join the chat of this event"})
+		return nil, false
+	}
+
+	return event, true
+}
+
+// canChat reports whether user may join the chat room of event, as one of its
+// attendees or organizers.
+func (app *app) canChat(user *database.User, event *database.Event) (bool, error) {
+	allowed, err := app.can(user, event, database.PermChat)
+	if err != nil || allowed || user.Id == 0 {
+		return allowed, err
+	}
+
+	attendee, err := app.models.Attendees.GetByEventAndUser(event.Id, user.Id)
+	if err != nil {
+		return false, err
+	}
+	return attendee != nil, nil
+}
+
+// stillInChat reports whether user may stay in the chat room of event they
+// joined with session. Users are kept in the room when that cannot be checked.
+func (app *app) stillInChat(user *database.User, session *database.Session, event *database.Event) bool {
+	current, err := app.models.Users.Get(user.Id)
+	if err != nil {
+		log.Printf("error checking chat user %d: %v", user.Id, err)
+		return true
+	}
+	if current == nil || current.TokenVersion != user.TokenVersion {
+		return false
+	}
+
+	s, err := app.models.Sessions.Get(session.Id)
+	if err != nil {
+		log.Printf("error checking chat session of user %d: %v", user.Id, err)
+		return true
+	}
+	if s == nil || s.RevokedAt != nil {
+		return false
+	}
+
+	e, err := app.models.Events.Get(event.Id)
+	if err != nil {
+		log.Printf("error checking chat room of event %d: %v", event.Id, err)
+		return true
+	}
+	if e == nil {
+		return false
+	}
+
+	visible, err := app.canViewEvent(current, e)
+	if err == nil && visible {
+		visible, err = app.canChat(current, e)
+	}
+	if err != nil {
+		log.Printf("error checking chat permissions on event %d: %v", event.Id, err)
+		return true
+	}
+	return visible
+}
+
+// readChat posts the messages a client sends until it disconnects. Replies to
+// the client go through replies, and are dropped if too many of them wait.
+func (app *app) readChat(conn *websocket.Conn, user *database.User, event *database.Event, replies chan<- chatFrame) {
+	conn.SetReadLimit(chatMaxFrame)
+	conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(chatPongWait))
+
+		var in chatInput
+		if err := json.Unmarshal(data, &in); err != nil {
+			in.Body = ""
+		}
+
+		if reply := app.postChat(user, event, in.Body); reply != nil {
+			select {
+			case replies <- chatFrame{Type: chatError, Data: reply}:
+			default:
+			}
+		}
+	}
+}
+
+// postChat posts a message of user to the chat room of event, or returns why
+// it could not.
+func (app *app) postChat(user *database.User, event *database.Event, body string) gin.H {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return gin.H{"error": "Message must not be empty"}
+	}
+	if utf8.RuneCountInString(body) > chatMaxBody {
+		return gin.H{"error": "Message must be at most " + strconv.Itoa(chatMaxBody) + " characters"}
+	}
+
+	key := "chat:" + strconv.Itoa(event.Id) + ":" + strconv.Itoa(user.Id)
+	if ok, wait := app.chatLimiter.Allow(key); !ok {
+		return gin.H{"error": "Too many messages", "retryAfter": int(math.Ceil(wait.Seconds()))}
+	}
+
+	// Attendees may leave and organizers be removed while they are connected.
+	allowed, err := app.canChat(user, event)
+	if err != nil {
+		log.Printf("error checking chat permissions on event %d: %v", event.Id, err)
+		return gin.H{"error": "Failed to post message"}
+	}
+	if !allowed {
+		return gin.H{"error": "You can no longer post in this chat"}
+	}
+
+	mute, err := app.models.Chat.GetMute(event.Id, user.Id)
+	if err != nil {
+		log.Printf("error checking chat mutes on event %d: %v", event.Id, err)
+		return gin.H{"error": "Failed to post message"}
+	}
+	if mute != nil {
+		return gin.H{"error": "You are muted", "until": mute.Until}
+	}
+
+	message := &database.ChatMessage{
+		EventId:  event.Id,
+		UserId:   user.Id,
+		UserName: user.Name,
+		Body:     body,
+	}
+	if err := app.models.Chat.Insert(message); err != nil {
+		log.Printf("error posting chat message on event %d: %v", event.Id, err)
+		return gin.H{"error": "Failed to post message"}
+	}
+
+	app.broker.Publish(broker.ChatTopic(event.Id), chatMessage, message)
+	return nil
+}
+
+// writeChat sends the missed messages to a client, then the messages of its
+// chat room and the replies to it, until done is closed, the client cannot
+// keep up or allowed, checked whenever the client is pinged, returns false.
+// It closes the connection when it stops.
+func (app *app) writeChat(conn *websocket.Conn, sub *broker.Subscription, missed []*database.ChatMessage,
+	replies <-chan chatFrame, done <-chan struct{}, allowed func() bool) {
+	defer conn.Close()
+
+	write := func(f chatFrame) bool {
+		conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+		return conn.WriteJSON(f) == nil
+	}
+
+	last := 0
+	for _, m := range missed {
+		if !write(chatFrame{Type: chatMessage, Data: m}) {
+			return
+		}
+		last = m.Id
+	}
+
+	ping := time.NewTicker(chatPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case f := <-replies:
+			if !write(f) {
+				return
+			}
+		case m, ok := <-sub.C:
+			if !ok {
+				// The client fell behind the room. It resumes from the last
+				// message it received when it reconnects.
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Too slow"),
+					time.Now().Add(chatWriteWait))
+				return
+			}
+			if message, ok := m.Data.(*database.ChatMessage); ok && message.Id <= last {
+				continue
+			}
+			if !write(chatFrame{Type: m.Type, Data: m.Data}) {
+				return
+			}
+		case <-ping.C:
+			// Attendees may leave, organizers be removed and sessions be
+			// revoked while clients are connected.
+			if !allowed() {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "No longer allowed"),
+					time.Now().Add(chatWriteWait))
+				return
+			}
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(chatWriteWait))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// GetChatMessages returns the history of the chat room of an event
+//
+//	@Summary			Returns the history of the chat room of an event
+//	@Description	Returns a page of the messages in the chat room of the event, newest first. Pass
+//	@Description	nextCursor as cursor to get the next page. Open to its attendees and organizers.
+//	@Tags				chat
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Param			cursor	query		int	false	"Cursor of the page"
+//	@Param			limit	query		int	false	"Messages to return, at most 100"	default(20)
+//	@Success			200	{object}	chatPage
+//	@Router			/api/v1/events/{id}/chat/messages [get]
+//	@Security		BearerAuth
+func (app *app) getChatMessages(c *gin.Context) {
+	cursor, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	event, ok := app.chatRoom(c, app.getUserFromContext(c))
+	if !ok {
+		return
+	}
+
+	messages, err := app.models.Chat.GetByEvent(event.Id, cursor, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
+		return
+	}
+
+	page := chatPage{Messages: messages}
+	if len(messages) > limit {
+		page.Messages = messages[:limit]
+		page.NextCursor = &messages[limit-1].Id
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// DeleteChatMessage deletes a chat message
+//
+//	@Summary			Deletes a chat message
+//	@Description	Removes a message from the chat room of the event. Its author, the owner,
+//	@Description	co-owners and admins can delete it.
+//	@Tags				chat
+//	@Param			id			path	int	true	"Event ID"
+//	@Param			messageId	path	int	true	"Message ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/chat/messages/{messageId} [delete]
+//	@Security		BearerAuth
+func (app *app) deleteChatMessage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("messageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	moderator, err := app.can(user, event, database.PermModerateChat)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+
+	message, err := app.models.Chat.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve message"})
+		return
+	}
+	if message == nil || message.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if message.UserId != user.Id && !moderator {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to delete this message"})
+		return
+	}
+
+	if err := app.models.Chat.Delete(message.Id, user.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
+		return
+	}
+
+	app.broker.Publish(broker.ChatTopic(event.Id), chatDeleted, gin.H{"id": message.Id})
+	c.Status(http.StatusNoContent)
+}
+
+// GetChatMutes returns the users muted in the chat room of an event
+//
+//	@Summary			Returns the users muted in the chat room of an event
+//	@Description	Returns the mutes in force in the chat room of the event, oldest first. Requires
+//	@Description	the owner, a co-owner or an admin.
+//	@Tags				chat
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.ChatMute
+//	@Router			/api/v1/events/{id}/chat/mutes [get]
+//	@Security		BearerAuth
+func (app *app) getChatMutes(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermModerateChat,
+		"You do not have permission to moderate the chat of this event")
+	if !ok {
+		return
+	}
+
+	mutes, err := app.models.Chat.GetMutes(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve mutes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mutes)
+}
+
+// MuteChatUser mutes a user in the chat room of an event
+//
+//	@Summary			Mutes a user in the chat room of an event
+//	@Description	Keeps a user from posting in the chat room of the event for the given minutes,
+//	@Description	or until they are unmuted if none are given. Muting them again replaces their
+//	@Description	mute. Moderators cannot be muted. Requires the owner, a co-owner or an admin.
+//	@Tags				chat
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int			true	"Event ID"
+//	@Param			mute	body		muteRequest	true	"User to mute"
+//	@Success			201	{object}	database.ChatMute
+//	@Router			/api/v1/events/{id}/chat/mutes [post]
+//	@Security		BearerAuth
+func (app *app) muteChatUser(c *gin.Context) {
+	var req muteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermModerateChat,
+		"You do not have permission to moderate the chat of this event")
+	if !ok {
+		return
+	}
+
+	target, err := app.models.Users.Get(req.UserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	moderator, err := app.can(target, event, database.PermModerateChat)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if moderator {
+		c.JSON(http.StatusConflict, gin.H{"error": "Moderators of the chat cannot be muted"})
+		return
+	}
+
+	mute := &database.ChatMute{
+		EventId:  event.Id,
+		UserId:   target.Id,
+		UserName: target.Name,
+		MutedBy:  app.getUserFromContext(c).Id,
+	}
+	if req.Minutes > 0 {
+		until := time.Now().UTC().Add(time.Duration(req.Minutes) * time.Minute)
+		mute.Until = &until
+	}
+
+	if err := app.models.Chat.Mute(mute); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mute user"})
+		return
+	}
+
+	app.broker.Publish(broker.ChatTopic(event.Id), chatMuted,
+		gin.H{"userId": mute.UserId, "until": mute.Until})
+	c.JSON(http.StatusCreated, mute)
+}
+
+// UnmuteChatUser unmutes a user in the chat room of an event
+//
+//	@Summary			Unmutes a user in the chat room of an event
+//	@Description	Lets a muted user post in the chat room of the event again. Requires the owner, a
+//	@Description	co-owner or an admin.
+//	@Tags				chat
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			userId	path	int	true	"User ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/chat/mutes/{userId} [delete]
+//	@Security		BearerAuth
+func (app *app) unmuteChatUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermModerateChat,
+		"You do not have permission to moderate the chat of this event")
+	if !ok {
+		return
+	}
+
+	unmuted, err := app.models.Chat.Unmute(event.Id, userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmute user"})
+		return
+	}
+	if !unmuted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not muted"})
+		return
+	}
+
+	app.broker.Publish(broker.ChatTopic(event.Id), chatUnmuted, gin.H{"userId": userId})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// onlineDevice is the device id of scans sent without one.
+const onlineDevice = "online"
+
+// rosterEntry is an attendee on a device's roster. Devices match a scanned
+// token by the hex SHA-256 of it, so a lost device does not leak tickets.
+type rosterEntry struct {
+	*database.RosterEntry
+	TicketHash string `json:"ticketHash"`
+}
+
+type rosterSnapshot struct {
+	EventId int `json:"eventId"`
+	// Cursor is where to ask for changes from after loading the snapshot.
+	Cursor      int64         `json:"cursor"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Attendees   []rosterEntry `json:"attendees"`
+}
+
+type rosterChanges struct {
+	Cursor  int64         `json:"cursor"`
+	More    bool          `json:"more"`
+	Changed []rosterEntry `json:"changed"`
+	Removed []int         `json:"removed"`
+}
+
+type offlineScan struct {
+	Token string `json:"token" binding:"required"`
+	// ScannedAt is when the device scanned the ticket, by its own clock.
+	ScannedAt time.Time `json:"scannedAt" binding:"required"`
+}
+
+type checkInBatchRequest struct {
+	DeviceId string        `json:"deviceId" binding:"required,max=64"`
+	Scans    []offlineScan `json:"scans" binding:"required,min=1,max=500,dive"`
+}
+
+type checkInBatchResponse struct {
+	Results []*database.Scan `json:"results"`
+}
+
+// GetCheckInSnapshot returns the check-in roster of an event
+//
+//	@Summary			Returns the check-in roster of an event
+//	@Description	Returns every attendee with the hash of their signed ticket and their check-in,
+//	@Description	so a device can check tickets in while offline, and a cursor to ask for changes
+//	@Description	from. Requires check-in staff or an organizer.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id			path		int		true	"Event ID"
+//	@Param			deviceId	query		string	true	"Device asking for the roster"
+//	@Success			200	{object}	rosterSnapshot
+//	@Router			/api/v1/events/{id}/checkin/snapshot [get]
+//	@Security		BearerAuth
+func (app *app) getCheckInSnapshot(c *gin.Context) {
+	deviceId, ok := deviceParam(c)
+	if !ok {
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermCheckIn,
+		"You do not have permission to check in attendees of this event")
+	if !ok {
+		return
+	}
+
+	roster, cursor, err := app.models.CheckIns.Roster(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve roster"})
+		return
+	}
+
+	if err := app.models.CheckIns.TouchDevice(event.Id, deviceId, app.getUserFromContext(c).Id,
+		cursor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rosterSnapshot{
+		EventId:     event.Id,
+		Cursor:      cursor,
+		GeneratedAt: time.Now().UTC(),
+		Attendees:   app.rosterEntries(event, roster),
+	})
+}
+
+// GetCheckInChanges returns the roster changes of an event since a cursor
+//
+//	@Summary			Returns the roster changes of an event since a cursor
+//	@Description	Returns the attendees that joined, left or were checked in or out since the
+//	@Description	cursor, in their current state, and the cursor to continue from. While more is
+//	@Description	set there are further changes. Requires check-in staff or an organizer.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id			path		int		true	"Event ID"
+//	@Param			deviceId	query		string	true	"Device asking for changes"
+//	@Param			cursor		query		int		true	"Cursor of the last snapshot or changes"
+//	@Param			limit		query		int		false	"Attendees to return, at most 1000"	default(500)
+//	@Success			200	{object}	rosterChanges
+//	@Router			/api/v1/events/{id}/checkin/changes [get]
+//	@Security		BearerAuth
+func (app *app) getCheckInChanges(c *gin.Context) {
+	deviceId, ok := deviceParam(c)
+	if !ok {
+		return
+	}
+
+	cursor, err := strconv.ParseInt(c.Query("cursor"), 10, 64)
+	if err != nil || cursor < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	limit := 500
+	if l := c.Query("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermCheckIn,
+		"You do not have permission to check in attendees of this event")
+	if !ok {
+		return
+	}
+
+	changes, err := app.models.CheckIns.Changes(event.Id, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve changes"})
+		return
+	}
+
+	if err := app.models.CheckIns.TouchDevice(event.Id, deviceId, app.getUserFromContext(c).Id,
+		changes.Cursor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rosterChanges{
+		Cursor:  changes.Cursor,
+		More:    changes.More,
+		Changed: app.rosterEntries(event, changes.Changed),
+		Removed: changes.Removed,
+	})
+}
+
+// CheckInBatch uploads the scans a device made offline
+//
+//	@Summary			Uploads the scans a device made offline
+//	@Description	Applies up to 500 queued scans and returns the result of each, in order:
+//	@Description	checked_in, duplicate, superseded (the check-in was undone after the scan),
+//	@Description	revoked (the attendee was removed) or invalid. When devices scanned the same
+//	@Description	ticket, the earliest scan is the check-in whatever order they upload in, ties
+//	@Description	going to the lower device id. Uploading the same scans again is harmless.
+//	@Description	Requires check-in staff or an organizer.
+//	@Tags				check-in
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int					true	"Event ID"
+//	@Param			batch	body		checkInBatchRequest	true	"Queued scans"
+//	@Success			200	{object}	checkInBatchResponse
+//	@Router			/api/v1/events/{id}/checkin/batch [post]
+//	@Security		BearerAuth
+func (app *app) checkInBatch(c *gin.Context) {
+	var req checkInBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.checkInEvent(c)
+	if !ok {
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	scans := make([]*database.Scan, 0, len(req.Scans))
+	for _, s := range req.Scans {
+		scans = append(scans, app.ticketScan(event, req.DeviceId, user.Id, s.Token, s.ScannedAt))
+	}
+
+	if err := app.models.CheckIns.RecordScans(scans); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record scans"})
+		return
+	}
+	if err := app.models.CheckIns.TouchDevice(event.Id, req.DeviceId, user.Id, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record device"})
+		return
+	}
+
+	for _, s := range scans {
+		if s.Result == database.ScanCheckedIn {
+			app.publishCheckIns(event.Id)
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, checkInBatchResponse{Results: scans})
+}
+
+// GetCheckInDevices returns the check-in devices of an event
+//
+//	@Summary			Returns the check-in devices of an event
+//	@Description	Returns every device that synced with or scanned at the event, with who used it
+//	@Description	last, how far it has synced and the totals of its scans. Requires the owner, a
+//	@Description	co-owner or an editor.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.Device
+//	@Router			/api/v1/events/{id}/checkin/devices [get]
+//	@Security		BearerAuth
+func (app *app) getCheckInDevices(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermManageAttendees,
+		"You do not have permission to audit the check-in of this event")
+	if !ok {
+		return
+	}
+
+	devices, err := app.models.CheckIns.GetDevices(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve devices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// GetDeviceScans returns the scans of a check-in device
+//
+//	@Summary			Returns the scans of a check-in device
+//	@Description	Returns every scan a device made at the event, newest first, with its result.
+//	@Description	Requires the owner, a co-owner or an editor.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id			path		int		true	"Event ID"
+//	@Param			deviceId	path		string	true	"Device ID"
+//	@Success			200	{object}	[]database.Scan
+//	@Router			/api/v1/events/{id}/checkin/devices/{deviceId}/scans [get]
+//	@Security		BearerAuth
+func (app *app) getDeviceScans(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermManageAttendees,
+		"You do not have permission to audit the check-in of this event")
+	if !ok {
+		return
+	}
+
+	scans, err := app.models.CheckIns.GetScans(event.Id, c.Param("deviceId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, scans)
+}
+
+// rosterEntries adds the ticket hashes to roster entries of event.
+func (app *app) rosterEntries(event *database.Event, roster []*database.RosterEntry) []rosterEntry {
+	entries := make([]rosterEntry, 0, len(roster))
+	for _, e := range roster {
+		token := app.ticketToken(&database.Attendee{Id: e.AttendeeId, EventId: event.Id})
+		sum := sha256.Sum256([]byte(token))
+		entries = append(entries, rosterEntry{RosterEntry: e, TicketHash: hex.EncodeToString(sum[:])})
+	}
+
+	return entries
+}
+
+// deviceParam returns the deviceId query parameter. It writes the error
+// response and returns false if it is missing or too long.
+func deviceParam(c *gin.Context) (string, bool) {
+	deviceId := c.Query("deviceId")
+	if deviceId == "" || len(deviceId) > 64 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deviceId must be between 1 and 64 characters"})
+		return "", false
+	}
+
+	return deviceId, true
+}
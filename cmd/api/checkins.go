@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// ticketResponse is an attendee's ticket. Token is what the QR code holds and
+// what staff scan at the entrance.
+type ticketResponse struct {
+	AttendeeId   int               `json:"attendeeId"`
+	EventId      int               `json:"eventId"`
+	TicketTypeId *int              `json:"ticketTypeId,omitempty"`
+	Quantity     int               `json:"quantity"`
+	Token        string            `json:"token"`
+	CheckIn      *database.CheckIn `json:"checkIn,omitempty"`
+}
+
+type checkInRequest struct {
+	Token string `json:"token" binding:"required"`
+	// DeviceId names the scanning device in the audit, it defaults to online.
+	DeviceId string `json:"deviceId" binding:"max=64"`
+}
+
+type checkInResponse struct {
+	*database.CheckIn
+	// Duplicate is set when the ticket had already been checked in, in which
+	// case the check-in is the original one.
+	Duplicate    bool   `json:"duplicate"`
+	Name         string `json:"name"`
+	TicketTypeId *int   `json:"ticketTypeId,omitempty"`
+	Quantity     int    `json:"quantity"`
+}
+
+// GetMyTicket returns the user's ticket for an event
+//
+//	@Summary			Returns the authenticated user's ticket for an event
+//	@Description	Returns the signed ticket token of the user's registration, which staff scan at
+//	@Description	the entrance, and its check-in if the user was checked in.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	ticketResponse
+//	@Router			/api/v1/events/{id}/ticket [get]
+//	@Security		BearerAuth
+func (app *app) getMyTicket(c *gin.Context) {
+	attendee, ok := app.userTicket(c)
+	if !ok {
+		return
+	}
+
+	checkIn, err := app.models.CheckIns.GetActive(attendee.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve check-in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticketResponse{
+		AttendeeId:   attendee.Id,
+		EventId:      attendee.EventId,
+		TicketTypeId: attendee.TicketTypeId,
+		Quantity:     attendee.Quantity,
+		Token:        app.ticketToken(attendee),
+		CheckIn:      checkIn,
+	})
+}
+
+// GetMyTicketQR returns the user's ticket for an event as a QR code
+//
+//	@Summary			Returns the authenticated user's ticket as a QR code
+//	@Description	Returns a PNG QR code holding the signed ticket token of the user's registration
+//	@Tags				check-in
+//	@Produce			png
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			size	query	int	false	"Width and height in pixels, 128 to 1024"	default(256)
+//	@Success			200	{file}	binary
+//	@Router			/api/v1/events/{id}/ticket/qr [get]
+//	@Security		BearerAuth
+func (app *app) getMyTicketQR(c *gin.Context) {
+	size := 256
+	if s := c.Query("size"); s != "" {
+		var err error
+		size, err = strconv.Atoi(s)
+		if err != nil || size < 128 || size > 1024 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 128 and 1024"})
+			return
+		}
+	}
+
+	attendee, ok := app.userTicket(c)
+	if !ok {
+		return
+	}
+
+	png, err := qrcode.Encode(app.ticketToken(attendee), qrcode.Medium, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render ticket"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// CheckIn checks an attendee in
+//
+//	@Summary			Checks an attendee in
+//	@Description	Checks in the attendee a scanned ticket token belongs to and records who checked
+//	@Description	them in. Scanning a ticket that was already checked in returns the original
+//	@Description	check-in with duplicate set and status 200. Tickets of removed attendees are
+//	@Description	rejected. Every scan is audited under deviceId. Requires check-in staff or an
+//	@Description	organizer.
+//	@Tags				check-in
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int				true	"Event ID"
+//	@Param			ticket	body		checkInRequest	true	"Scanned ticket token"
+//	@Success			201	{object}	checkInResponse
+//	@Success			200	{object}	checkInResponse
+//	@Router			/api/v1/events/{id}/checkin [post]
+//	@Security		BearerAuth
+func (app *app) checkIn(c *gin.Context) {
+	var req checkInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.checkInEvent(c)
+	if !ok {
+		return
+	}
+
+	deviceId := req.DeviceId
+	if deviceId == "" {
+		deviceId = onlineDevice
+	}
+
+	scan := app.ticketScan(event, deviceId, app.getUserFromContext(c).Id, req.Token, time.Time{})
+	if err := app.models.CheckIns.RecordScans([]*database.Scan{scan}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+	if scan.Result == database.ScanCheckedIn {
+		app.publishCheckIns(event.Id)
+	}
+
+	switch scan.Result {
+	case database.ScanInvalid:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket"})
+		return
+	case database.ScanRevoked, database.ScanSuperseded:
+		c.JSON(http.StatusConflict, gin.H{"error": "This ticket is no longer valid"})
+		return
+	}
+
+	attendee, err := app.models.Attendees.Get(*scan.AttendeeId)
+	if err != nil || attendee == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return
+	}
+	attendeeUser, err := app.models.Users.Get(attendee.UserId)
+	if err != nil || attendeeUser == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return
+	}
+
+	status := http.StatusCreated
+	if scan.Result == database.ScanDuplicate {
+		status = http.StatusOK
+	}
+	c.JSON(status, checkInResponse{
+		CheckIn:      scan.CheckIn,
+		Duplicate:    scan.Result == database.ScanDuplicate,
+		Name:         attendeeUser.Name,
+		TicketTypeId: attendee.TicketTypeId,
+		Quantity:     attendee.Quantity,
+	})
+}
+
+// UndoCheckIn reverts a check-in
+//
+//	@Summary			Reverts a check-in
+//	@Description	Reverts the check-in of an attendee, for example after a mistaken scan. The
+//	@Description	reverted check-in is kept with undoneAt set and the ticket can be scanned again.
+//	@Description	Requires check-in staff or an organizer.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			attendeeId	path		int	true	"Attendee ID"
+//	@Success			200	{object}	database.CheckIn
+//	@Router			/api/v1/events/{id}/checkin/{attendeeId} [delete]
+//	@Security		BearerAuth
+func (app *app) undoCheckIn(c *gin.Context) {
+	event, ok := app.checkInEvent(c)
+	if !ok {
+		return
+	}
+
+	attendeeId, err := strconv.Atoi(c.Param("attendeeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendee ID"})
+		return
+	}
+
+	attendee, err := app.models.Attendees.Get(attendeeId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return
+	}
+	if attendee == nil || attendee.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendee not found"})
+		return
+	}
+
+	checkIn, err := app.models.CheckIns.Undo(attendee.Id, app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo check-in"})
+		return
+	}
+	if checkIn == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This attendee is not checked in"})
+		return
+	}
+
+	app.publishCheckIns(event.Id)
+	c.JSON(http.StatusOK, checkIn)
+}
+
+// GetCheckInCounts returns the check-in counts of an event
+//
+//	@Summary			Returns the check-in counts of an event
+//	@Description	Returns how many attendees and tickets are checked in out of the total, for
+//	@Description	dashboards at the entrance. Requires check-in staff or an organizer.
+//	@Tags				check-in
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	database.CheckInCounts
+//	@Router			/api/v1/events/{id}/checkin/counts [get]
+//	@Security		BearerAuth
+func (app *app) getCheckInCounts(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermCheckIn,
+		"You do not have permission to check in attendees of this event")
+	if !ok {
+		return
+	}
+
+	counts, err := app.models.CheckIns.Counts(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve check-in counts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// checkInEvent loads the event named by the id parameter and checks that the
+// user may check attendees in and that it is open for check-in.
+func (app *app) checkInEvent(c *gin.Context) (*database.Event, bool) {
+	event, ok := app.eventWithPermission(c, database.PermCheckIn,
+		"You do not have permission to check in attendees of this event")
+	if !ok {
+		return nil, false
+	}
+	// Completed events stay open so devices that were offline can catch up.
+	if event.Status == database.EventDraft || event.Status == database.EventCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "This event is not open for check-in"})
+		return nil, false
+	}
+
+	return event, true
+}
+
+// userTicket loads the user's registration for the event named by the id
+// parameter. It writes the error response and returns false if they have none.
+func (app *app) userTicket(c *gin.Context) (*database.Attendee, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+
+	attendee, err := app.models.Attendees.GetByEventAndUser(id, app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return nil, false
+	}
+	if attendee == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You do not have a ticket for this event"})
+		return nil, false
+	}
+
+	return attendee, true
+}
+
+// ticketToken signs the ticket of an attendee. The token names the attendee
+// and the event, so it can be checked without a lookup and never matches a
+// later registration, as attendee ids are not reused.
+func (app *app) ticketToken(attendee *database.Attendee) string {
+	return app.ticketSigner.Sign(fmt.Sprintf("%d:%d", attendee.Id, attendee.EventId))
+}
+
+// ticketScan builds the scan of a ticket token by a device, rejecting tokens
+// that are forged or for another event as invalid.
+func (app *app) ticketScan(event *database.Event, deviceId string, userId int, token string,
+	scannedAt time.Time) *database.Scan {
+	scan := &database.Scan{
+		EventId:   event.Id,
+		DeviceId:  deviceId,
+		UserId:    userId,
+		ScannedAt: scannedAt,
+	}
+
+	attendeeId, eventId, ok := app.parseTicketToken(token)
+	if !ok || eventId != event.Id {
+		scan.Result = database.ScanInvalid
+		return scan
+	}
+
+	scan.AttendeeId = &attendeeId
+	return scan
+}
+
+// parseTicketToken returns the attendee and event a ticket token names and
+// reports whether its signature is valid.
+func (app *app) parseTicketToken(token string) (attendeeId, eventId int, ok bool) {
+	payload, ok := app.ticketSigner.Verify(token)
+	if !ok {
+		return 0, 0, false
+	}
+
+	a, e, ok := strings.Cut(payload, ":")
+	if !ok {
+		return 0, 0, false
+	}
+	attendeeId, err := strconv.Atoi(a)
+	if err != nil {
+		return 0, 0, false
+	}
+	eventId, err = strconv.Atoi(e)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return attendeeId, eventId, true
+}
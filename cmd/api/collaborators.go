@@ -0,0 +1,471 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+type inviteCollaboratorRequest struct {
+	UserId int    `json:"userId" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=co-owner editor checkin"`
+}
+
+type transferOwnershipRequest struct {
+	UserId int `json:"userId" binding:"required"`
+}
+
+// GetCollaborators returns the collaborators of an event
+//
+//	@Summary			Returns the collaborators of an event
+//	@Description	Returns the collaborators of an event, including pending invitations.
+//	@Description	Only the event's organizers and admins may list them.
+//	@Tags				collaborators
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.Collaborator
+//	@Router			/api/v1/events/{id}/collaborators [get]
+//	@Security		BearerAuth
+func (app *app) getCollaborators(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	allowed, err := app.can(app.getUserFromContext(c), event, database.PermViewCollaborators)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "You do not have permission to view the collaborators of this event"})
+		return
+	}
+
+	collaborators, err := app.models.Collaborators.GetByEvent(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collaborators"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborators)
+}
+
+// InviteCollaborator invites a user to help organize an event
+//
+//	@Summary			Invites a user to help organize an event
+//	@Description	Invites a user as co-owner, editor or check-in staff. The invitation takes effect once
+//	@Description	the user accepts it. Co-owners and the owner may invite editors and check-in staff,
+//	@Description	only the owner may invite co-owners.
+//	@Tags				collaborators
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int							true	"Event ID"
+//	@Param			invitation	body		inviteCollaboratorRequest	true	"User and role"
+//	@Success			201	{object}	database.Collaborator
+//	@Router			/api/v1/events/{id}/collaborators [post]
+//	@Security		BearerAuth
+func (app *app) inviteCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req inviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	perm := database.PermManageCollaborators
+	if req.Role == database.RoleCoOwner {
+		perm = database.PermManageCoOwners
+	}
+
+	user := app.getUserFromContext(c)
+	allowed, err := app.can(user, event, perm)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "You do not have permission to invite " + req.Role + " collaborators"})
+		return
+	}
+
+	if req.UserId == event.OwnerID {
+		c.JSON(http.StatusConflict, gin.H{"error": "The owner cannot be invited to their own event"})
+		return
+	}
+
+	invitee, err := app.models.Users.Get(req.UserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if invitee == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	collaborator := &database.Collaborator{
+		EventId:   event.Id,
+		UserId:    invitee.Id,
+		Role:      req.Role,
+		InvitedBy: user.Id,
+	}
+
+	err = app.models.Collaborators.Invite(collaborator)
+	if errors.Is(err, database.ErrAlreadyCollaborator) {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "User is already invited to or collaborating on this event"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite collaborator"})
+		return
+	}
+
+	app.notifyUser(invitee, database.NotifyCollaboration, event, "Invitation to organize "+event.Name,
+		fmt.Sprintf("%s invited you to help organize %s as %s.\n"+
+			"Accept the invitation with POST /api/v1/events/%d/collaborators/accept.",
+			user.Name, event.Name, req.Role, event.Id))
+
+	c.JSON(http.StatusCreated, collaborator)
+}
+
+// AcceptCollaboration accepts an invitation to help organize an event
+//
+//	@Summary			Accepts an invitation to help organize an event
+//	@Description	Accepts the authenticated user's pending invitation to an event
+//	@Tags				collaborators
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	database.Collaborator
+//	@Router			/api/v1/events/{id}/collaborators/accept [post]
+//	@Security		BearerAuth
+func (app *app) acceptCollaboration(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	accepted, err := app.models.Collaborators.Accept(id, user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+	if !accepted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	collaborator, err := app.models.Collaborators.Get(id, user.Id)
+	if err != nil || collaborator == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collaborator"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborator)
+}
+
+// RemoveCollaborator removes a collaborator from an event
+//
+//	@Summary			Removes a collaborator from an event
+//	@Description	Removes a collaborator or withdraws an invitation. Users may always remove
+//	@Description	themselves, which also declines a pending invitation. Otherwise the same rules
+//	@Description	as for inviting apply.
+//	@Tags				collaborators
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			userId	path	int	true	"User ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/collaborators/{userId} [delete]
+//	@Security		BearerAuth
+func (app *app) removeCollaborator(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	collaborator, err := app.models.Collaborators.Get(id, userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collaborator"})
+		return
+	}
+	if collaborator == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if user.Id != userId {
+		perm := database.PermManageCollaborators
+		if collaborator.Role == database.RoleCoOwner {
+			perm = database.PermManageCoOwners
+		}
+
+		allowed, err := app.can(user, event, perm)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "You do not have permission to remove this collaborator"})
+			return
+		}
+	}
+
+	if _, err := app.models.Collaborators.Delete(id, userId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove collaborator"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyCollaborations returns the events the user helps organize
+//
+//	@Summary			Returns the events the authenticated user helps organize
+//	@Description	Returns the authenticated user's collaborations and pending invitations, newest first
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	[]database.Collaborator
+//	@Router			/api/v1/users/me/collaborations [get]
+//	@Security		BearerAuth
+func (app *app) getMyCollaborations(c *gin.Context) {
+	user := app.getUserFromContext(c)
+
+	collaborations, err := app.models.Collaborators.GetByUser(user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collaborations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborations)
+}
+
+// TransferOwnership offers the ownership of an event to another user
+//
+//	@Summary			Offers the ownership of an event to another user
+//	@Description	Offers the event to another user, replacing any earlier offer. Ownership changes
+//	@Description	once the user accepts, after which the previous owner stays on as a co-owner.
+//	@Description	Only the owner may transfer an event.
+//	@Tags				collaborators
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int							true	"Event ID"
+//	@Param			transfer	body		transferOwnershipRequest	true	"New owner"
+//	@Success			201	{object}	database.OwnershipTransfer
+//	@Router			/api/v1/events/{id}/transfer [post]
+//	@Security		BearerAuth
+func (app *app) transferOwnership(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req transferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	allowed, err := app.can(user, event, database.PermTransferOwnership)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "Only the owner can transfer this event"})
+		return
+	}
+
+	if req.UserId == event.OwnerID {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already own this event"})
+		return
+	}
+
+	recipient, err := app.models.Users.Get(req.UserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if recipient == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	transfer := &database.OwnershipTransfer{
+		EventId:    event.Id,
+		FromUserId: event.OwnerID,
+		ToUserId:   recipient.Id,
+	}
+	if err := app.models.Collaborators.OfferTransfer(transfer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer event"})
+		return
+	}
+
+	app.notifyUser(recipient, database.NotifyCollaboration, event, "Ownership of "+event.Name,
+		fmt.Sprintf("%s would like to hand %s over to you.\n"+
+			"Accept with POST /api/v1/events/%d/transfer/accept or decline with DELETE /api/v1/events/%d/transfer.",
+			user.Name, event.Name, event.Id, event.Id))
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// AcceptOwnershipTransfer accepts the ownership of an event
+//
+//	@Summary			Accepts the ownership of an event
+//	@Description	Makes the authenticated user the owner of an event offered to them
+//	@Tags				collaborators
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	database.Event
+//	@Router			/api/v1/events/{id}/transfer/accept [post]
+//	@Security		BearerAuth
+func (app *app) acceptOwnershipTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	transfer, err := app.models.Collaborators.GetTransfer(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transfer"})
+		return
+	}
+	if transfer == nil || transfer.ToUserId != user.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return
+	}
+
+	err = app.models.Collaborators.AcceptTransfer(transfer)
+	if errors.Is(err, database.ErrStaleTransfer) {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "The event changed owner since it was offered to you"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept transfer"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil || event == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+
+	event.Localize(nil)
+	c.JSON(http.StatusOK, event)
+}
+
+// CancelOwnershipTransfer withdraws or declines the transfer of an event
+//
+//	@Summary			Withdraws or declines the transfer of an event
+//	@Description	Lets the owner withdraw a pending transfer, or its recipient decline it
+//	@Tags				collaborators
+//	@Param			id		path	int	true	"Event ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/transfer [delete]
+//	@Security		BearerAuth
+func (app *app) cancelOwnershipTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	transfer, err := app.models.Collaborators.GetTransfer(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transfer"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if transfer == nil || transfer.FromUserId != user.Id && transfer.ToUserId != user.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return
+	}
+
+	if _, err := app.models.Collaborators.CancelTransfer(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel transfer"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,698 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/markdown"
+	"github.com/Aergiaaa/gin-event/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type commentRequest struct {
+	Body string `json:"body" binding:"required,max=5000"`
+	// ParentId makes the comment a reply. Replies to a reply join its thread.
+	ParentId *int `json:"parentId"`
+}
+
+type editCommentRequest struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+type reportCommentRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type resolveReportRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss hide delete"`
+}
+
+// commentPage is a page of comments. NextCursor is the cursor of the next
+// page and unset on the last one.
+type commentPage struct {
+	// Pinned holds the pinned threads, on the first page of threads only.
+	Pinned     []*database.Comment `json:"pinned,omitempty"`
+	Comments   []*database.Comment `json:"comments"`
+	NextCursor *int                `json:"nextCursor,omitempty"`
+}
+
+// GetComments returns the discussion of an event
+//
+//	@Summary			Returns the discussion of an event
+//	@Description	Returns a page of the threads of an event, newest first, with the number of
+//	@Description	replies of each. The first page also lists the pinned threads. Pass nextCursor
+//	@Description	as cursor to get the next page. Hidden comments are only shown to the owner,
+//	@Description	co-owners and admins, and deleted ones only while they have replies.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Param			cursor	query		int	false	"Cursor of the page"
+//	@Param			limit	query		int	false	"Threads to return, at most 100"	default(20)
+//	@Success			200	{object}	commentPage
+//	@Router			/api/v1/events/{id}/comments [get]
+func (app *app) getComments(c *gin.Context) {
+	cursor, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	moderator, err := app.can(app.getUserFromContext(c), event, database.PermModerateComments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+
+	var page commentPage
+	if cursor == 0 {
+		page.Pinned, err = app.models.Comments.GetPinned(event.Id, moderator)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+			return
+		}
+	}
+
+	comments, err := app.models.Comments.GetThreads(event.Id, moderator, cursor, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+	page.Comments, page.NextCursor = paginate(comments, limit)
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetCommentReplies returns the replies to a comment
+//
+//	@Summary			Returns the replies to a comment
+//	@Description	Returns a page of the replies to a comment, oldest first. Pass nextCursor as
+//	@Description	cursor to get the next page.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			commentId	path		int	true	"Comment ID"
+//	@Param			cursor		query		int	false	"Cursor of the page"
+//	@Param			limit		query		int	false	"Replies to return, at most 100"	default(20)
+//	@Success			200	{object}	commentPage
+//	@Router			/api/v1/events/{id}/comments/{commentId}/replies [get]
+func (app *app) getCommentReplies(c *gin.Context) {
+	cursor, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	moderator, err := app.can(app.getUserFromContext(c), event, database.PermModerateComments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+
+	comment, ok := app.eventComment(c, event, moderator)
+	if !ok {
+		return
+	}
+
+	replies, err := app.models.Comments.GetReplies(comment.Id, moderator, cursor, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+
+	var page commentPage
+	page.Comments, page.NextCursor = paginate(replies, limit)
+
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateComment comments on an event
+//
+//	@Summary			Comments on an event
+//	@Description	Starts a thread, or replies to one if parentId is set. The body is markdown,
+//	@Description	returned along with its rendering as sanitized HTML. Anyone who can see the
+//	@Description	event may comment. Users can post a limited number of comments per minute.
+//	@Tags				comments
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int				true	"Event ID"
+//	@Param			comment	body		commentRequest	true	"Comment"
+//	@Success			201	{object}	database.Comment
+//	@Router			/api/v1/events/{id}/comments [post]
+//	@Security		BearerAuth
+func (app *app) createComment(c *gin.Context) {
+	var req commentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment must not be empty"})
+		return
+	}
+
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if !app.allow(c, app.commentLimiter, "comment:"+strconv.Itoa(user.Id)) {
+		return
+	}
+
+	comment := &database.Comment{
+		EventId:  event.Id,
+		UserId:   user.Id,
+		UserName: user.Name,
+		Body:     body,
+		HTML:     markdown.Render(body),
+	}
+
+	if req.ParentId != nil {
+		parent, err := app.models.Comments.Get(*req.ParentId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comment"})
+			return
+		}
+		if parent == nil || parent.EventId != event.Id || parent.Deleted || parent.HiddenAt != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+
+		comment.ParentId = &parent.Id
+		if parent.ParentId != nil {
+			comment.ParentId = parent.ParentId
+		}
+	}
+
+	if err := app.models.Comments.Insert(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment edits a comment
+//
+//	@Summary			Edits a comment
+//	@Description	Replaces the body of a comment and marks it as edited. Only its author can
+//	@Description	edit it.
+//	@Tags				comments
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int					true	"Event ID"
+//	@Param			commentId	path		int					true	"Comment ID"
+//	@Param			comment		body		editCommentRequest	true	"New body"
+//	@Success			200	{object}	database.Comment
+//	@Router			/api/v1/events/{id}/comments/{commentId} [put]
+//	@Security		BearerAuth
+func (app *app) updateComment(c *gin.Context) {
+	var req editCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment must not be empty"})
+		return
+	}
+
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	comment, ok := app.eventComment(c, event, true)
+	if !ok {
+		return
+	}
+	if comment.UserId != app.getUserFromContext(c).Id {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own comments"})
+		return
+	}
+
+	comment.Body = body
+	comment.HTML = markdown.Render(body)
+	if err := app.models.Comments.Update(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment deletes a comment
+//
+//	@Summary			Deletes a comment
+//	@Description	Erases a comment. Its replies stay in the thread. Its author, the owner,
+//	@Description	co-owners and admins can delete it. Open reports of it are closed.
+//	@Tags				comments
+//	@Param			id			path	int	true	"Event ID"
+//	@Param			commentId	path	int	true	"Comment ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/comments/{commentId} [delete]
+//	@Security		BearerAuth
+func (app *app) deleteComment(c *gin.Context) {
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	moderator, err := app.can(user, event, database.PermModerateComments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+
+	comment, ok := app.eventComment(c, event, true)
+	if !ok {
+		return
+	}
+	if comment.UserId != user.Id && !moderator {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to delete this comment"})
+		return
+	}
+
+	if err := app.models.Comments.Delete(comment.Id, user.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PinComment pins a thread
+//
+//	@Summary			Pins a thread
+//	@Description	Pins a top-level comment to the top of the discussion. Requires the owner, a
+//	@Description	co-owner or an admin.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			commentId	path		int	true	"Comment ID"
+//	@Success			200	{object}	database.Comment
+//	@Router			/api/v1/events/{id}/comments/{commentId}/pin [post]
+//	@Security		BearerAuth
+func (app *app) pinComment(c *gin.Context) {
+	app.setCommentPinned(c, true)
+}
+
+// UnpinComment unpins a thread
+//
+//	@Summary			Unpins a thread
+//	@Description	Moves a pinned comment back among the other threads. Requires the owner, a
+//	@Description	co-owner or an admin.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			commentId	path		int	true	"Comment ID"
+//	@Success			200	{object}	database.Comment
+//	@Router			/api/v1/events/{id}/comments/{commentId}/pin [delete]
+//	@Security		BearerAuth
+func (app *app) unpinComment(c *gin.Context) {
+	app.setCommentPinned(c, false)
+}
+
+func (app *app) setCommentPinned(c *gin.Context, pinned bool) {
+	event, ok := app.eventWithPermission(c, database.PermModerateComments,
+		"You do not have permission to pin comments on this event")
+	if !ok {
+		return
+	}
+
+	comment, ok := app.eventComment(c, event, true)
+	if !ok {
+		return
+	}
+	if comment.ParentId != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only threads can be pinned, not replies"})
+		return
+	}
+
+	if err := app.models.Comments.SetPinned(comment, pinned); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// HideComment hides a comment
+//
+//	@Summary			Hides a comment
+//	@Description	Hides a comment, and a thread's replies with it, from everyone but the owner,
+//	@Description	co-owners and admins. Open reports of it are closed. Requires the owner, a
+//	@Description	co-owner or an admin.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			commentId	path		int	true	"Comment ID"
+//	@Success			200	{object}	database.Comment
+//	@Router			/api/v1/events/{id}/comments/{commentId}/hide [post]
+//	@Security		BearerAuth
+func (app *app) hideComment(c *gin.Context) {
+	app.setCommentHidden(c, true)
+}
+
+// UnhideComment shows a hidden comment again
+//
+//	@Summary			Shows a hidden comment again
+//	@Description	Makes a hidden comment visible to everyone who can see the event. Requires the
+//	@Description	owner, a co-owner or an admin.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			commentId	path		int	true	"Comment ID"
+//	@Success			200	{object}	database.Comment
+//	@Router			/api/v1/events/{id}/comments/{commentId}/hide [delete]
+//	@Security		BearerAuth
+func (app *app) unhideComment(c *gin.Context) {
+	app.setCommentHidden(c, false)
+}
+
+func (app *app) setCommentHidden(c *gin.Context, hidden bool) {
+	event, ok := app.eventWithPermission(c, database.PermModerateComments,
+		"You do not have permission to hide comments on this event")
+	if !ok {
+		return
+	}
+
+	comment, ok := app.eventComment(c, event, true)
+	if !ok {
+		return
+	}
+
+	if err := app.models.Comments.SetHidden(comment, hidden,
+		app.getUserFromContext(c).Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// ReportComment reports an abusive comment
+//
+//	@Summary			Reports an abusive comment
+//	@Description	Puts a comment in the moderation queue of the event's organizers and admins.
+//	@Description	Users can report a comment once and file a limited number of reports per minute.
+//	@Tags				comments
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int						true	"Event ID"
+//	@Param			commentId	path		int						true	"Comment ID"
+//	@Param			report		body		reportCommentRequest	true	"Why the comment is abusive"
+//	@Success			201	{object}	database.CommentReport
+//	@Router			/api/v1/events/{id}/comments/{commentId}/report [post]
+//	@Security		BearerAuth
+func (app *app) reportComment(c *gin.Context) {
+	var req reportCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	comment, ok := app.eventComment(c, event, false)
+	if !ok {
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if comment.UserId == user.Id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own comment"})
+		return
+	}
+	if !app.allow(c, app.commentLimiter, "report:"+strconv.Itoa(user.Id)) {
+		return
+	}
+
+	report := &database.CommentReport{
+		CommentId: comment.Id,
+		EventId:   event.Id,
+		UserId:    user.Id,
+		Reason:    strings.TrimSpace(req.Reason),
+	}
+	err := app.models.Comments.Report(report)
+	if err == database.ErrCommentReported {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already reported this comment"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetCommentReports returns the moderation queue of an event
+//
+//	@Summary			Returns the moderation queue of an event
+//	@Description	Returns the reports of comments on the event, oldest first, with the reported
+//	@Description	comments. Requires the owner, a co-owner or an admin.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			id		path		int		true	"Event ID"
+//	@Param			status	query		string	false	"Only reports in this status"	Enums(open, dismissed, actioned)
+//	@Success			200	{object}	[]database.CommentReport
+//	@Router			/api/v1/events/{id}/comment-reports [get]
+//	@Security		BearerAuth
+func (app *app) getCommentReports(c *gin.Context) {
+	status, ok := reportStatusParam(c)
+	if !ok {
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermModerateComments,
+		"You do not have permission to moderate comments on this event")
+	if !ok {
+		return
+	}
+
+	reports, err := app.models.Comments.GetReports(event.Id, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetAllCommentReports returns the moderation queue of every event
+//
+//	@Summary			Returns the moderation queue of every event
+//	@Description	Returns the reports of comments on all events, oldest first, with the reported
+//	@Description	comments. Requires an admin.
+//	@Tags				comments
+//	@Produce			json
+//	@Param			status	query		string	false	"Only reports in this status"	Enums(open, dismissed, actioned)
+//	@Success			200	{object}	[]database.CommentReport
+//	@Router			/api/v1/comment-reports [get]
+//	@Security		BearerAuth
+func (app *app) getAllCommentReports(c *gin.Context) {
+	status, ok := reportStatusParam(c)
+	if !ok {
+		return
+	}
+
+	if !app.getUserFromContext(c).IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view the moderation queue"})
+		return
+	}
+
+	reports, err := app.models.Comments.GetReports(0, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveCommentReport resolves a report of a comment
+//
+//	@Summary			Resolves a report of a comment
+//	@Description	Dismisses an open report, or hides or deletes the reported comment, which
+//	@Description	closes every open report of it. Requires the owner, a co-owner or an admin.
+//	@Tags				comments
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int						true	"Event ID"
+//	@Param			reportId	path		int						true	"Report ID"
+//	@Param			resolution	body		resolveReportRequest	true	"What to do"
+//	@Success			200	{object}	database.CommentReport
+//	@Router			/api/v1/events/{id}/comment-reports/{reportId}/resolve [post]
+//	@Security		BearerAuth
+func (app *app) resolveCommentReport(c *gin.Context) {
+	var req resolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermModerateComments,
+		"You do not have permission to moderate comments on this event")
+	if !ok {
+		return
+	}
+
+	reportId, err := strconv.Atoi(c.Param("reportId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	report, err := app.models.Comments.GetReport(reportId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve report"})
+		return
+	}
+	if report == nil || report.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if report.Status != database.ReportOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report has already been resolved"})
+		return
+	}
+
+	comment, err := app.models.Comments.Get(report.CommentId)
+	if err != nil || comment == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comment"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	switch req.Action {
+	case "dismiss":
+		err = app.models.Comments.Dismiss(report, user.Id)
+	case "hide":
+		err = app.models.Comments.SetHidden(comment, true, user.Id)
+	case "delete":
+		err = app.models.Comments.Delete(comment.Id, user.Id)
+	}
+	if err == database.ErrReportResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report has already been resolved"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
+		return
+	}
+
+	if report, err = app.models.Comments.GetReport(report.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// eventComment loads the comment named by the commentId parameter and checks
+// that it belongs to event and is not deleted, and not hidden unless
+// withHidden is set. It writes the error response and returns false if the
+// comment cannot be used.
+func (app *app) eventComment(c *gin.Context, event *database.Event,
+	withHidden bool) (*database.Comment, bool) {
+	id, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return nil, false
+	}
+
+	comment, err := app.models.Comments.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comment"})
+		return nil, false
+	}
+	if comment == nil || comment.EventId != event.Id || comment.Deleted ||
+		comment.HiddenAt != nil && !withHidden {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return nil, false
+	}
+
+	return comment, true
+}
+
+func reportStatusParam(c *gin.Context) (string, bool) {
+	status := c.Query("status")
+	if status != "" && status != database.ReportOpen && status != database.ReportDismissed &&
+		status != database.ReportActioned {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return "", false
+	}
+	return status, true
+}
+
+// pageParams reads the cursor and limit query parameters of paginated
+// listings. It writes the error response and returns false if they are
+// invalid.
+func pageParams(c *gin.Context) (int, int, bool) {
+	cursor := 0
+	if s := c.Query("cursor"); s != "" {
+		var err error
+		cursor, err = strconv.Atoi(s)
+		if err != nil || cursor < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return 0, 0, false
+		}
+	}
+
+	limit := 20
+	if s := c.Query("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return 0, 0, false
+		}
+	}
+
+	return cursor, limit, true
+}
+
+// paginate trims comments, fetched with one more than limit, to a page and
+// returns the cursor of the next page, or nil if there is none.
+func paginate(comments []*database.Comment, limit int) ([]*database.Comment, *int) {
+	if len(comments) <= limit {
+		return comments, nil
+	}
+
+	comments = comments[:limit]
+	return comments, &comments[limit-1].Id
+}
+
+// allow counts an attempt by key against limiter. It writes the error
+// response and returns false if the limit is exceeded.
+func (app *app) allow(c *gin.Context, limiter *ratelimit.Limiter, key string) bool {
+	ok, wait := limiter.Allow(key)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again in " +
+			wait.Round(time.Second).String()})
+		return false
+	}
+	return true
+}
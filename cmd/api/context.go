@@ -22,3 +22,18 @@ func (app *app) getUserFromContext(c *gin.Context) *database.User {
 	return user
 }
 
+func (app *app) getSessionFromContext(c *gin.Context) *database.Session {
+	var emptySession database.Session
+
+	ctxSession, exist := c.Get("session")
+	if !exist {
+		return &emptySession
+	}
+
+	session, ok := ctxSession.(*database.Session)
+	if !ok {
+		return &emptySession
+	}
+
+	return session
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Aergiaaa/gin-event/internal/database"
 
@@ -12,11 +16,13 @@ import (
 // GetEvent returns a single event
 //
 //	@Summary			Returns a single event
-//	@Description	Returns a single event
+//	@Description	Returns a single event. Private events are only returned to their organizers,
+//	@Description	attendees and invited users.
 //	@Tags				events
 //	@Accept			json
 //	@Produce			json
-//	@Param			id		path		int	true	"Event ID"
+//	@Param			id		path		int		true	"Event ID"
+//	@Param			tz		query		string	false	"IANA time zone to also render the event times in"
 //	@Success			200	{object}	database.Event
 //	@Router			/api/v1/events/{id} [get]
 func (app *app) getEvent(c *gin.Context) {
@@ -26,6 +32,12 @@ func (app *app) getEvent(c *gin.Context) {
 		return
 	}
 
+	viewer, err := viewerLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone"})
+		return
+	}
+
 	event, err := app.models.Events.Get(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
@@ -36,32 +48,67 @@ func (app *app) getEvent(c *gin.Context) {
 		return
 	}
 
+	visible, err := app.canViewEvent(app.getUserFromContext(c), event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	event.Localize(viewer)
 	c.JSON(http.StatusOK, event)
 }
 
 // GetEvents returns all events
 //
 //	@Summary			Returns all events
-//	@Description	Returns all events
+//	@Description	Returns all published and completed public events. Drafts, unlisted and private
+//	@Description	events are never listed.
 //	@Tags				events
 //	@Accept			json
 //	@Produce			json
+//	@Param			includeCancelled	query		bool	false	"Also list cancelled events"
+//	@Param			period				query		string	false	"Only events that have not ended yet or that have ended"	Enums(upcoming, past)
+//	@Param			tz					query		string	false	"IANA time zone to also render the event times in"
 //	@Success			200		{object}		[]database.Event
 //	@Router			/api/v1/events [get]
 func (app *app) getAllEvents(c *gin.Context) {
-	events, err := app.models.Events.GetAll()
+	filter := database.EventFilter{
+		IncludeCancelled: c.Query("includeCancelled") == "true",
+		Period:           c.Query("period"),
+	}
+	if filter.Period != "" && filter.Period != database.PeriodUpcoming &&
+		filter.Period != database.PeriodPast {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period"})
+		return
+	}
+
+	viewer, err := viewerLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone"})
+		return
+	}
+
+	events, err := app.models.Events.GetAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
 		return
 	}
 
+	for _, e := range events {
+		e.Localize(viewer)
+	}
+
 	c.JSON(http.StatusOK, events)
 }
 
 // CreateEvent creates a new event
 //
 //	@Summary			Creates a new event
-//	@Description	Creates a new event
+//	@Description	Creates a new event. Events are created as drafts unless status is "published".
 //	@Tags				events
 //	@Accept			json
 //	@Produce			json
@@ -77,6 +124,11 @@ func (app *app) createEvent(c *gin.Context) {
 		return
 	}
 
+	if err := event.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	user := app.getUserFromContext(c)
 	event.OwnerID = user.Id
 
@@ -85,18 +137,22 @@ func (app *app) createEvent(c *gin.Context) {
 		return
 	}
 
+	event.Localize(nil)
 	c.JSON(http.StatusCreated, event)
 }
 
 // UpdateEvent updates an existing event
 //
 //	@Summary			Updates an existing event
-//	@Description	Updates an existing event
+//	@Description	Updates an existing event. Requires the owner, a co-owner or an editor. A change
+//	@Description	of date or location is notified to the attendees, or announced to them with
+//	@Description	announce, which requires the owner or a co-owner.
 //	@Tags				events
 //	@Accept			json
 //	@Produce			json
-//	@Param			id		path		int				true	"Event ID"
-//	@Param			event	body		database.Event	true	"Event"
+//	@Param			id			path		int				true	"Event ID"
+//	@Param			event		body		database.Event	true	"Event"
+//	@Param			announce	query		bool			false	"Announce a new date or location to the attendees"
 //	@Success			200	{object}	database.Event
 //	@Router			/api/v1/events/{id} [put]
 //	@Security		BearerAuth
@@ -118,11 +174,34 @@ func (app *app) updateEvent(c *gin.Context) {
 	}
 
 	user := app.getUserFromContext(c)
-	if existingEvent.OwnerID != user.Id {
+	allowed, err := app.can(user, existingEvent, database.PermEditEvent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
 		c.JSON(http.StatusForbidden,
 			gin.H{"error": "You do not have permission to update this event"})
 		return
 	}
+	announce := c.Query("announce") == "true"
+	if announce {
+		allowed, err := app.can(user, existingEvent, database.PermAnnounce)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "You do not have permission to make announcements for this event"})
+			return
+		}
+	}
+	if existingEvent.IsFinal() {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Cancelled or completed events cannot be updated"})
+		return
+	}
 
 	updatedEvent := &database.Event{}
 	if err := c.ShouldBindJSON(updatedEvent); err != nil {
@@ -130,19 +209,49 @@ func (app *app) updateEvent(c *gin.Context) {
 		return
 	}
 
+	if err := updatedEvent.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	updatedEvent.Id = id
+	updatedEvent.OwnerID = existingEvent.OwnerID
+	updatedEvent.Status = existingEvent.Status
+	updatedEvent.Rating, updatedEvent.Ratings = existingEvent.Rating, existingEvent.Ratings
+	if updatedEvent.AttendeeVisibility == "" {
+		updatedEvent.AttendeeVisibility = existingEvent.AttendeeVisibility
+	}
+	if updatedEvent.Visibility == "" {
+		updatedEvent.Visibility = existingEvent.Visibility
+	}
+
 	if err := app.models.Events.Update(updatedEvent); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
 		return
 	}
 
+	app.publishEvent(updatedEvent)
+	if what, changes := eventChanges(existingEvent, updatedEvent); what != "" {
+		if announce {
+			if err := app.announceChanges(existingEvent, updatedEvent, user.Id); err != nil {
+				log.Printf("error announcing changes to event %d: %v", id, err)
+			}
+		} else {
+			app.notifyAttendees(updatedEvent, database.NotifyEventUpdated,
+				"New "+what+" for "+updatedEvent.Name, changes)
+		}
+	}
+
+	updatedEvent.Localize(nil)
 	c.JSON(http.StatusOK, updatedEvent)
 }
 
 // DeleteEvent deletes an existing event
 //
 //	@Summary			Deletes an existing event
-//	@Description	Deletes an existing event
+//	@Description	Moves an event to the trash. It can be restored until it is purged after the
+//	@Description	retention period. Events with paid orders cannot be deleted until the orders are
+//	@Description	refunded. Requires the owner or a co-owner.
 //	@Tags				events
 //	@Accept			json
 //	@Produce			json
@@ -154,9 +263,9 @@ func (app *app) deleteEvent(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
 	}
 
-	user := app.getUserFromContext(c)
 	event, err := app.models.Events.Get(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
@@ -167,15 +276,226 @@ func (app *app) deleteEvent(c *gin.Context) {
 		return
 	}
 
-	if event.OwnerID != user.Id {
+	allowed, err := app.can(app.getUserFromContext(c), event, database.PermDeleteEvent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
 		c.JSON(http.StatusForbidden,
 			gin.H{"error": "You do not have permission to delete this event"})
 		return
 	}
 
 	if err := app.models.Events.Delete(id); err != nil {
+		if errors.Is(err, database.ErrEventHasPaidOrders) {
+			c.JSON(http.StatusConflict,
+				gin.H{"error": "Refund the paid orders of the event before deleting it"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{"message": "Event deleted successfully"})
 }
+
+// RestoreEvent restores a deleted event
+//
+//	@Summary			Restores a deleted event
+//	@Description	Restores a deleted event together with its attendees, as long as it has not been purged
+//	@Tags				events
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	database.Event
+//	@Router			/api/v1/events/{id}/restore [post]
+//	@Security		BearerAuth
+func (app *app) restoreEvent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.GetDeleted(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted event not found"})
+		return
+	}
+
+	allowed, err := app.can(app.getUserFromContext(c), event, database.PermRestoreEvent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "You do not have permission to restore this event"})
+		return
+	}
+
+	if err := app.models.Events.Restore(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore event"})
+		return
+	}
+
+	event.DeletedAt = nil
+	event.Localize(nil)
+	c.JSON(http.StatusOK, event)
+}
+
+type eventStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=published cancelled completed"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ChangeEventStatus moves an event through its lifecycle
+//
+//	@Summary			Moves an event through its lifecycle
+//	@Description	Publishes a draft, or cancels or completes a published event.
+//	@Description	Attendees are notified when an event is cancelled. Requires the owner or a co-owner.
+//	@Tags				events
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int					true	"Event ID"
+//	@Param			status	body		eventStatusRequest	true	"New status"
+//	@Success			200	{object}	database.Event
+//	@Router			/api/v1/events/{id}/status [post]
+//	@Security		BearerAuth
+func (app *app) changeEventStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req eventStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	allowed, err := app.can(user, event, database.PermChangeStatus)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "You do not have permission to change the status of this event"})
+		return
+	}
+
+	from := event.Status
+	err = app.models.Events.Transition(event, req.Status, user.Id, req.Reason)
+	if errors.Is(err, database.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": fmt.Sprintf("An event cannot go from %s to %s", from, req.Status)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change event status"})
+		return
+	}
+
+	app.publishEvent(event)
+	if event.Status == database.EventCancelled {
+		app.notifyCancellation(event, req.Reason)
+	}
+
+	event.Localize(nil)
+	c.JSON(http.StatusOK, event)
+}
+
+// GetEventStatusHistory returns the status changes of an event
+//
+//	@Summary			Returns the status changes of an event
+//	@Description	Returns every status change of an event, oldest first
+//	@Tags				events
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.EventStatusChange
+//	@Router			/api/v1/events/{id}/status-history [get]
+//	@Security		BearerAuth
+func (app *app) getEventStatusHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	allowed, err := app.can(app.getUserFromContext(c), event, database.PermViewHistory)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden,
+			gin.H{"error": "You do not have permission to view the history of this event"})
+		return
+	}
+
+	history, err := app.models.Events.GetStatusHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// notifyCancellation notifies every attendee of a cancelled event.
+func (app *app) notifyCancellation(event *database.Event, reason string) {
+	body := fmt.Sprintf("%s on %s at %s has been cancelled.",
+		event.Name, formatEventStart(event), event.Location)
+	if reason != "" {
+		body += "\n\nReason: " + reason
+	}
+
+	app.notifyAttendees(event, database.NotifyEventCancelled, "Event cancelled: "+event.Name, body)
+}
+
+// formatEventStart renders when an event starts, in its own time zone, for
+// use in emails.
+func formatEventStart(event *database.Event) string {
+	if event.AllDay {
+		return event.StartsAt.In(event.Loc()).Format("Mon 2 Jan 2006")
+	}
+	return event.StartsAt.In(event.Loc()).Format("Mon 2 Jan 2006 15:04 MST")
+}
+
+// viewerLocation returns the time zone named by the tz query parameter, or
+// nil if the request did not name one.
+func viewerLocation(c *gin.Context) (*time.Location, error) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return nil, nil
+	}
+
+	return time.LoadLocation(tz)
+}
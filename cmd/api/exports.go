@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportFormats maps the export formats to their content types.
+var exportFormats = map[string]string{
+	"csv":   "text/csv; charset=utf-8",
+	"jsonl": "application/x-ndjson",
+	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportColumns are the columns of CSV and XLSX exports that come before the
+// answers to the registration form.
+var exportColumns = []string{
+	"attendeeId", "userId", "name", "email", "rsvpStatus", "ticketTypeId", "ticketType",
+	"quantity", "registeredAt", "checkedInAt",
+}
+
+// eventReport sums up the registrations of an event and how many attendees
+// showed up.
+type eventReport struct {
+	EventId  int    `json:"eventId"`
+	Timezone string `json:"timezone"`
+	// Registrations and Tickets count the attendees and the tickets they hold.
+	Registrations    int `json:"registrations"`
+	Tickets          int `json:"tickets"`
+	PendingRequests  int `json:"pendingRequests"`
+	RejectedRequests int `json:"rejectedRequests"`
+	CheckedIn        int `json:"checkedIn"`
+	CheckedInTickets int `json:"checkedInTickets"`
+	// ShowUpRate is the share of registrations that were checked in.
+	ShowUpRate float64 `json:"showUpRate"`
+	// ByDay counts the registrations per day in the event's time zone, leaving
+	// out days without any. Undated counts the registrations whose time is not
+	// known, which ByDay leaves out as well.
+	ByDay        []reportDay        `json:"byDay"`
+	Undated      int                `json:"undated"`
+	ByTicketType []reportTicketType `json:"byTicketType"`
+}
+
+type reportDay struct {
+	Date          string `json:"date"`
+	Registrations int    `json:"registrations"`
+	Tickets       int    `json:"tickets"`
+}
+
+type reportTicketType struct {
+	TicketTypeId  *int   `json:"ticketTypeId,omitempty"`
+	Name          string `json:"name"`
+	Registrations int    `json:"registrations"`
+	Tickets       int    `json:"tickets"`
+	CheckedIn     int    `json:"checkedIn"`
+}
+
+// ExportAttendees exports the attendee list of an event
+//
+//	@Summary			Exports the attendee list of an event
+//	@Description	Streams every attendee of the event with their email, RSVP status, ticket type,
+//	@Description	registration and check-in time, followed by the pending and rejected join
+//	@Description	requests, as CSV, JSON Lines or XLSX. Times are in the event's time zone. CSV
+//	@Description	and XLSX exports have a column per question of the registration form, named by
+//	@Description	its id. Requires the owner.
+//	@Tags				attendees
+//	@Produce			text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			id		path	int		true	"Event ID"
+//	@Param			format	query	string	false	"Export format"	Enums(csv, jsonl, xlsx)	default(csv)
+//	@Success			200	{file}	binary
+//	@Router			/api/v1/events/{id}/attendees/export [get]
+//	@Security		BearerAuth
+func (app *app) exportAttendees(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := exportFormats[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, jsonl or xlsx"})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermExportAttendees,
+		"Only the owner can export the attendees of this event")
+	if !ok {
+		return
+	}
+
+	form, err := app.models.RegistrationForms.Get(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration form"})
+		return
+	}
+
+	// Large exports to slow clients take longer than the write timeout of the
+	// server.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("error clearing the write deadline of an export: %v", err)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="event-%d-attendees.%s"`, event.Id, format))
+	c.Header("Cache-Control", "private, no-store")
+
+	var w recordWriter
+	switch format {
+	case "csv":
+		w = newCSVRecordWriter(c.Writer, event.Loc(), form.Questions)
+	case "jsonl":
+		w = newJSONRecordWriter(c.Writer, event.Loc())
+	case "xlsx":
+		w = newXLSXRecordWriter(c.Writer, event.Loc(), form.Questions)
+	}
+
+	err = app.models.Attendees.EachRecord(event.Id, w.WriteRecord)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export attendees"})
+			return
+		}
+		// The export is already on its way, all that is left is to cut it short.
+		log.Printf("error exporting attendees of event %d: %v", event.Id, err)
+	}
+}
+
+// GetEventReport returns the registration report of an event
+//
+//	@Summary			Returns the registration report of an event
+//	@Description	Returns the registrations and tickets of the event, its join requests, how many
+//	@Description	attendees were checked in and the show-up rate, with the registrations per day
+//	@Description	in the event's time zone and per ticket type. Registrations made before their
+//	@Description	time was recorded are counted as undated. Requires the owner.
+//	@Tags				attendees
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	eventReport
+//	@Router			/api/v1/events/{id}/report [get]
+//	@Security		BearerAuth
+func (app *app) getEventReport(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermExportAttendees,
+		"Only the owner can view the report of this event")
+	if !ok {
+		return
+	}
+
+	loc := event.Loc()
+	report := eventReport{EventId: event.Id, Timezone: loc.String()}
+	days := map[string]*reportDay{}
+	types := map[int]*reportTicketType{}
+	var noType *reportTicketType
+
+	err := app.models.Attendees.EachRecord(event.Id, func(r *database.AttendeeRecord) error {
+		switch r.RSVPStatus {
+		case database.RSVPPending:
+			report.PendingRequests++
+			return nil
+		case database.RSVPRejected:
+			report.RejectedRequests++
+			return nil
+		}
+
+		report.Registrations++
+		report.Tickets += r.Quantity
+		if r.CheckedInAt != nil {
+			report.CheckedIn++
+			report.CheckedInTickets += r.Quantity
+		}
+
+		if r.RegisteredAt != nil {
+			date := r.RegisteredAt.In(loc).Format(time.DateOnly)
+			if days[date] == nil {
+				days[date] = &reportDay{Date: date}
+			}
+			days[date].Registrations++
+			days[date].Tickets += r.Quantity
+		} else {
+			report.Undated++
+		}
+
+		var t *reportTicketType
+		if r.TicketTypeId == nil {
+			if noType == nil {
+				noType = &reportTicketType{}
+			}
+			t = noType
+		} else {
+			if types[*r.TicketTypeId] == nil {
+				id := *r.TicketTypeId
+				types[id] = &reportTicketType{TicketTypeId: &id, Name: r.TicketType}
+			}
+			t = types[*r.TicketTypeId]
+		}
+		t.Registrations++
+		t.Tickets += r.Quantity
+		if r.CheckedInAt != nil {
+			t.CheckedIn++
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	if report.Registrations > 0 {
+		report.ShowUpRate = float64(report.CheckedIn) / float64(report.Registrations)
+	}
+
+	report.ByDay = make([]reportDay, 0, len(days))
+	for _, d := range days {
+		report.ByDay = append(report.ByDay, *d)
+	}
+	sort.Slice(report.ByDay, func(i, j int) bool { return report.ByDay[i].Date < report.ByDay[j].Date })
+
+	report.ByTicketType = make([]reportTicketType, 0, len(types)+1)
+	for _, t := range types {
+		report.ByTicketType = append(report.ByTicketType, *t)
+	}
+	sort.Slice(report.ByTicketType, func(i, j int) bool {
+		return *report.ByTicketType[i].TicketTypeId < *report.ByTicketType[j].TicketTypeId
+	})
+	if noType != nil {
+		report.ByTicketType = append(report.ByTicketType, *noType)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// recordWriter writes attendee records in an export format. Close finishes
+// the export and must be called after the last record.
+type recordWriter interface {
+	WriteRecord(r *database.AttendeeRecord) error
+	Close() error
+}
+
+// exportHeader is the header of CSV and XLSX exports: exportColumns followed
+// by a column per question.
+func exportHeader(questions []*database.Question) []string {
+	header := slices.Clone(exportColumns)
+	for _, q := range questions {
+		header = append(header, q.Id)
+	}
+	return header
+}
+
+// exportFields renders a record as the cells of exportHeader, with its times
+// in loc.
+func exportFields(r *database.AttendeeRecord, loc *time.Location,
+	questions []*database.Question) []string {
+	optionalInt := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	optionalTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.In(loc).Format(time.RFC3339)
+	}
+
+	fields := []string{
+		optionalInt(r.AttendeeId), strconv.Itoa(r.UserId), r.Name, r.Email, r.RSVPStatus,
+		optionalInt(r.TicketTypeId), r.TicketType, strconv.Itoa(r.Quantity),
+		optionalTime(r.RegisteredAt), optionalTime(r.CheckedInAt),
+	}
+	for _, q := range questions {
+		fields = append(fields, r.Answers.Text(q.Id))
+	}
+	return fields
+}
+
+type csvRecordWriter struct {
+	w         *csv.Writer
+	loc       *time.Location
+	questions []*database.Question
+	header    bool
+}
+
+func newCSVRecordWriter(w io.Writer, loc *time.Location,
+	questions []*database.Question) *csvRecordWriter {
+	return &csvRecordWriter{w: csv.NewWriter(w), loc: loc, questions: questions}
+}
+
+func (cw *csvRecordWriter) writeHeader() error {
+	if cw.header {
+		return nil
+	}
+	cw.header = true
+	return cw.w.Write(exportHeader(cw.questions))
+}
+
+func (cw *csvRecordWriter) WriteRecord(r *database.AttendeeRecord) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	fields := exportFields(r, cw.loc, cw.questions)
+	for i, f := range fields {
+		// Keep spreadsheets from running names that look like formulas.
+		if f != "" && strings.ContainsRune("=+-@\t\r", rune(f[0])) {
+			fields[i] = "'" + f
+		}
+	}
+	return cw.w.Write(fields)
+}
+
+func (cw *csvRecordWriter) Close() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonRecordWriter struct {
+	enc *json.Encoder
+	loc *time.Location
+}
+
+func newJSONRecordWriter(w io.Writer, loc *time.Location) *jsonRecordWriter {
+	return &jsonRecordWriter{enc: json.NewEncoder(w), loc: loc}
+}
+
+func (jw *jsonRecordWriter) WriteRecord(r *database.AttendeeRecord) error {
+	rec := *r
+	if rec.RegisteredAt != nil {
+		t := rec.RegisteredAt.In(jw.loc)
+		rec.RegisteredAt = &t
+	}
+	if rec.CheckedInAt != nil {
+		t := rec.CheckedInAt.In(jw.loc)
+		rec.CheckedInAt = &t
+	}
+	return jw.enc.Encode(&rec)
+}
+
+func (jw *jsonRecordWriter) Close() error {
+	return nil
+}
+
+// xlsxRecordWriter streams the rows into a worksheet. The workbook can only
+// be written out as a whole, so it goes to w on Close; excelize keeps the
+// rows in a temporary file meanwhile.
+type xlsxRecordWriter struct {
+	w         io.Writer
+	f         *excelize.File
+	sw        *excelize.StreamWriter
+	loc       *time.Location
+	questions []*database.Question
+	row       int
+	err       error
+}
+
+const xlsxSheet = "Attendees"
+
+func newXLSXRecordWriter(w io.Writer, loc *time.Location,
+	questions []*database.Question) *xlsxRecordWriter {
+	xw := &xlsxRecordWriter{w: w, f: excelize.NewFile(), loc: loc, questions: questions}
+
+	if xw.err = xw.f.SetSheetName("Sheet1", xlsxSheet); xw.err != nil {
+		return xw
+	}
+	if xw.sw, xw.err = xw.f.NewStreamWriter(xlsxSheet); xw.err != nil {
+		return xw
+	}
+	xw.err = xw.writeRow(exportHeader(questions))
+	return xw
+}
+
+func (xw *xlsxRecordWriter) writeRow(fields []string) error {
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		values[i] = f
+	}
+	return xw.sw.SetRow(cell, values)
+}
+
+func (xw *xlsxRecordWriter) WriteRecord(r *database.AttendeeRecord) error {
+	if xw.err != nil {
+		return xw.err
+	}
+	return xw.writeRow(exportFields(r, xw.loc, xw.questions))
+}
+
+func (xw *xlsxRecordWriter) Close() error {
+	defer xw.f.Close()
+
+	if xw.err != nil {
+		return xw.err
+	}
+	if err := xw.sw.Flush(); err != nil {
+		return err
+	}
+	return xw.f.Write(xw.w)
+}
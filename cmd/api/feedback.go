@@ -0,0 +1,394 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTextResults caps how many answers to a text question feedback results
+// list.
+const maxTextResults = 100
+
+type feedbackRequest struct {
+	Rating  int              `json:"rating" binding:"required,min=1,max=5"`
+	Answers database.Answers `json:"answers"`
+}
+
+// feedbackResults sums up the feedback attendees gave on an event.
+type feedbackResults struct {
+	EventId int `json:"eventId"`
+	// Responses is how many attendees gave feedback, out of Attendees.
+	Responses    int     `json:"responses"`
+	Attendees    int     `json:"attendees"`
+	ResponseRate float64 `json:"responseRate"`
+	// Rating is the average rating, unset without responses. RatingCounts
+	// counts the responses per rating from 1 to 5.
+	Rating       *float64           `json:"rating,omitempty"`
+	RatingCounts map[int]int        `json:"ratingCounts"`
+	Questions    []*questionResults `json:"questions"`
+}
+
+// questionResults sums up the answers to a survey question. Select,
+// multi-select and checkbox questions count the answers per option, with
+// checkboxes having the options yes and no, number questions have the
+// average, lowest and highest answer and text questions list the answers,
+// newest first.
+type questionResults struct {
+	Id       string         `json:"id"`
+	Label    string         `json:"label"`
+	Type     string         `json:"type"`
+	Answered int            `json:"answered"`
+	Counts   map[string]int `json:"counts,omitempty"`
+	Average  *float64       `json:"average,omitempty"`
+	Min      *float64       `json:"min,omitempty"`
+	Max      *float64       `json:"max,omitempty"`
+	Answers  []string       `json:"answers,omitempty"`
+}
+
+// GetFeedbackSurvey returns the feedback survey of an event
+//
+//	@Summary			Returns the feedback survey of an event
+//	@Description	Returns the questions attendees answer along with their rating once the event
+//	@Description	has ended. Events without questions return an empty survey.
+//	@Tags				feedback
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	database.FeedbackSurvey
+//	@Router			/api/v1/events/{id}/feedback-survey [get]
+func (app *app) getFeedbackSurvey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	visible, err := app.canViewEvent(app.getUserFromContext(c), event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	survey, err := app.models.Feedback.GetSurvey(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feedback survey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, survey)
+}
+
+// UpdateFeedbackSurvey replaces the feedback survey of an event
+//
+//	@Summary			Replaces the feedback survey of an event
+//	@Description	Replaces the questions attendees answer along with their rating. Questions
+//	@Description	follow the rules of registration form questions. Feedback given before is kept.
+//	@Description	Requires the owner, a co-owner or an editor.
+//	@Tags				feedback
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int						true	"Event ID"
+//	@Param			survey	body		database.FeedbackSurvey	true	"Feedback survey"
+//	@Success			200	{object}	database.FeedbackSurvey
+//	@Router			/api/v1/events/{id}/feedback-survey [put]
+//	@Security		BearerAuth
+func (app *app) updateFeedbackSurvey(c *gin.Context) {
+	var survey database.FeedbackSurvey
+	if err := c.ShouldBindJSON(&survey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := survey.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermEditEvent,
+		"You do not have permission to edit the feedback survey of this event")
+	if !ok {
+		return
+	}
+
+	survey.EventId = event.Id
+	if err := app.models.Feedback.SetSurvey(&survey, app.getUserFromContext(c).Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update feedback survey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, survey)
+}
+
+// GiveFeedback rates an event and answers its feedback survey
+//
+//	@Summary			Rates an event and answers its feedback survey
+//	@Description	Records a rating from 1 to 5 and the answers to the feedback survey. Only
+//	@Description	attendees can give feedback, once, after the event has ended. The rating counts
+//	@Description	towards the public rating of the event.
+//	@Tags				feedback
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int				true	"Event ID"
+//	@Param			feedback	body		feedbackRequest	true	"Rating and answers"
+//	@Success			201	{object}	database.Feedback
+//	@Failure			400	{object}	invalidAnswersResponse
+//	@Router			/api/v1/events/{id}/feedback [post]
+//	@Security		BearerAuth
+func (app *app) giveFeedback(c *gin.Context) {
+	var req feedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	attendee, err := app.models.Attendees.GetByEventAndUser(event.Id, user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attendee"})
+		return
+	}
+	if attendee == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only attendees can give feedback on this event"})
+		return
+	}
+
+	if !event.TookPlace(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedback opens once the event has ended"})
+		return
+	}
+
+	survey, err := app.models.Feedback.GetSurvey(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feedback survey"})
+		return
+	}
+
+	answers, problems := survey.Check(req.Answers)
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, invalidAnswersResponse{
+			Error:   "Some answers to the feedback survey are invalid",
+			Answers: problems,
+		})
+		return
+	}
+
+	feedback := &database.Feedback{
+		EventId: event.Id,
+		UserId:  user.Id,
+		Rating:  req.Rating,
+		Answers: answers,
+	}
+	err = app.models.Feedback.Insert(feedback)
+	if err == database.ErrFeedbackGiven {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already gave feedback on this event"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save feedback"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, feedback)
+}
+
+// GetFeedbackResults sums up the feedback on an event
+//
+//	@Summary			Sums up the feedback on an event
+//	@Description	Returns how many attendees gave feedback, the average rating and how often each
+//	@Description	rating was given, and the results of each survey question: how often each
+//	@Description	option was chosen, the average, lowest and highest number or the latest text
+//	@Description	answers, up to 100. Requires the owner.
+//	@Tags				feedback
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	feedbackResults
+//	@Router			/api/v1/events/{id}/feedback/results [get]
+//	@Security		BearerAuth
+func (app *app) getFeedbackResults(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermViewFeedback,
+		"Only the owner can view the feedback on this event")
+	if !ok {
+		return
+	}
+
+	survey, err := app.models.Feedback.GetSurvey(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feedback survey"})
+		return
+	}
+
+	feedback, err := app.models.Feedback.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feedback"})
+		return
+	}
+
+	attendees, err := app.models.Attendees.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attendees"})
+		return
+	}
+
+	results := feedbackResults{
+		EventId:      event.Id,
+		Responses:    len(feedback),
+		Attendees:    len(attendees),
+		RatingCounts: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+		Questions:    make([]*questionResults, len(survey.Questions)),
+	}
+	if results.Attendees > 0 {
+		results.ResponseRate = float64(results.Responses) / float64(results.Attendees)
+	}
+
+	total := 0
+	for _, f := range feedback {
+		total += f.Rating
+		results.RatingCounts[f.Rating]++
+	}
+	if len(feedback) > 0 {
+		rating := math.Round(float64(total)/float64(len(feedback))*100) / 100
+		results.Rating = &rating
+	}
+
+	for i, q := range survey.Questions {
+		results.Questions[i] = summarizeAnswers(q, feedback)
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// summarizeAnswers sums up the answers to q in feedback, which is sorted
+// newest first.
+func summarizeAnswers(q *database.Question, feedback []*database.Feedback) *questionResults {
+	r := &questionResults{Id: q.Id, Label: q.Label, Type: q.Type}
+	var sum float64
+
+	switch q.Type {
+	case database.QuestionSelect, database.QuestionMultiSelect:
+		r.Counts = map[string]int{}
+		for _, o := range q.Options {
+			r.Counts[o] = 0
+		}
+	case database.QuestionCheckbox:
+		r.Counts = map[string]int{"yes": 0, "no": 0}
+	}
+
+	for _, f := range feedback {
+		v, ok := f.Answers[q.Id]
+		if !ok {
+			continue
+		}
+		r.Answered++
+
+		switch v := v.(type) {
+		case string:
+			if q.Type == database.QuestionText {
+				if len(r.Answers) < maxTextResults {
+					r.Answers = append(r.Answers, v)
+				}
+			} else if _, ok := r.Counts[v]; ok {
+				r.Counts[v]++
+			}
+		case []any:
+			for _, o := range v {
+				if s, ok := o.(string); ok {
+					if _, ok := r.Counts[s]; ok {
+						r.Counts[s]++
+					}
+				}
+			}
+		case bool:
+			r.Counts[f.Answers.Text(q.Id)]++
+		case float64:
+			sum += v
+			if r.Min == nil || v < *r.Min {
+				r.Min = &v
+			}
+			if r.Max == nil || v > *r.Max {
+				r.Max = &v
+			}
+		}
+	}
+
+	if q.Type == database.QuestionNumber && r.Answered > 0 {
+		average := math.Round(sum/float64(r.Answered)*100) / 100
+		r.Average = &average
+	}
+
+	return r
+}
+
+// inviteFeedback asks the attendees of the events that ended feedbackDelay
+// ago to rate them. Every event is only handled once, events whose attendees
+// could not be retrieved are tried again on the next run.
+func (app *app) inviteFeedback() error {
+	events, err := app.models.Feedback.DueInvites(time.Now().Add(-app.feedbackDelay))
+	if err != nil {
+		return err
+	}
+
+	invited := 0
+	for _, event := range events {
+		attendees, err := app.models.Attendees.GetByEvent(event.Id)
+		if err != nil {
+			log.Printf("error retrieving attendees of event %d: %v", event.Id, err)
+			continue
+		}
+
+		// Mark the event first, so that its attendees are not asked twice if
+		// that fails.
+		if err := app.models.Feedback.MarkInvited(event.Id); err != nil {
+			log.Printf("error marking event %d as invited to give feedback: %v", event.Id, err)
+			continue
+		}
+
+		body := fmt.Sprintf("Thanks for attending %s. Rate it and tell the organizers what you "+
+			"thought: see GET /api/v1/events/%d/feedback-survey and answer with "+
+			"POST /api/v1/events/%d/feedback.", event.Name, event.Id, event.Id)
+		for _, user := range attendees {
+			app.notify(user, database.NotifyFeedbackRequest, event, "How was "+event.Name+"?", body)
+		}
+		invited++
+	}
+
+	if invited > 0 {
+		log.Printf("invited the attendees of %d events to give feedback", invited)
+	}
+	return nil
+}
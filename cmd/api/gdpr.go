@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/gdpr"
+
+	"github.com/gin-gonic/gin"
+)
+
+type eraseAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// RequestDataExport starts building an archive of the user's data
+//
+//	@Summary			Starts building an archive of the authenticated user's data
+//	@Description	Starts building a zip archive with the profile, owned events, attendances and
+//	@Description	login history of the authenticated user. Poll the export until it is ready.
+//	@Tags				Users
+//	@Produce			json
+//	@Success			202	{object}	database.DataExport
+//	@Router			/api/v1/users/me/export [post]
+//	@Security		BearerAuth
+func (app *app) requestDataExport(c *gin.Context) {
+	user := app.getUserFromContext(c)
+
+	export := &database.DataExport{UserId: user.Id}
+	if err := app.models.DataExports.Insert(export); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+
+	app.audit(fmt.Sprintf("user:%d", user.Id), "data.export", user.Id,
+		fmt.Sprintf("export %d requested", export.Id))
+
+	app.background(func() {
+		path, err := gdpr.ExportToFile(&app.models, user.Id, export.Id, app.exportDir)
+		if err != nil {
+			log.Printf("error building export %d: %v", export.Id, err)
+		}
+
+		if err := app.models.DataExports.Complete(export.Id, path); err != nil {
+			log.Printf("error completing export %d: %v", export.Id, err)
+		}
+	})
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetDataExports returns the user's data exports
+//
+//	@Summary			Returns the authenticated user's data exports
+//	@Description	Returns the authenticated user's data exports and their status
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	[]database.DataExport
+//	@Router			/api/v1/users/me/exports [get]
+//	@Security		BearerAuth
+func (app *app) getDataExports(c *gin.Context) {
+	user := app.getUserFromContext(c)
+
+	exports, err := app.models.DataExports.GetByUser(user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve exports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+// DownloadDataExport downloads a finished data export
+//
+//	@Summary			Downloads a finished data export
+//	@Description	Downloads a finished data export as a zip archive
+//	@Tags				Users
+//	@Produce			application/zip
+//	@Param			id		path	int	true	"Export ID"
+//	@Success			200
+//	@Router			/api/v1/users/me/exports/{id}/download [get]
+//	@Security		BearerAuth
+func (app *app) downloadDataExport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	export, err := app.models.DataExports.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve export"})
+		return
+	}
+	if export == nil || export.UserId != user.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	if export.Status != database.ExportReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready", "status": export.Status})
+		return
+	}
+
+	app.audit(fmt.Sprintf("user:%d", user.Id), "data.export.download", user.Id,
+		fmt.Sprintf("export %d downloaded", export.Id))
+
+	c.FileAttachment(export.FilePath, fmt.Sprintf("gin-event-export-%d.zip", export.Id))
+}
+
+// EraseCurrentUser erases the authenticated user's personal data
+//
+//	@Summary			Erases the authenticated user's personal data
+//	@Description	Anonymizes the account instead of deleting it, so events keep their attendance
+//	@Description	figures. The account can no longer be used afterwards.
+//	@Tags				Users
+//	@Accept			json
+//	@Param			account	body	eraseAccountRequest	true	"Confirmation"
+//	@Success			204
+//	@Router			/api/v1/users/me/erasure [post]
+//	@Security		BearerAuth
+func (app *app) eraseCurrentUser(c *gin.Context) {
+	var req eraseAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+
+	if match, _ := app.verifyPassword(user, req.Password); !match {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	if err := gdpr.Erase(&app.models, user.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to erase account"})
+		return
+	}
+
+	app.audit(fmt.Sprintf("user:%d", user.Id), "data.erase", user.Id, "erased on request of the user")
+
+	c.Status(http.StatusNoContent)
+}
+
+// audit records an action on a user's data. Failures are logged rather than
+// returned since the action itself has already happened.
+func (app *app) audit(actor, action string, userId int, details string) {
+	entry := &database.AuditEntry{
+		Actor:   actor,
+		Action:  action,
+		UserId:  userId,
+		Details: details,
+	}
+
+	if err := app.models.Audit.Insert(entry); err != nil {
+		log.Printf("error writing audit entry %s for user %d: %v", action, userId, err)
+	}
+}
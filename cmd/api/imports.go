@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/importer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize is the largest CSV file that can be uploaded.
+const maxImportSize = 32 << 20
+
+type importRequest struct {
+	// Mapping is a JSON object mapping fields to the columns holding them.
+	Mapping string `form:"mapping"`
+	DryRun  bool   `form:"dryRun"`
+}
+
+type attendeeImportRequest struct {
+	importRequest
+	MatchUsers bool `form:"matchUsers"`
+}
+
+type invalidImportResponse struct {
+	Error  string           `json:"error"`
+	Report *importer.Report `json:"report"`
+}
+
+// ImportEvents imports events from a CSV file
+//
+//	@Summary			Imports events from a CSV file
+//	@Description	Creates an event owned by the authenticated user for every row of a CSV file
+//	@Description	with a header. The columns are read by field name (name, description, location,
+//	@Description	startsAt, endsAt, timezone, allDay, attendeeVisibility, visibility,
+//	@Description	approvalRequired, status) unless mapping names another column for a field.
+//	@Description	Rows are checked by the same rules as events created one by one, and nothing is
+//	@Description	imported if any row is invalid. A dry run only returns the report. The import
+//	@Description	then runs in the background in chunks of 500 rows, each in its own transaction.
+//	@Tags				imports
+//	@Accept			mpfd
+//	@Produce			json
+//	@Param			file		formData	file	true	"CSV file"
+//	@Param			mapping		formData	string	false	"JSON object mapping fields to columns"
+//	@Param			dryRun		formData	bool	false	"Only validate the file"
+//	@Success			202	{object}	database.ImportJob
+//	@Success			200	{object}	importer.Report
+//	@Failure			422	{object}	invalidImportResponse
+//	@Router			/api/v1/events/import [post]
+//	@Security		BearerAuth
+func (app *app) importEvents(c *gin.Context) {
+	var req importRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job := &database.ImportJob{
+		UserId: app.getUserFromContext(c).Id,
+		Kind:   database.ImportEvents,
+	}
+
+	app.startImport(c, job, &req)
+}
+
+// ImportAttendees imports attendees of an event from a CSV file
+//
+//	@Summary			Imports attendees of an event from a CSV file
+//	@Description	Adds the user of every row of a CSV file with a header to the event. Users are
+//	@Description	identified by the userId column, or by the email column when matchUsers is set.
+//	@Description	The ticketTypeId and quantity columns choose their tickets. Columns are read by
+//	@Description	field name unless mapping names another column for a field. Nothing is imported
+//	@Description	if any row is invalid, and a dry run only returns the report. The import then
+//	@Description	runs in the background in chunks of 500 rows, each in its own transaction, and
+//	@Description	skips users who joined meanwhile. Requires the owner, a co-owner or an editor,
+//	@Description	and the owner or a co-owner to match users by email.
+//	@Tags				imports
+//	@Accept			mpfd
+//	@Produce			json
+//	@Param			id			path		int		true	"Event ID"
+//	@Param			file		formData	file	true	"CSV file"
+//	@Param			mapping		formData	string	false	"JSON object mapping fields to columns"
+//	@Param			matchUsers	formData	bool	false	"Identify users by email"
+//	@Param			dryRun		formData	bool	false	"Only validate the file"
+//	@Success			202	{object}	database.ImportJob
+//	@Success			200	{object}	importer.Report
+//	@Failure			422	{object}	invalidImportResponse
+//	@Router			/api/v1/events/{id}/attendees/import [post]
+//	@Security		BearerAuth
+func (app *app) importAttendees(c *gin.Context) {
+	var req attendeeImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermManageAttendees,
+		"You are not allowed to add attendees to this event")
+	if !ok {
+		return
+	}
+	if event.IsFinal() {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Attendees cannot be added to cancelled or completed events"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if req.MatchUsers {
+		// Matching emails tells whether they belong to an account, which only
+		// those who can export the attendees' emails may learn.
+		allowed, err := app.can(user, event, database.PermExportAttendees)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "Only the owner can import attendees by email"})
+			return
+		}
+	}
+
+	job := &database.ImportJob{
+		UserId:     user.Id,
+		Kind:       database.ImportAttendees,
+		EventId:    &event.Id,
+		MatchUsers: req.MatchUsers,
+	}
+
+	app.startImport(c, job, &req.importRequest)
+}
+
+// GetImports returns the user's imports
+//
+//	@Summary			Returns the authenticated user's imports
+//	@Description	Returns the imports the authenticated user started, newest first, with their
+//	@Description	progress
+//	@Tags				imports
+//	@Produce			json
+//	@Success			200	{object}	[]database.ImportJob
+//	@Router			/api/v1/users/me/imports [get]
+//	@Security		BearerAuth
+func (app *app) getImports(c *gin.Context) {
+	jobs, err := app.models.Imports.GetByUser(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve imports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetImport returns an import
+//
+//	@Summary			Returns an import
+//	@Description	Returns the progress of an import the authenticated user started, and why it
+//	@Description	failed if it did
+//	@Tags				imports
+//	@Produce			json
+//	@Param			id	path		int	true	"Import ID"
+//	@Success			200	{object}	database.ImportJob
+//	@Router			/api/v1/imports/{id} [get]
+//	@Security		BearerAuth
+func (app *app) getImport(c *gin.Context) {
+	job, ok := app.userImport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeImport resumes a failed import
+//
+//	@Summary			Resumes a failed import
+//	@Description	Runs a failed import again from the first chunk that was not imported, for
+//	@Description	example after making room for more tickets
+//	@Tags				imports
+//	@Produce			json
+//	@Param			id	path		int	true	"Import ID"
+//	@Success			202	{object}	database.ImportJob
+//	@Router			/api/v1/imports/{id}/resume [post]
+//	@Security		BearerAuth
+func (app *app) resumeImport(c *gin.Context) {
+	job, ok := app.userImport(c)
+	if !ok {
+		return
+	}
+
+	if job.Kind == database.ImportAttendees {
+		event, err := app.models.Events.Get(*job.EventId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+			return
+		}
+		allowed := false
+		if event != nil {
+			allowed, err = app.can(app.getUserFromContext(c), event, database.PermManageAttendees)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking permissions"})
+				return
+			}
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "You are not allowed to add attendees to this event"})
+			return
+		}
+	}
+
+	err := app.models.Imports.Resume(job)
+	if errors.Is(err, database.ErrImportNotFailed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only failed imports can be resumed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume import"})
+		return
+	}
+
+	run := *job
+	app.background(func() { app.runImport(&run) })
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// startImport saves the uploaded file of job and validates it, then starts
+// the import unless req asks for a dry run.
+func (app *app) startImport(c *gin.Context, job *database.ImportJob, req *importRequest) {
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &job.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest,
+				gin.H{"error": "mapping must be a JSON object of field names to column names"})
+			return
+		}
+	}
+
+	path, ok := app.saveImportFile(c)
+	if !ok {
+		return
+	}
+	job.FilePath = path
+
+	report, err := importer.Validate(&app.models, job)
+	if err != nil || req.DryRun || report.Rows == 0 || report.InvalidRows > 0 {
+		os.Remove(path)
+	}
+	switch {
+	case errors.Is(err, importer.ErrInvalidFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	case req.DryRun:
+		c.JSON(http.StatusOK, report)
+		return
+	case report.Rows == 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The file has no rows"})
+		return
+	case report.InvalidRows > 0:
+		c.JSON(http.StatusUnprocessableEntity, invalidImportResponse{
+			Error:  "Some rows are invalid, nothing was imported",
+			Report: report,
+		})
+		return
+	}
+
+	job.TotalRows = report.Rows
+	job.ChunkSize = importer.DefaultChunkSize
+	if err := app.models.Imports.Insert(job); err != nil {
+		os.Remove(path)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	run := *job
+	app.background(func() { app.runImport(&run) })
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// saveImportFile stores the uploaded file in the import directory, so the
+// import can be resumed from it. It writes the error response and returns
+// false if there is no file.
+func (app *app) saveImportFile(c *gin.Context) (string, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file of at most 32 MiB is required"})
+		return "", false
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return "", false
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(app.importDir, 0o700); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return "", false
+	}
+	dst, err := os.CreateTemp(app.importDir, "import-*.csv")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return "", false
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return "", false
+	}
+
+	return dst.Name(), true
+}
+
+// userImport loads the import named by the id parameter. It writes the error
+// response and returns false if the user did not start it.
+func (app *app) userImport(c *gin.Context) (*database.ImportJob, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return nil, false
+	}
+
+	job, err := app.models.Imports.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve import"})
+		return nil, false
+	}
+	if job == nil || job.UserId != app.getUserFromContext(c).Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+		return nil, false
+	}
+
+	return job, true
+}
+
+// runImport runs a running import to the end, removing its file once it is
+// complete.
+func (app *app) runImport(job *database.ImportJob) {
+	err := importer.Run(&app.models, job)
+	if job.EventId != nil {
+		app.publishAttendees(*job.EventId)
+	}
+	if err != nil {
+		log.Printf("error running import %d: %v", job.Id, err)
+		return
+	}
+
+	if err := os.Remove(job.FilePath); err != nil {
+		log.Printf("error removing file of import %d: %v", job.Id, err)
+	}
+}
+
+// resumeImports carries on with the imports that were running when the
+// server stopped.
+func (app *app) resumeImports() {
+	jobs, err := app.models.Imports.GetRunning()
+	if err != nil {
+		log.Printf("error retrieving running imports: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		app.runImport(job)
+	}
+}
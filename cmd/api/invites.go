@@ -0,0 +1,419 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+type inviteUserRequest struct {
+	UserId int `json:"userId" binding:"required"`
+}
+
+type createInviteLinkRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+	MaxUses   int        `json:"maxUses" binding:"min=0"`
+}
+
+// GetEventInvites returns the users invited to an event
+//
+//	@Summary			Returns the users invited to an event
+//	@Description	Returns the users invited to an event. Requires the owner, a co-owner or an editor.
+//	@Tags				invites
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.EventInvite
+//	@Router			/api/v1/events/{id}/invites [get]
+//	@Security		BearerAuth
+func (app *app) getEventInvites(c *gin.Context) {
+	event, ok := app.inviteEvent(c)
+	if !ok {
+		return
+	}
+
+	invites, err := app.models.Invites.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+// InviteUser invites a user to an event
+//
+//	@Summary			Invites a user to an event
+//	@Description	Lets a user open the event even if it is private, and notifies them.
+//	@Description	Requires the owner, a co-owner or an editor.
+//	@Tags				invites
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int					true	"Event ID"
+//	@Param			invite		body		inviteUserRequest	true	"User to invite"
+//	@Success			201	{object}	database.EventInvite
+//	@Router			/api/v1/events/{id}/invites [post]
+//	@Security		BearerAuth
+func (app *app) inviteUser(c *gin.Context) {
+	var req inviteUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.inviteEvent(c)
+	if !ok {
+		return
+	}
+
+	invitee, err := app.models.Users.Get(req.UserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if invitee == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	invite := &database.EventInvite{
+		EventId:   event.Id,
+		UserId:    invitee.Id,
+		InvitedBy: user.Id,
+	}
+
+	err = app.models.Invites.Invite(invite)
+	if errors.Is(err, database.ErrAlreadyInvited) {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already invited to this event"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite user"})
+		return
+	}
+
+	app.notifyUser(invitee, database.NotifyInvite, event, "You are invited to "+event.Name,
+		fmt.Sprintf("%s invited you to %s on %s at %s.\nSee GET /api/v1/events/%d.",
+			user.Name, event.Name, formatEventStart(event), event.Location, event.Id))
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// UninviteUser withdraws the invitation of a user to an event
+//
+//	@Summary			Withdraws the invitation of a user to an event
+//	@Description	Withdraws an invitation. Users who already attend keep attending.
+//	@Tags				invites
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			userId	path	int	true	"User ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/invites/{userId} [delete]
+//	@Security		BearerAuth
+func (app *app) uninviteUser(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	event, ok := app.inviteEvent(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := app.models.Invites.Delete(event.Id, userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw invite"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetInviteLinks returns the invite links of an event
+//
+//	@Summary			Returns the invite links of an event
+//	@Description	Returns the invite links of an event with their tokens, newest first.
+//	@Description	Requires the owner, a co-owner or an editor.
+//	@Tags				invites
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.InviteLink
+//	@Router			/api/v1/events/{id}/invite-links [get]
+//	@Security		BearerAuth
+func (app *app) getInviteLinks(c *gin.Context) {
+	event, ok := app.inviteEvent(c)
+	if !ok {
+		return
+	}
+
+	links, err := app.models.Invites.GetLinksByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invite links"})
+		return
+	}
+
+	for _, l := range links {
+		l.Token = app.inviteSigner.Sign(strconv.Itoa(l.Id))
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// CreateInviteLink creates a shareable invite link for an event
+//
+//	@Summary			Creates a shareable invite link for an event
+//	@Description	Creates a link whose token makes whoever redeems it an attendee, even of a
+//	@Description	private event. The link can expire and be limited to a number of uses (0 for no
+//	@Description	limit). Requires the owner, a co-owner or an editor.
+//	@Tags				invites
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int						true	"Event ID"
+//	@Param			link	body		createInviteLinkRequest	true	"Link settings"
+//	@Success			201	{object}	database.InviteLink
+//	@Router			/api/v1/events/{id}/invite-links [post]
+//	@Security		BearerAuth
+func (app *app) createInviteLink(c *gin.Context) {
+	var req createInviteLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		return
+	}
+
+	event, ok := app.inviteEvent(c)
+	if !ok {
+		return
+	}
+
+	link := &database.InviteLink{
+		EventId:   event.Id,
+		CreatedBy: app.getUserFromContext(c).Id,
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+	}
+	if err := app.models.Invites.InsertLink(link); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite link"})
+		return
+	}
+
+	link.Token = app.inviteSigner.Sign(strconv.Itoa(link.Id))
+	c.JSON(http.StatusCreated, link)
+}
+
+// RevokeInviteLink revokes an invite link
+//
+//	@Summary			Revokes an invite link
+//	@Description	Stops an invite link from being redeemed. Attendees who already redeemed it stay.
+//	@Tags				invites
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			linkId	path	int	true	"Invite link ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/invite-links/{linkId} [delete]
+//	@Security		BearerAuth
+func (app *app) revokeInviteLink(c *gin.Context) {
+	linkId, err := strconv.Atoi(c.Param("linkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite link ID"})
+		return
+	}
+
+	event, ok := app.inviteEvent(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := app.models.Invites.RevokeLink(event.Id, linkId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite link"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite link not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetInvite returns the event an invite link leads to
+//
+//	@Summary			Returns the event an invite link leads to
+//	@Description	Returns the event of a valid invite link, so that it can be shown before the
+//	@Description	link is redeemed
+//	@Tags				invites
+//	@Produce			json
+//	@Param			token	path		string	true	"Invite link token"
+//	@Param			tz		query		string	false	"IANA time zone to also render the event times in"
+//	@Success			200	{object}	database.Event
+//	@Router			/api/v1/invites/{token} [get]
+func (app *app) getInvite(c *gin.Context) {
+	viewer, err := viewerLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone"})
+		return
+	}
+
+	link, event, ok := app.inviteLink(c)
+	if !ok {
+		return
+	}
+	if err := link.Check(time.Now()); err != nil {
+		app.inviteLinkError(c, err)
+		return
+	}
+
+	event.Localize(viewer)
+	c.JSON(http.StatusOK, event)
+}
+
+type redeemInviteRequest struct {
+	ticketChoice
+	// Answers are the answers to the event's registration form.
+	Answers database.Answers `json:"answers"`
+}
+
+// RedeemInvite redeems an invite link
+//
+//	@Summary			Redeems an invite link
+//	@Description	Makes the authenticated user an attendee of the event the link leads to.
+//	@Description	Redeeming a link again does not use it up further. Events with ticket types
+//	@Description	need a ticketTypeId and optionally a quantity. Paid tickets are bought with an
+//	@Description	order instead. The event's registration form is answered in answers.
+//	@Tags				invites
+//	@Accept			json
+//	@Produce			json
+//	@Param			token	path		string				true	"Invite link token"
+//	@Param			tickets	body		redeemInviteRequest	false	"Tickets to take and answers"
+//	@Success			201	{object}	database.Attendee
+//	@Failure			400	{object}	invalidAnswersResponse
+//	@Router			/api/v1/invites/{token}/redeem [post]
+//	@Security		BearerAuth
+func (app *app) redeemInvite(c *gin.Context) {
+	var req redeemInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, event, ok := app.inviteLink(c)
+	if !ok {
+		return
+	}
+
+	if event.IsFinal() {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Cancelled or completed events cannot be joined"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	attendee, err := app.models.Attendees.GetByEventAndUser(event.Id, user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return
+	}
+	var answers database.Answers
+	if attendee == nil {
+		ticketType, ok := app.checkTicketChoice(c, event, &req.ticketChoice, false)
+		if !ok {
+			return
+		}
+		if ticketType != nil && ticketType.Price > 0 {
+			c.JSON(http.StatusPaymentRequired,
+				gin.H{"error": "Paid tickets must be bought with an order"})
+			return
+		}
+
+		answers, ok = app.checkAnswers(c, event, req.Answers, false)
+		if !ok {
+			return
+		}
+	}
+
+	attendee, err = app.models.Invites.Redeem(link, &database.Attendee{
+		UserId:       user.Id,
+		TicketTypeId: req.TicketTypeId,
+		Quantity:     req.Quantity,
+		Answers:      answers,
+	})
+	if errors.Is(err, database.ErrSoldOut) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Not enough tickets left"})
+		return
+	}
+	if err != nil {
+		app.inviteLinkError(c, err)
+		return
+	}
+
+	app.publishAttendees(attendee.EventId)
+	c.JSON(http.StatusCreated, attendee)
+}
+
+// inviteEvent loads the event named by the id parameter and checks that the
+// user may manage its invites.
+func (app *app) inviteEvent(c *gin.Context) (*database.Event, bool) {
+	return app.eventWithPermission(c, database.PermManageAttendees,
+		"You do not have permission to manage the invites of this event")
+}
+
+// inviteLink resolves the token parameter to an invite link and its event. It
+// writes the error response and returns false if either does not exist.
+func (app *app) inviteLink(c *gin.Context) (*database.InviteLink, *database.Event, bool) {
+	payload, ok := app.inviteSigner.Verify(c.Param("token"))
+	id, err := strconv.Atoi(payload)
+	if !ok || err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite link not found"})
+		return nil, nil, false
+	}
+
+	link, err := app.models.Invites.GetLink(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invite link"})
+		return nil, nil, false
+	}
+	if link == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite link not found"})
+		return nil, nil, false
+	}
+
+	event, err := app.models.Events.Get(link.EventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return nil, nil, false
+	}
+	if event == nil || event.Status == database.EventDraft {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, nil, false
+	}
+
+	return link, event, true
+}
+
+func (app *app) inviteLinkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrInviteLinkRevoked):
+		c.JSON(http.StatusGone, gin.H{"error": "This invite link has been revoked"})
+	case errors.Is(err, database.ErrInviteLinkExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "This invite link has expired"})
+	case errors.Is(err, database.ErrInviteLinkExhausted):
+		c.JSON(http.StatusGone, gin.H{"error": "This invite link has been used up"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem invite link"})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+func (app *app) startJobs() {
+	app.schedule("purge deleted events", time.Hour, app.purgeDeletedEvents)
+	app.schedule("expire pending orders", time.Minute, app.expireOrders)
+	app.schedule("invite feedback", 15*time.Minute, app.inviteFeedback)
+	app.schedule("remind attendees", 15*time.Minute, app.remindAttendees)
+	app.schedule("send notification digests", time.Hour, app.sendDigests)
+	app.background(app.resumeImports)
+	app.background(app.resumeAnnouncements)
+}
+
+// schedule runs job immediately and then every interval for as long as the
+// server runs. A failing run is logged and retried at the next tick.
+func (app *app) schedule(name string, interval time.Duration, job func() error) {
+	app.background(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := app.runJob(job); err != nil {
+				log.Printf("error running job %q: %v", name, err)
+			}
+			<-ticker.C
+		}
+	})
+}
+
+func (app *app) runJob(job func() error) error {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("job panicked: %v", r)
+		}
+	}()
+
+	return job()
+}
+
+func (app *app) purgeDeletedEvents() error {
+	n, err := app.models.Events.PurgeDeleted(time.Now().Add(-app.eventRetention))
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("purged %d deleted events", n)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rejectJoinRequestRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// GetJoinRequests returns the join requests of an event
+//
+//	@Summary			Returns the join requests of an event
+//	@Description	Returns the join requests of an event, oldest first. Requires the owner, a
+//	@Description	co-owner or an editor.
+//	@Tags				join requests
+//	@Produce			json
+//	@Param			id			path		int		true	"Event ID"
+//	@Param			status		query		string	false	"Only requests in this status"	Enums(pending, approved, rejected)
+//	@Success			200	{object}	[]database.JoinRequest
+//	@Router			/api/v1/events/{id}/join-requests [get]
+//	@Security		BearerAuth
+func (app *app) getJoinRequests(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != database.JoinRequestPending &&
+		status != database.JoinRequestApproved && status != database.JoinRequestRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	event, ok := app.joinRequestEvent(c)
+	if !ok {
+		return
+	}
+
+	requests, err := app.models.JoinRequests.GetByEvent(event.Id, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveJoinRequest approves a join request
+//
+//	@Summary			Approves a join request
+//	@Description	Makes the applicant an attendee with the tickets they asked for and notifies
+//	@Description	them. Fails if too few tickets are left. Applicants for paid tickets are not
+//	@Description	added but may then buy them with an order, and the request is returned. Requires
+//	@Description	the owner, a co-owner or an editor.
+//	@Tags				join requests
+//	@Produce			json
+//	@Param			id			path		int	true	"Event ID"
+//	@Param			requestId	path		int	true	"Join request ID"
+//	@Success			201	{object}	database.Attendee
+//	@Success			200	{object}	database.JoinRequest
+//	@Router			/api/v1/events/{id}/join-requests/{requestId}/approve [post]
+//	@Security		BearerAuth
+func (app *app) approveJoinRequest(c *gin.Context) {
+	event, ok := app.joinRequestEvent(c)
+	if !ok {
+		return
+	}
+	if event.IsFinal() {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Attendees cannot be added to cancelled or completed events"})
+		return
+	}
+
+	request, ok := app.pendingJoinRequest(c, event)
+	if !ok {
+		return
+	}
+
+	attendee, err := app.models.JoinRequests.Approve(request, app.getUserFromContext(c).Id)
+	if errors.Is(err, database.ErrJoinRequestDecided) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This join request has already been decided"})
+		return
+	}
+	if errors.Is(err, database.ErrSoldOut) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Not enough tickets left"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve join request"})
+		return
+	}
+
+	app.publishAttendees(event.Id)
+
+	if attendee == nil {
+		app.notifyApplicant(request, event, database.NotifyJoinRequest, fmt.Sprintf(
+			"Your request to join %s on %s has been approved. You can now buy your tickets.",
+			event.Name, formatEventStart(event)))
+
+		c.JSON(http.StatusOK, request)
+		return
+	}
+
+	app.notifyApplicant(request, event, database.NotifyAttendeeAdded, fmt.Sprintf(
+		"You are in! Your request to join %s on %s has been approved.",
+		event.Name, formatEventStart(event)))
+
+	c.JSON(http.StatusCreated, attendee)
+}
+
+// RejectJoinRequest rejects a join request
+//
+//	@Summary			Rejects a join request
+//	@Description	Rejects a join request and notifies the applicant, with the reason if one is
+//	@Description	given. Requires the owner, a co-owner or an editor.
+//	@Tags				join requests
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int							true	"Event ID"
+//	@Param			requestId	path		int							true	"Join request ID"
+//	@Param			rejection	body		rejectJoinRequestRequest	false	"Reason"
+//	@Success			200	{object}	database.JoinRequest
+//	@Router			/api/v1/events/{id}/join-requests/{requestId}/reject [post]
+//	@Security		BearerAuth
+func (app *app) rejectJoinRequest(c *gin.Context) {
+	var req rejectJoinRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.joinRequestEvent(c)
+	if !ok {
+		return
+	}
+
+	request, ok := app.pendingJoinRequest(c, event)
+	if !ok {
+		return
+	}
+
+	err := app.models.JoinRequests.Reject(request, app.getUserFromContext(c).Id, req.Reason)
+	if errors.Is(err, database.ErrJoinRequestDecided) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This join request has already been decided"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject join request"})
+		return
+	}
+
+	body := fmt.Sprintf("Your request to join %s has been declined.", event.Name)
+	if req.Reason != "" {
+		body += "\n\nReason: " + req.Reason
+	}
+	app.notifyApplicant(request, event, database.NotifyJoinRequest, body)
+
+	c.JSON(http.StatusOK, request)
+}
+
+// WithdrawJoinRequest withdraws a pending join request
+//
+//	@Summary			Withdraws a pending join request
+//	@Description	Lets the applicant withdraw their own pending join request
+//	@Tags				join requests
+//	@Param			id			path	int	true	"Event ID"
+//	@Param			requestId	path	int	true	"Join request ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/join-requests/{requestId} [delete]
+//	@Security		BearerAuth
+func (app *app) withdrawJoinRequest(c *gin.Context) {
+	eventId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	requestId, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join request ID"})
+		return
+	}
+
+	request, err := app.models.JoinRequests.Get(requestId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join request"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if request == nil || request.EventId != eventId || request.UserId != user.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+		return
+	}
+
+	withdrawn, err := app.models.JoinRequests.Withdraw(requestId, user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw join request"})
+		return
+	}
+	if !withdrawn {
+		c.JSON(http.StatusConflict, gin.H{"error": "This join request has already been decided"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyJoinRequests returns the user's join requests
+//
+//	@Summary			Returns the authenticated user's join requests
+//	@Description	Returns the join requests the authenticated user has made, newest first
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	[]database.JoinRequest
+//	@Router			/api/v1/users/me/join-requests [get]
+//	@Security		BearerAuth
+func (app *app) getMyJoinRequests(c *gin.Context) {
+	requests, err := app.models.JoinRequests.GetByUser(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// joinRequestEvent loads the event named by the id parameter and checks that
+// the user may decide on its join requests.
+func (app *app) joinRequestEvent(c *gin.Context) (*database.Event, bool) {
+	return app.eventWithPermission(c, database.PermManageAttendees,
+		"You do not have permission to manage the join requests of this event")
+}
+
+// pendingJoinRequest loads the join request named by the requestId parameter
+// and checks that it belongs to event and is still pending. It writes the
+// error response and returns false otherwise.
+func (app *app) pendingJoinRequest(c *gin.Context, event *database.Event) (*database.JoinRequest, bool) {
+	requestId, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join request ID"})
+		return nil, false
+	}
+
+	request, err := app.models.JoinRequests.Get(requestId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join request"})
+		return nil, false
+	}
+	if request == nil || request.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+		return nil, false
+	}
+	if request.Status != database.JoinRequestPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "This join request has already been decided"})
+		return nil, false
+	}
+
+	return request, true
+}
+
+// notifyApplicant notifies the user behind a join request of its outcome.
+func (app *app) notifyApplicant(request *database.JoinRequest, event *database.Event, typ, body string) {
+	applicant, err := app.models.Users.Get(request.UserId)
+	if err != nil || applicant == nil {
+		log.Printf("error retrieving applicant of join request %d: %v", request.Id, err)
+		return
+	}
+
+	app.notifyUser(applicant, typ, event, "Your request to join "+event.Name, body)
+}
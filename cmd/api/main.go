@@ -3,10 +3,18 @@ package main
 import (
 	"database/sql"
 	"log"
+	"time"
 
 	_ "github.com/Aergiaaa/gin-event/docs"
+	"github.com/Aergiaaa/gin-event/internal/broker"
 	"github.com/Aergiaaa/gin-event/internal/database"
 	"github.com/Aergiaaa/gin-event/internal/env"
+	"github.com/Aergiaaa/gin-event/internal/mailer"
+	"github.com/Aergiaaa/gin-event/internal/notifier"
+	"github.com/Aergiaaa/gin-event/internal/password"
+	"github.com/Aergiaaa/gin-event/internal/payment"
+	"github.com/Aergiaaa/gin-event/internal/ratelimit"
+	"github.com/Aergiaaa/gin-event/internal/signing"
 	"github.com/joho/godotenv"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -25,6 +33,40 @@ type app struct {
 	port      int
 	jwtSecret string
 	models    database.Models
+	mailer    mailer.Mailer
+	exportDir string
+	importDir string
+
+	hasher         *password.Hasher
+	passwordPolicy *password.Policy
+
+	eventRetention time.Duration
+	// feedbackDelay is how long after an event ends its attendees are asked
+	// for feedback.
+	feedbackDelay time.Duration
+	// reminderLead is how long before an event starts its attendees are
+	// reminded of it.
+	reminderLead time.Duration
+
+	// senders deliver notifications, by channel.
+	senders map[string]notifier.Sender
+
+	// broker carries the changes streamed to clients, and streamHeartbeat is
+	// how often idle streams are kept alive.
+	broker          broker.Broker
+	streamHeartbeat time.Duration
+
+	inviteSigner *signing.Signer
+	ticketSigner *signing.Signer
+
+	payments payment.PaymentProvider
+	// orderHold is how long a pending order keeps its tickets.
+	orderHold time.Duration
+
+	// commentLimiter limits how many comments and reports each user posts.
+	commentLimiter *ratelimit.Limiter
+	// chatLimiter limits how many chat messages each user posts to a room.
+	chatLimiter *ratelimit.Limiter
 }
 
 func main() {
@@ -40,14 +82,126 @@ func main() {
 	defer db.Close()
 
 	models := database.NewModels(db)
+	jwtSecret := env.GetEnvString("JWT_SECRET", "secret-123456")
+	mail := newMailer()
+	streams := broker.NewMemory(env.GetEnvInt("STREAM_REPLAY_BUFFER", 100), 10*time.Minute)
 	app := &app{
 		host:      env.GetEnvString("HOST", "localhost"),
 		port:      env.GetEnvInt("PORT", 8080),
-		jwtSecret: env.GetEnvString("JWT_SECRET", "secret-123456"),
+		jwtSecret: jwtSecret,
 		models:    models,
+		mailer:    mail,
+		exportDir: env.GetEnvString("EXPORT_DIR", "./exports"),
+		importDir: env.GetEnvString("IMPORT_DIR", "./imports"),
+
+		hasher:         newHasher(),
+		passwordPolicy: newPasswordPolicy(),
+
+		eventRetention: time.Duration(env.GetEnvInt("EVENT_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		feedbackDelay:  time.Duration(env.GetEnvInt("FEEDBACK_INVITE_DELAY_HOURS", 2)) * time.Hour,
+		reminderLead:   time.Duration(env.GetEnvInt("REMINDER_LEAD_HOURS", 24)) * time.Hour,
+
+		senders: newSenders(&models, mail, streams),
+
+		broker:          streams,
+		streamHeartbeat: time.Duration(env.GetEnvInt("STREAM_HEARTBEAT_SECONDS", 15)) * time.Second,
+
+		inviteSigner: signing.New(jwtSecret, "invite-link"),
+		ticketSigner: signing.New(jwtSecret, "ticket"),
+
+		payments:  newPaymentProvider(),
+		orderHold: time.Duration(env.GetEnvInt("ORDER_HOLD_MINUTES", 30)) * time.Minute,
+
+		commentLimiter: ratelimit.New(env.GetEnvInt("COMMENT_RATE_LIMIT", 5), time.Minute),
+		chatLimiter:    ratelimit.New(env.GetEnvInt("CHAT_RATE_LIMIT", 20), time.Minute),
 	}
 
 	if err := app.serve(); err != nil {
 		log.Fatalf("error serving app: %v", err)
 	}
 }
+
+func newMailer() mailer.Mailer {
+	host := env.GetEnvString("SMTP_HOST", "")
+	if host == "" {
+		return mailer.LogMailer{}
+	}
+
+	return mailer.SMTPMailer{
+		Host:     host,
+		Port:     env.GetEnvInt("SMTP_PORT", 587),
+		Username: env.GetEnvString("SMTP_USERNAME", ""),
+		Password: env.GetEnvString("SMTP_PASSWORD", ""),
+		From:     env.GetEnvString("SMTP_FROM", "no-reply@localhost"),
+	}
+}
+
+// newSenders returns the senders of every notification channel.
+func newSenders(models *database.Models, m mailer.Mailer, b broker.Broker) map[string]notifier.Sender {
+	return map[string]notifier.Sender{
+		database.ChannelInApp: notifier.InApp{Notifications: &models.Notifications, Broker: b},
+		database.ChannelEmail: notifier.Email{Mailer: m, Notifications: &models.Notifications},
+		database.ChannelWebhook: notifier.Webhook{Client: notifier.NewWebhookClient(
+			time.Duration(env.GetEnvInt("WEBHOOK_TIMEOUT_SECONDS", 5)) * time.Second,
+		)},
+	}
+}
+
+// newPaymentProvider returns the configured payment provider. Payments are
+// faked unless a real provider is configured.
+func newPaymentProvider() payment.PaymentProvider {
+	name := env.GetEnvString("PAYMENT_PROVIDER", "fake")
+	if name == "stripe" {
+		stripe := &payment.Stripe{
+			SecretKey:     env.GetEnvString("STRIPE_SECRET_KEY", ""),
+			WebhookSecret: env.GetEnvString("STRIPE_WEBHOOK_SECRET", ""),
+			SuccessURL:    env.GetEnvString("CHECKOUT_SUCCESS_URL", "http://localhost:3000/orders/success"),
+			CancelURL:     env.GetEnvString("CHECKOUT_CANCEL_URL", "http://localhost:3000/orders/cancel"),
+		}
+		if stripe.SecretKey == "" || stripe.WebhookSecret == "" {
+			log.Fatal("STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET are required for Stripe payments")
+		}
+		return stripe
+	}
+	if name != "fake" {
+		log.Fatalf("unknown payment provider %q", name)
+	}
+
+	log.Println("Using the fake payment provider, no real payments are taken")
+	return payment.Fake{Settle: env.GetEnvBool("FAKE_PAYMENTS_SETTLE", true)}
+}
+
+// newHasher returns the configured password hasher. Hashes from the other
+// supported algorithm are still accepted and replaced on the next login.
+func newHasher() *password.Hasher {
+	argon := password.DefaultArgon2id
+	argon.Memory = uint32(env.GetEnvInt("ARGON2_MEMORY_KIB", int(argon.Memory)))
+	argon.Iterations = uint32(env.GetEnvInt("ARGON2_ITERATIONS", int(argon.Iterations)))
+	argon.Parallelism = uint8(env.GetEnvInt("ARGON2_PARALLELISM", int(argon.Parallelism)))
+
+	bcrypt := password.Bcrypt{Cost: env.GetEnvInt("BCRYPT_COST", 12)}
+
+	if env.GetEnvString("PASSWORD_HASHER", argon.Name()) == bcrypt.Name() {
+		return password.NewHasher(bcrypt, argon)
+	}
+	return password.NewHasher(argon, bcrypt)
+}
+
+func newPasswordPolicy() *password.Policy {
+	policy := password.NewPolicy(
+		env.GetEnvInt("PASSWORD_MIN_LENGTH", 8),
+		env.GetEnvInt("PASSWORD_MAX_LENGTH", 72),
+	)
+	policy.RequireUpper = env.GetEnvBool("PASSWORD_REQUIRE_UPPER", false)
+	policy.RequireLower = env.GetEnvBool("PASSWORD_REQUIRE_LOWER", false)
+	policy.RequireDigit = env.GetEnvBool("PASSWORD_REQUIRE_DIGIT", false)
+	policy.RequireSymbol = env.GetEnvBool("PASSWORD_REQUIRE_SYMBOL", false)
+
+	if path := env.GetEnvString("BREACHED_PASSWORDS_FILE", ""); path != "" {
+		if err := policy.LoadBreached(path); err != nil {
+			log.Fatalf("error loading breached passwords: %v", err)
+		}
+	}
+
+	return policy
+}
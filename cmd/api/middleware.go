@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
@@ -17,42 +22,115 @@ func (app *app) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenStr == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token is required"})
+		user, session, err := app.authenticate(authHeader)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
+		app.touchSession(session, c.ClientIP())
 
-			return []byte(app.jwtSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+		c.Set("user", user)
+		c.Set("session", session)
+		c.Next()
+	}
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+// OptionalAuthMiddleware identifies the user when a bearer token is sent but
+// lets anonymous requests through, for public routes whose response depends on
+// who is asking.
+func (app *app) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
 			return
 		}
 
-		userId := int(claims["userId"].(float64))
-		user, err := app.models.Users.Get(userId)
+		user, session, err := app.authenticate(authHeader)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
+		app.touchSession(session, c.ClientIP())
+
 		c.Set("user", user)
+		c.Set("session", session)
 		c.Next()
 	}
 }
+
+func (app *app) authenticate(authHeader string) (*database.User, *database.Session, error) {
+	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenStr == authHeader {
+		return nil, nil, errors.New("Bearer token is required")
+	}
+
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+
+		return []byte(app.jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, nil, errors.New("Invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, nil, errors.New("Invalid token claims")
+	}
+
+	userId, ok := claims["userId"].(float64)
+	if !ok {
+		return nil, nil, errors.New("Invalid token claims")
+	}
+	sessionId, ok := claims["sid"].(string)
+	if !ok {
+		return nil, nil, errors.New("Invalid token claims")
+	}
+
+	user, err := app.models.Users.Get(int(userId))
+	if err != nil || user == nil {
+		return nil, nil, errors.New("Unauthorized access")
+	}
+
+	version, _ := claims["ver"].(float64)
+	if int(version) != user.TokenVersion {
+		return nil, nil, errors.New("Token has been revoked")
+	}
+
+	session, err := app.models.Sessions.Get(sessionId)
+	if err != nil || session == nil || session.UserId != user.Id {
+		return nil, nil, errors.New("Unauthorized access")
+	}
+	if session.RevokedAt != nil {
+		return nil, nil, errors.New("Session has been revoked")
+	}
+
+	return user, session, nil
+}
+
+// sessionTouchInterval is how stale a session's last-seen time may get before
+// a request refreshes it, so that busy clients don't write on every request.
+const sessionTouchInterval = time.Minute
+
+func (app *app) touchSession(session *database.Session, ip string) {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval && session.IP == ip {
+		return
+	}
+
+	session.LastSeenAt = now
+	session.IP = ip
+
+	app.background(func() {
+		if err := app.models.Sessions.Touch(session.Id, ip, now); err != nil {
+			log.Printf("error updating session %s: %v", session.Id, err)
+		}
+	})
+}
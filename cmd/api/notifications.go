@@ -0,0 +1,389 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/notifier"
+
+	"github.com/gin-gonic/gin"
+)
+
+type notificationPage struct {
+	Notifications []*database.Notification `json:"notifications"`
+	NextCursor    *int                     `json:"nextCursor,omitempty"`
+}
+
+// GetNotifications returns the notifications of the authenticated user
+//
+//	@Summary			Returns the notifications of the authenticated user
+//	@Description	Returns a page of the notification center of the user, newest first. Pass
+//	@Description	nextCursor as cursor to get the next page.
+//	@Tags				notifications
+//	@Produce			json
+//	@Param			unread	query		bool	false	"Only unread notifications"
+//	@Param			cursor	query		int		false	"Cursor of the page"
+//	@Param			limit	query		int		false	"Page size, up to 100"	default(20)
+//	@Success			200	{object}	notificationPage
+//	@Router			/api/v1/users/me/notifications [get]
+//	@Security		BearerAuth
+func (app *app) getNotifications(c *gin.Context) {
+	cursor, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	notifications, err := app.models.Notifications.GetByUser(app.getUserFromContext(c).Id,
+		c.Query("unread") == "true", cursor, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications"})
+		return
+	}
+
+	page := notificationPage{Notifications: notifications}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		page.NextCursor = &notifications[limit-1].Id
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUnreadNotificationCount counts the unread notifications of the
+// authenticated user
+//
+//	@Summary			Counts the unread notifications of the authenticated user
+//	@Tags				notifications
+//	@Produce			json
+//	@Success			200	{object}	map[string]int
+//	@Router			/api/v1/users/me/notifications/unread-count [get]
+//	@Security		BearerAuth
+func (app *app) getUnreadNotificationCount(c *gin.Context) {
+	n, err := app.models.Notifications.CountUnread(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": n})
+}
+
+// MarkNotificationRead marks a notification as read
+//
+//	@Summary			Marks a notification as read
+//	@Tags				notifications
+//	@Produce			json
+//	@Param			id	path		int	true	"Notification ID"
+//	@Success			200	{object}	database.Notification
+//	@Router			/api/v1/users/me/notifications/{id}/read [post]
+//	@Security		BearerAuth
+func (app *app) markNotificationRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	notification, err := app.models.Notifications.MarkRead(app.getUserFromContext(c).Id, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+	if notification == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks every notification as read
+//
+//	@Summary			Marks every notification of the authenticated user as read
+//	@Description	Marks every notification as read and returns how many were unread.
+//	@Tags				notifications
+//	@Produce			json
+//	@Success			200	{object}	map[string]int
+//	@Router			/api/v1/users/me/notifications/read [post]
+//	@Security		BearerAuth
+func (app *app) markAllNotificationsRead(c *gin.Context) {
+	n, err := app.models.Notifications.MarkAllRead(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"read": n})
+}
+
+// GetNotificationPreferences returns the notification preferences of the
+// authenticated user
+//
+//	@Summary			Returns the notification preferences of the authenticated user
+//	@Description	Returns for each type of notification whether each channel is enabled. Every
+//	@Description	channel is enabled until the user turns it off.
+//	@Tags				notifications
+//	@Produce			json
+//	@Success			200	{object}	database.NotificationPreferences
+//	@Router			/api/v1/users/me/notification-preferences [get]
+//	@Security		BearerAuth
+func (app *app) getNotificationPreferences(c *gin.Context) {
+	preferences, err := app.models.Preferences.Get(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdateNotificationPreferences changes the notification preferences of the
+// authenticated user
+//
+//	@Summary			Changes the notification preferences of the authenticated user
+//	@Description	Turns channels on or off per type of notification, such as
+//	@Description	{"announcement": {"email": false}}. Preferences left out stay as they are. The
+//	@Description	types are announcement, attendee_added, event_updated, event_cancelled, reminder,
+//	@Description	join_request, order_refunded, invite, collaboration and feedback_request, the
+//	@Description	channels in_app, email and webhook.
+//	@Tags				notifications
+//	@Accept			json
+//	@Produce			json
+//	@Param			preferences	body		database.NotificationPreferences	true	"Preferences to change"
+//	@Success			200	{object}	database.NotificationPreferences
+//	@Router			/api/v1/users/me/notification-preferences [put]
+//	@Security		BearerAuth
+func (app *app) updateNotificationPreferences(c *gin.Context) {
+	var req database.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	if err := app.models.Preferences.Set(user.Id, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+
+	preferences, err := app.models.Preferences.Get(user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// GetNotificationSettings returns the notification settings of the
+// authenticated user
+//
+//	@Summary			Returns the notification settings of the authenticated user
+//	@Description	Returns the webhook URL and secret of the webhook channel and whether emails are
+//	@Description	collected into a daily digest.
+//	@Tags				notifications
+//	@Produce			json
+//	@Success			200	{object}	database.NotificationSettings
+//	@Router			/api/v1/users/me/notification-settings [get]
+//	@Security		BearerAuth
+func (app *app) getNotificationSettings(c *gin.Context) {
+	settings, err := app.models.Preferences.GetSettings(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateNotificationSettings changes the notification settings of the
+// authenticated user
+//
+//	@Summary			Changes the notification settings of the authenticated user
+//	@Description	Sets the URL notifications of the webhook channel are posted to, or clears it
+//	@Description	with an empty one, and chooses whether emails are collected into a daily
+//	@Description	digest. Setting a new URL generates a new secret. Webhooks carry the hex encoded
+//	@Description	HMAC-SHA256 of their body, keyed with the secret, in the X-Signature header.
+//	@Description	Webhooks are not sent to local or private addresses and do not follow
+//	@Description	redirects.
+//	@Tags				notifications
+//	@Accept			json
+//	@Produce			json
+//	@Param			settings	body		database.NotificationSettings	true	"Settings"
+//	@Success			200	{object}	database.NotificationSettings
+//	@Router			/api/v1/users/me/notification-settings [put]
+//	@Security		BearerAuth
+func (app *app) updateNotificationSettings(c *gin.Context) {
+	var settings database.NotificationSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	settings.WebhookURL = strings.TrimSpace(settings.WebhookURL)
+	if err := settings.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := app.models.Preferences.SetSettings(app.getUserFromContext(c).Id, &settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// recipient returns user with their notification settings and the channels
+// they enabled for typ. The webhook channel is left out until they set a
+// webhook URL.
+func (app *app) recipient(user *database.User, typ string) (*notifier.Recipient, []string, error) {
+	preferences, err := app.models.Preferences.Get(user.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings, err := app.models.Preferences.GetSettings(user.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	channels := []string{}
+	for _, channel := range preferences.Channels(typ) {
+		if channel != database.ChannelWebhook || settings.WebhookURL != "" {
+			channels = append(channels, channel)
+		}
+	}
+
+	return &notifier.Recipient{User: user, Settings: settings}, channels, nil
+}
+
+// send delivers a notification to a recipient through channel.
+func (app *app) send(to *notifier.Recipient, channel string, n *database.Notification) error {
+	sender, ok := app.senders[channel]
+	if !ok {
+		return fmt.Errorf("unknown notification channel %q", channel)
+	}
+	return sender.Send(to, n)
+}
+
+// notify delivers a notification about event to user through every channel
+// they enabled for typ.
+func (app *app) notify(user *database.User, typ string, event *database.Event, title, body string) {
+	to, channels, err := app.recipient(user, typ)
+	if err != nil {
+		log.Printf("error retrieving notification preferences of user %d: %v", user.Id, err)
+		return
+	}
+
+	n := &database.Notification{
+		UserId:    user.Id,
+		Type:      typ,
+		EventId:   event.Id,
+		Title:     title,
+		Body:      body,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, channel := range channels {
+		if err := app.send(to, channel, n); err != nil {
+			log.Printf("error notifying user %d through %s: %v", user.Id, channel, err)
+		}
+	}
+}
+
+// notifyUser notifies user in the background.
+func (app *app) notifyUser(user *database.User, typ string, event *database.Event, title, body string) {
+	app.background(func() {
+		app.notify(user, typ, event, title, body)
+	})
+}
+
+// notifyAttendees notifies every attendee of event in the background.
+func (app *app) notifyAttendees(event *database.Event, typ, title, body string) {
+	app.background(func() {
+		attendees, err := app.models.Attendees.GetByEvent(event.Id)
+		if err != nil {
+			log.Printf("error retrieving attendees of event %d: %v", event.Id, err)
+			return
+		}
+
+		for _, user := range attendees {
+			app.notify(user, typ, event, title, body)
+		}
+	})
+}
+
+// sendDigests emails the users who collect their email notifications into a
+// daily digest everything collected since their last one. Digests that cannot
+// be sent stay collected and are tried again on the next run.
+func (app *app) sendDigests() error {
+	digests, err := app.models.Notifications.DueDigests(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, d := range digests {
+		user, err := app.models.Users.Get(d.UserId)
+		if err != nil {
+			log.Printf("error retrieving user %d for their digest: %v", d.UserId, err)
+			continue
+		}
+		if user == nil {
+			continue
+		}
+
+		var body strings.Builder
+		for _, n := range d.Notifications {
+			fmt.Fprintf(&body, "%s\n\n%s\n\n", n.Title, n.Body)
+		}
+		body.WriteString("See all your notifications with GET /api/v1/users/me/notifications.")
+
+		subject := fmt.Sprintf("Your daily digest: %d notifications", len(d.Notifications))
+		if len(d.Notifications) == 1 {
+			subject = "Your daily digest: 1 notification"
+		}
+		if err := app.mailer.Send(user.Email, subject, body.String()); err != nil {
+			log.Printf("error sending digest to user %d: %v", user.Id, err)
+			continue
+		}
+
+		if err := app.models.Notifications.MarkDigestSent(d); err != nil {
+			log.Printf("error recording the digest of user %d: %v", user.Id, err)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("sent %d notification digests", sent)
+	}
+	return nil
+}
+
+// remindAttendees reminds the attendees of the events that start within
+// reminderLead. Every event is only handled once, unless its start changes.
+func (app *app) remindAttendees() error {
+	events, err := app.models.Notifications.ClaimReminders(time.Now().Add(app.reminderLead))
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		app.notifyAttendees(event, database.NotifyReminder, "Reminder: "+event.Name,
+			fmt.Sprintf("%s starts %s at %s.", event.Name, formatEventStart(event), event.Location))
+	}
+
+	if len(events) > 0 {
+		log.Printf("reminded the attendees of %d events", len(events))
+	}
+	return nil
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/payment"
+
+	"github.com/gin-gonic/gin"
+)
+
+type orderRequest struct {
+	TicketTypeId int `json:"ticketTypeId" binding:"required"`
+	Quantity     int `json:"quantity" binding:"omitempty,min=1"`
+	// PromoCode is an optional promo code of the event to redeem.
+	PromoCode string `json:"promoCode" binding:"max=32"`
+	// Answers are the answers to the event's registration form. They default
+	// to the answers given in the approved join request, if any.
+	Answers database.Answers `json:"answers"`
+}
+
+// CreateOrder starts buying paid tickets
+//
+//	@Summary			Starts buying paid tickets
+//	@Description	Creates a pending order that holds the tickets for a limited time and starts the
+//	@Description	payment. The buyer completes it at checkoutUrl, after which they become an
+//	@Description	attendee. Providers that settle right away return the order already paid, and so
+//	@Description	are orders a promo code makes free. Events that require approval need an approved
+//	@Description	join request first. Promo code errors carry a reason like POST promo-codes/check.
+//	@Description	The event's registration form is answered in answers.
+//	@Tags				orders
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int				true	"Event ID"
+//	@Param			order	body		orderRequest	true	"Tickets to buy"
+//	@Success			201	{object}	database.Order
+//	@Failure			400	{object}	invalidAnswersResponse
+//	@Router			/api/v1/events/{id}/orders [post]
+//	@Security		BearerAuth
+func (app *app) createOrder(c *gin.Context) {
+	var req orderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	visible, err := app.canViewEvent(user, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if event.Status == database.EventDraft || event.IsFinal() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tickets for this event are not on sale"})
+		return
+	}
+
+	if event.ApprovalRequired {
+		manager, err := app.can(user, event, database.PermManageAttendees)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		approved, err := app.models.JoinRequests.GetApproved(event.Id, user.Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join requests"})
+			return
+		}
+		if !manager && approved == nil {
+			c.JSON(http.StatusForbidden,
+				gin.H{"error": "This event requires approval, ask to join it first"})
+			return
+		}
+		if approved != nil && req.Answers == nil {
+			req.Answers = approved.Answers
+		}
+	}
+
+	attendee, err := app.models.Attendees.GetByEventAndUser(event.Id, user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attendee"})
+		return
+	}
+	if attendee != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already attend this event"})
+		return
+	}
+
+	choice := ticketChoice{TicketTypeId: &req.TicketTypeId, Quantity: req.Quantity}
+	ticketType, ok := app.checkTicketChoice(c, event, &choice, false)
+	if !ok {
+		return
+	}
+	if ticketType.Price == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Free tickets are taken by joining the event"})
+		return
+	}
+
+	answers, ok := app.checkAnswers(c, event, req.Answers, false)
+	if !ok {
+		return
+	}
+
+	order := &database.Order{
+		EventId:      event.Id,
+		UserId:       user.Id,
+		TicketTypeId: ticketType.Id,
+		Quantity:     choice.Quantity,
+		Amount:       ticketType.Price * int64(choice.Quantity),
+		Currency:     ticketType.Currency,
+		Provider:     app.payments.Name(),
+		Answers:      answers,
+		ExpiresAt:    time.Now().Add(app.orderHold),
+	}
+	if req.PromoCode != "" {
+		quote, ok := app.applyPromoCode(c, event, ticketType, choice.Quantity, req.PromoCode, user.Id)
+		if !ok {
+			return
+		}
+		order.PromoCodeId = &quote.PromoCodeId
+		order.Discount = quote.Discount
+		order.Amount = quote.Amount
+	}
+
+	err = app.models.Orders.Insert(order)
+	if errors.Is(err, database.ErrSoldOut) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Not enough tickets left"})
+		return
+	}
+	if errors.Is(err, database.ErrOrderPending) {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "You already have a pending order for this event"})
+		return
+	}
+	if errors.Is(err, database.ErrPromoCodeExhausted) || errors.Is(err, database.ErrPromoCodeUserLimit) {
+		promoCodeError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+
+	// Nothing is left to pay, the order is settled without the provider.
+	if order.Amount == 0 {
+		if err := app.settleOrder(c.Request.Context(), order, ""); err != nil {
+			log.Printf("error settling order %d: %v", order.Id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete the order"})
+			return
+		}
+		c.JSON(http.StatusCreated, order)
+		return
+	}
+
+	session, err := app.payments.CreateCheckout(c.Request.Context(), payment.Checkout{
+		OrderId:       order.Id,
+		Description:   fmt.Sprintf("%d × %s, %s", order.Quantity, ticketType.Name, event.Name),
+		Amount:        order.Amount,
+		Currency:      order.Currency,
+		CustomerEmail: user.Email,
+		ExpiresAt:     order.ExpiresAt,
+	})
+	if err != nil {
+		log.Printf("error starting checkout of order %d: %v", order.Id, err)
+		if err := app.models.Orders.Expire(order); err != nil {
+			log.Printf("error releasing order %d: %v", order.Id, err)
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start the payment"})
+		return
+	}
+
+	order.SessionId = session.Id
+	order.CheckoutURL = session.URL
+	if err := app.models.Orders.SetCheckout(order); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save the checkout"})
+		return
+	}
+
+	if session.PaymentId != "" {
+		if err := app.settleOrder(c.Request.Context(), order, session.PaymentId); err != nil {
+			log.Printf("error settling order %d: %v", order.Id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete the order"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetOrder returns an order
+//
+//	@Summary			Returns an order
+//	@Description	Returns an order to its buyer or to organizers who may see the event's sales
+//	@Tags				orders
+//	@Produce			json
+//	@Param			orderId	path		int	true	"Order ID"
+//	@Success			200	{object}	database.Order
+//	@Router			/api/v1/orders/{orderId} [get]
+//	@Security		BearerAuth
+func (app *app) getOrder(c *gin.Context) {
+	order, ok := app.userOrder(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder cancels a pending order
+//
+//	@Summary			Cancels a pending order
+//	@Description	Lets the buyer give up a pending order, which releases its tickets right away
+//	@Tags				orders
+//	@Param			orderId	path	int	true	"Order ID"
+//	@Success			204
+//	@Router			/api/v1/orders/{orderId} [delete]
+//	@Security		BearerAuth
+func (app *app) cancelOrder(c *gin.Context) {
+	order, ok := app.userOrder(c)
+	if !ok {
+		return
+	}
+	if order.UserId != app.getUserFromContext(c).Id {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the buyer can cancel an order"})
+		return
+	}
+
+	err := app.models.Orders.Expire(order)
+	if errors.Is(err, database.ErrOrderStateChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending orders can be cancelled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyOrders returns the user's orders
+//
+//	@Summary			Returns the authenticated user's orders
+//	@Description	Returns the orders the authenticated user has placed, newest first
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	[]database.Order
+//	@Router			/api/v1/users/me/orders [get]
+//	@Security		BearerAuth
+func (app *app) getMyOrders(c *gin.Context) {
+	orders, err := app.models.Orders.GetByUser(app.getUserFromContext(c).Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetEventOrders returns the orders of an event
+//
+//	@Summary			Returns the orders of an event
+//	@Description	Returns the orders of an event, newest first. Requires the owner or a co-owner.
+//	@Tags				orders
+//	@Produce			json
+//	@Param			id		path		int		true	"Event ID"
+//	@Param			status	query		string	false	"Only orders in this status"	Enums(pending, paid, expired, refunded)
+//	@Success			200	{object}	[]database.Order
+//	@Router			/api/v1/events/{id}/orders [get]
+//	@Security		BearerAuth
+func (app *app) getEventOrders(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != database.OrderPending && status != database.OrderPaid &&
+		status != database.OrderExpired && status != database.OrderRefunded {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermViewSales,
+		"You do not have permission to view the orders of this event")
+	if !ok {
+		return
+	}
+
+	orders, err := app.models.Orders.GetByEvent(event.Id, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// RefundOrder refunds a paid order
+//
+//	@Summary			Refunds a paid order
+//	@Description	Pays the order back in full through the payment provider, removes the attendee
+//	@Description	and releases the tickets. Requires the owner or a co-owner.
+//	@Tags				orders
+//	@Produce			json
+//	@Param			id		path		int	true	"Event ID"
+//	@Param			orderId	path		int	true	"Order ID"
+//	@Success			200	{object}	database.Order
+//	@Router			/api/v1/events/{id}/orders/{orderId}/refund [post]
+//	@Security		BearerAuth
+func (app *app) refundOrder(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermRefundOrders,
+		"You do not have permission to refund the orders of this event")
+	if !ok {
+		return
+	}
+
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := app.models.Orders.Get(orderId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order"})
+		return
+	}
+	if order == nil || order.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.Status != database.OrderPaid {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only paid orders can be refunded"})
+		return
+	}
+
+	// Orders a promo code made free have no payment to pay back.
+	if order.PaymentId != "" {
+		if err := app.payments.Refund(c.Request.Context(), order.PaymentId); err != nil {
+			log.Printf("error refunding order %d: %v", order.Id, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to refund the payment"})
+			return
+		}
+	}
+
+	err = app.models.Orders.Refund(order)
+	if errors.Is(err, database.ErrOrderStateChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only paid orders can be refunded"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refund order"})
+		return
+	}
+
+	app.publishAttendees(order.EventId)
+	app.notifyBuyer(order)
+	c.JSON(http.StatusOK, order)
+}
+
+// PaymentWebhook receives payment updates from the payment provider
+//
+//	@Summary			Receives payment updates from the payment provider
+//	@Description	Called by the payment provider when a checkout is paid or expires or a payment
+//	@Description	is refunded. Requests must carry the provider's signature.
+//	@Tags				orders
+//	@Accept			json
+//	@Success			204
+//	@Router			/api/v1/payments/webhook [post]
+func (app *app) paymentWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<16))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read webhook"})
+		return
+	}
+
+	event, err := app.payments.ParseWebhook(payload, c.Request.Header)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook"})
+		return
+	}
+	if event == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if err := app.handlePaymentEvent(c.Request.Context(), event); err != nil {
+		// The provider retries webhooks that fail.
+		log.Printf("error handling %s payment webhook: %v", event.Type, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle webhook"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handlePaymentEvent applies a verified provider event to its order. Events
+// for unknown orders and repeated events are ignored.
+func (app *app) handlePaymentEvent(ctx context.Context, event *payment.Event) error {
+	var order *database.Order
+	var err error
+	if event.Type == payment.EventRefunded {
+		order, err = app.models.Orders.GetByPayment(app.payments.Name(), event.PaymentId)
+	} else {
+		order, err = app.models.Orders.GetBySession(app.payments.Name(), event.SessionId)
+	}
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		log.Printf("ignoring %s payment webhook for an unknown order", event.Type)
+		return nil
+	}
+
+	switch event.Type {
+	case payment.EventPaid:
+		return app.settleOrder(ctx, order, event.PaymentId)
+	case payment.EventExpired:
+		if order.Status == database.OrderPending {
+			err = app.models.Orders.Expire(order)
+		}
+	case payment.EventRefunded:
+		if order.Status == database.OrderPaid {
+			if err = app.models.Orders.Refund(order); err == nil {
+				app.publishAttendees(order.EventId)
+				app.notifyBuyer(order)
+			}
+		}
+	}
+
+	if errors.Is(err, database.ErrOrderStateChanged) {
+		return nil
+	}
+	return err
+}
+
+// settleOrder records the payment of an order, which issues its attendee.
+// Payments that can no longer be honoured, because the tickets sold out after
+// the order expired or the buyer was added to the event meanwhile, are
+// refunded right away.
+func (app *app) settleOrder(ctx context.Context, order *database.Order, paymentId string) error {
+	for {
+		if order.Status == database.OrderPaid || order.Status == database.OrderRefunded {
+			return nil
+		}
+
+		err := app.models.Orders.MarkPaid(order, paymentId)
+		if errors.Is(err, database.ErrOrderStateChanged) {
+			// The order expired or was paid concurrently, look again.
+			if order, err = app.models.Orders.Get(order.Id); err != nil || order == nil {
+				return err
+			}
+			continue
+		}
+		if errors.Is(err, database.ErrSoldOut) || errors.Is(err, database.ErrAlreadyAttending) {
+			log.Printf("refunding order %d: %v", order.Id, err)
+			if paymentId != "" {
+				if err := app.payments.Refund(ctx, paymentId); err != nil {
+					return err
+				}
+			}
+			order.PaymentId = paymentId
+			err = app.models.Orders.Refund(order)
+		}
+		if err != nil {
+			return err
+		}
+
+		app.publishAttendees(order.EventId)
+		app.notifyBuyer(order)
+		return nil
+	}
+}
+
+// userOrder loads the order named by the orderId parameter and checks that
+// the user bought it or may see the sales of its event. It writes the error
+// response and returns false otherwise.
+func (app *app) userOrder(c *gin.Context) (*database.Order, bool) {
+	orderId, err := strconv.Atoi(c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return nil, false
+	}
+
+	order, err := app.models.Orders.Get(orderId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order"})
+		return nil, false
+	}
+	if order == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return nil, false
+	}
+
+	user := app.getUserFromContext(c)
+	if order.UserId == user.Id {
+		return order, true
+	}
+
+	event, err := app.models.Events.Get(order.EventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return nil, false
+	}
+	if event != nil {
+		allowed, err := app.can(user, event, database.PermViewSales)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return nil, false
+		}
+		if allowed {
+			return order, true
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	return nil, false
+}
+
+// notifyBuyer notifies the buyer of an order that was paid or refunded.
+func (app *app) notifyBuyer(order *database.Order) {
+	buyer, err := app.models.Users.Get(order.UserId)
+	if err != nil || buyer == nil {
+		log.Printf("error retrieving buyer of order %d: %v", order.Id, err)
+		return
+	}
+
+	event, err := app.models.Events.Get(order.EventId)
+	if err != nil || event == nil {
+		log.Printf("error retrieving event of order %d: %v", order.Id, err)
+		return
+	}
+
+	switch order.Status {
+	case database.OrderPaid:
+		app.notifyUser(buyer, database.NotifyAttendeeAdded, event, "Your tickets for "+event.Name,
+			fmt.Sprintf("Thank you for your order! Your %d tickets for %s on %s are confirmed.",
+				order.Quantity, event.Name, formatEventStart(event)))
+	case database.OrderRefunded:
+		app.notifyUser(buyer, database.NotifyOrderRefunded, event,
+			"Your order for "+event.Name+" was refunded",
+			fmt.Sprintf("Your order #%d for %s has been refunded in full.", order.Id, event.Name))
+	}
+}
+
+// expireOrders releases the tickets held by abandoned orders.
+func (app *app) expireOrders() error {
+	n, err := app.models.Orders.ExpireDue(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("expired %d pending orders", n)
+	}
+	return nil
+}
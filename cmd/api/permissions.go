@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// can reports whether user may perform perm on event, either through the
+// role they hold on it or because they are an admin. user is the empty user
+// for anonymous requests, who may do nothing.
+func (app *app) can(user *database.User, event *database.Event, perm database.Permission) (bool, error) {
+	if user.IsAdmin && database.RoleAllows(database.RoleAdmin, perm) {
+		return true, nil
+	}
+
+	role, err := app.eventRole(user, event)
+	if err != nil {
+		return false, err
+	}
+
+	return database.RoleAllows(role, perm), nil
+}
+
+// eventRole returns the role user holds on event, or the empty string if they
+// neither own it nor are an accepted collaborator.
+func (app *app) eventRole(user *database.User, event *database.Event) (string, error) {
+	if user.Id == 0 {
+		return "", nil
+	}
+	if event.OwnerID == user.Id {
+		return database.RoleOwner, nil
+	}
+
+	collaborator, err := app.models.Collaborators.Get(event.Id, user.Id)
+	if err != nil {
+		return "", err
+	}
+	if collaborator == nil || collaborator.Status != database.CollaboratorAccepted {
+		return "", nil
+	}
+
+	return collaborator.Role, nil
+}
+
+// canViewEvent reports whether user may see event at all. Drafts are only
+// visible to their organizers and admins, private events also to their
+// attendees and invited users.
+func (app *app) canViewEvent(user *database.User, event *database.Event) (bool, error) {
+	if event.Status != database.EventDraft && event.Visibility != database.VisibilityPrivate {
+		return true, nil
+	}
+
+	allowed, err := app.can(user, event, database.PermViewEvent)
+	if err != nil || allowed || event.Status == database.EventDraft || user.Id == 0 {
+		return allowed, err
+	}
+
+	return app.models.Invites.HasAccess(event.Id, user.Id)
+}
+
+// eventWithPermission loads the event named by the id parameter and checks
+// that the user holds perm on it, answering with denied otherwise. It writes
+// the error response and returns false if the event cannot be used.
+func (app *app) eventWithPermission(c *gin.Context, perm database.Permission, denied string) (*database.Event, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return nil, false
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+
+	allowed, err := app.can(app.getUserFromContext(c), event, perm)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": denied})
+		return nil, false
+	}
+
+	return event, true
+}
+
+// visibleEvent loads the event named by the id parameter and checks that the
+// user may see it. It writes the error response and returns false if the
+// event cannot be used.
+func (app *app) visibleEvent(c *gin.Context) (*database.Event, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return nil, false
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+
+	visible, err := app.canViewEvent(app.getUserFromContext(c), event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return nil, false
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+
+	return event, true
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type promoCheckRequest struct {
+	Code         string `json:"code" binding:"required,max=32"`
+	TicketTypeId int    `json:"ticketTypeId" binding:"required"`
+	Quantity     int    `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// promoQuote is the price of tickets after a promo code, in the minor unit of
+// Currency.
+type promoQuote struct {
+	PromoCodeId int    `json:"promoCodeId"`
+	Code        string `json:"code"`
+	Subtotal    int64  `json:"subtotal"`
+	Discount    int64  `json:"discount"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+}
+
+// GetPromoCodes returns the promo codes of an event
+//
+//	@Summary			Returns the promo codes of an event
+//	@Description	Returns the promo codes of an event with how often each was redeemed. Requires
+//	@Description	the owner or a co-owner.
+//	@Tags				promo codes
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.PromoCode
+//	@Router			/api/v1/events/{id}/promo-codes [get]
+//	@Security		BearerAuth
+func (app *app) getPromoCodes(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermViewSales,
+		"You do not have permission to view the promo codes of this event")
+	if !ok {
+		return
+	}
+
+	codes, err := app.models.PromoCodes.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve promo codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// CreatePromoCode creates a promo code for an event
+//
+//	@Summary			Creates a promo code for an event
+//	@Description	Creates a code that takes a percentage or a fixed amount per order off paid
+//	@Description	tickets. Codes are case-insensitive and may be limited to some ticket types, to a
+//	@Description	number of uses overall and per user, and to a date window. Requires the owner or
+//	@Description	a co-owner.
+//	@Tags				promo codes
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int					true	"Event ID"
+//	@Param			promoCode	body		database.PromoCode	true	"Promo code"
+//	@Success			201	{object}	database.PromoCode
+//	@Router			/api/v1/events/{id}/promo-codes [post]
+//	@Security		BearerAuth
+func (app *app) createPromoCode(c *gin.Context) {
+	var code database.PromoCode
+	if !bindPromoCode(c, &code) {
+		return
+	}
+
+	event, ok := app.promoCodeEvent(c)
+	if !ok {
+		return
+	}
+	if !app.checkPromoTicketTypes(c, event, &code) {
+		return
+	}
+
+	code.EventId = event.Id
+	code.CreatedBy = app.getUserFromContext(c).Id
+	err := app.models.PromoCodes.Insert(&code)
+	if errors.Is(err, database.ErrPromoCodeExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This event already has a promo code with that name"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promo code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+// UpdatePromoCode updates a promo code
+//
+//	@Summary			Updates a promo code
+//	@Description	Replaces a promo code. Redemptions made so far are kept and count towards the new
+//	@Description	caps. Requires the owner or a co-owner.
+//	@Tags				promo codes
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int					true	"Event ID"
+//	@Param			codeId		path		int					true	"Promo code ID"
+//	@Param			promoCode	body		database.PromoCode	true	"Promo code"
+//	@Success			200	{object}	database.PromoCode
+//	@Router			/api/v1/events/{id}/promo-codes/{codeId} [put]
+//	@Security		BearerAuth
+func (app *app) updatePromoCode(c *gin.Context) {
+	var code database.PromoCode
+	if !bindPromoCode(c, &code) {
+		return
+	}
+
+	event, ok := app.promoCodeEvent(c)
+	if !ok {
+		return
+	}
+
+	existing, ok := app.eventPromoCode(c, event)
+	if !ok {
+		return
+	}
+	if !app.checkPromoTicketTypes(c, event, &code) {
+		return
+	}
+
+	code.Id = existing.Id
+	code.EventId = event.Id
+	err := app.models.PromoCodes.Update(&code)
+	if errors.Is(err, database.ErrPromoCodeExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This event already has a promo code with that name"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update promo code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+// DeletePromoCode deletes a promo code
+//
+//	@Summary			Deletes a promo code
+//	@Description	Deletes a promo code that was never redeemed. Redeemed codes can be ended by
+//	@Description	setting endsAt instead. Requires the owner or a co-owner.
+//	@Tags				promo codes
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			codeId	path	int	true	"Promo code ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/promo-codes/{codeId} [delete]
+//	@Security		BearerAuth
+func (app *app) deletePromoCode(c *gin.Context) {
+	event, ok := app.promoCodeEvent(c)
+	if !ok {
+		return
+	}
+
+	code, ok := app.eventPromoCode(c, event)
+	if !ok {
+		return
+	}
+
+	err := app.models.PromoCodes.Delete(code.Id)
+	if errors.Is(err, database.ErrPromoCodeInUse) {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Redeemed promo codes cannot be deleted, end them instead"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete promo code"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPromoRedemptions returns the promo code redemptions of an event
+//
+//	@Summary			Returns the promo code redemptions of an event
+//	@Description	Returns every redemption of the event's promo codes, newest first, with the order
+//	@Description	it was made on and its status, the attendee it issued once paid and the discount
+//	@Description	given. Redemptions of expired and refunded orders are given back and carry the
+//	@Description	time they were released. Requires the owner or a co-owner.
+//	@Tags				promo codes
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.PromoRedemption
+//	@Router			/api/v1/events/{id}/promo-codes/redemptions [get]
+//	@Security		BearerAuth
+func (app *app) getPromoRedemptions(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermViewSales,
+		"You do not have permission to view the promo codes of this event")
+	if !ok {
+		return
+	}
+
+	redemptions, err := app.models.PromoCodes.GetRedemptions(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve redemptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, redemptions)
+}
+
+// CheckPromoCode prices tickets with a promo code
+//
+//	@Summary			Prices tickets with a promo code
+//	@Description	Checks that a promo code can be used on the given tickets and returns the price
+//	@Description	after its discount, without redeeming it. Errors carry a reason: not_found,
+//	@Description	not_started, expired, exhausted or not_applicable.
+//	@Tags				promo codes
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int					true	"Event ID"
+//	@Param			check	body		promoCheckRequest	true	"Promo code and tickets"
+//	@Success			200	{object}	promoQuote
+//	@Router			/api/v1/events/{id}/promo-codes/check [post]
+//	@Security		BearerAuth
+func (app *app) checkPromoCode(c *gin.Context) {
+	var req promoCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	visible, err := app.canViewEvent(user, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	choice := ticketChoice{TicketTypeId: &req.TicketTypeId, Quantity: req.Quantity}
+	ticketType, ok := app.checkTicketChoice(c, event, &choice, false)
+	if !ok {
+		return
+	}
+
+	quote, ok := app.applyPromoCode(c, event, ticketType, choice.Quantity, req.Code, user.Id)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// applyPromoCode prices quantity tickets of ticketType with the promo code
+// named code for userId. It writes the error response and returns false if
+// the code cannot be used on them.
+func (app *app) applyPromoCode(c *gin.Context, event *database.Event, ticketType *database.TicketType,
+	quantity int, code string, userId int) (*promoQuote, bool) {
+	promo, err := app.models.PromoCodes.GetByCode(event.Id, code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve promo code"})
+		return nil, false
+	}
+	if promo == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found", "reason": "not_found"})
+		return nil, false
+	}
+
+	if err := promo.Check(ticketType, time.Now()); err != nil {
+		promoCodeError(c, err)
+		return nil, false
+	}
+	if promo.MaxUsesPerUser > 0 {
+		used, err := app.models.PromoCodes.CountRedemptions(promo.Id, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve redemptions"})
+			return nil, false
+		}
+		if used >= promo.MaxUsesPerUser {
+			promoCodeError(c, database.ErrPromoCodeUserLimit)
+			return nil, false
+		}
+	}
+
+	subtotal := ticketType.Price * int64(quantity)
+	discount := promo.Discount(subtotal)
+	return &promoQuote{
+		PromoCodeId: promo.Id,
+		Code:        promo.Code,
+		Subtotal:    subtotal,
+		Discount:    discount,
+		Amount:      subtotal - discount,
+		Currency:    ticketType.Currency,
+	}, true
+}
+
+// promoCodeError writes the response for an error from PromoCode.Check or
+// from redeeming a promo code. The reason lets clients tell them apart.
+func promoCodeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrPromoCodeNotStarted):
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "This promo code is not valid yet", "reason": "not_started"})
+	case errors.Is(err, database.ErrPromoCodeExpired):
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "This promo code has expired", "reason": "expired"})
+	case errors.Is(err, database.ErrPromoCodeExhausted):
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "This promo code has been used up", "reason": "exhausted"})
+	case errors.Is(err, database.ErrPromoCodeUserLimit):
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "You have already used this promo code", "reason": "exhausted"})
+	case errors.Is(err, database.ErrPromoCodeNotApplicable):
+		c.JSON(http.StatusBadRequest,
+			gin.H{"error": "This promo code does not apply to these tickets", "reason": "not_applicable"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply promo code"})
+	}
+}
+
+// bindPromoCode binds and checks a promo code from the request body. It
+// writes the error response and returns false if the body is invalid.
+func bindPromoCode(c *gin.Context, code *database.PromoCode) bool {
+	if err := c.ShouldBindJSON(code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if !promoCodePattern.MatchString(code.Code) {
+		c.JSON(http.StatusBadRequest,
+			gin.H{"error": "code may only contain letters, digits, dashes and underscores"})
+		return false
+	}
+	if code.Kind == database.PromoPercent && code.Value > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Percent promo codes cannot exceed 100"})
+		return false
+	}
+	if code.StartsAt != nil && code.EndsAt != nil && !code.EndsAt.After(*code.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endsAt must be after startsAt"})
+		return false
+	}
+
+	code.Code = strings.ToUpper(code.Code)
+	return true
+}
+
+// checkPromoTicketTypes checks that the ticket types a promo code is limited
+// to belong to event. It writes the error response and returns false
+// otherwise.
+func (app *app) checkPromoTicketTypes(c *gin.Context, event *database.Event, code *database.PromoCode) bool {
+	if len(code.TicketTypeIds) == 0 {
+		return true
+	}
+
+	ticketTypes, err := app.models.TicketTypes.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ticket types"})
+		return false
+	}
+
+	known := make(map[int]bool, len(ticketTypes))
+	for _, ticketType := range ticketTypes {
+		known[ticketType.Id] = true
+	}
+	for _, id := range code.TicketTypeIds {
+		if !known[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown ticket type for this event"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// promoCodeEvent loads the event named by the id parameter and checks that
+// the user may manage its promo codes.
+func (app *app) promoCodeEvent(c *gin.Context) (*database.Event, bool) {
+	return app.eventWithPermission(c, database.PermManagePromoCodes,
+		"You do not have permission to manage the promo codes of this event")
+}
+
+// eventPromoCode loads the promo code named by the codeId parameter and checks
+// that it belongs to event. It writes the error response and returns false
+// otherwise.
+func (app *app) eventPromoCode(c *gin.Context, event *database.Event) (*database.PromoCode, bool) {
+	codeId, err := strconv.Atoi(c.Param("codeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+		return nil, false
+	}
+
+	code, err := app.models.PromoCodes.Get(codeId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve promo code"})
+		return nil, false
+	}
+	if code == nil || code.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+		return nil, false
+	}
+
+	return code, true
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+type invalidAnswersResponse struct {
+	Error string `json:"error"`
+	// Answers maps the ids of the questions answered wrongly to the problem.
+	Answers map[string]string `json:"answers"`
+}
+
+// GetRegistrationForm returns the registration form of an event
+//
+//	@Summary			Returns the registration form of an event
+//	@Description	Returns the questions users answer when they register for the event, with their
+//	@Description	types, whether they are required and the rules their answers must follow. Events
+//	@Description	without questions return an empty form.
+//	@Tags				attendees
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	database.RegistrationForm
+//	@Router			/api/v1/events/{id}/registration-form [get]
+func (app *app) getRegistrationForm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	visible, err := app.canViewEvent(app.getUserFromContext(c), event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	form, err := app.models.RegistrationForms.Get(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration form"})
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// UpdateRegistrationForm replaces the registration form of an event
+//
+//	@Summary			Replaces the registration form of an event
+//	@Description	Replaces the questions users answer when they register. Questions are text,
+//	@Description	select, multi_select, checkbox or number questions, identified by an id of
+//	@Description	letters, digits, _ and -. Text questions take minLength, maxLength and a regular
+//	@Description	expression pattern the whole answer must match, multi-select questions
+//	@Description	minChoices and maxChoices, and number questions min, max and integer. Required
+//	@Description	checkboxes must be checked. Answers given before are kept. Requires the owner, a
+//	@Description	co-owner or an editor.
+//	@Tags				attendees
+//	@Accept			json
+//	@Produce			json
+//	@Param			id		path		int							true	"Event ID"
+//	@Param			form	body		database.RegistrationForm	true	"Registration form"
+//	@Success			200	{object}	database.RegistrationForm
+//	@Router			/api/v1/events/{id}/registration-form [put]
+//	@Security		BearerAuth
+func (app *app) updateRegistrationForm(c *gin.Context) {
+	var form database.RegistrationForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := form.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, ok := app.eventWithPermission(c, database.PermEditEvent,
+		"You do not have permission to edit the registration form of this event")
+	if !ok {
+		return
+	}
+
+	form.EventId = event.Id
+	if err := app.models.RegistrationForms.Set(&form, app.getUserFromContext(c).Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update registration form"})
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// checkAnswers validates answers against the registration form of event and
+// returns them in their stored form. Required questions may be left out if
+// optional is set. It writes the error response and returns false if the
+// answers are invalid.
+func (app *app) checkAnswers(c *gin.Context, event *database.Event, answers database.Answers,
+	optional bool) (database.Answers, bool) {
+	form, err := app.models.RegistrationForms.Get(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration form"})
+		return nil, false
+	}
+
+	checked, problems := form.Check(answers, optional)
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, invalidAnswersResponse{
+			Error:   "Some answers to the registration form are invalid",
+			Answers: problems,
+		})
+		return nil, false
+	}
+
+	return checked, true
+}
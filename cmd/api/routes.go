@@ -25,28 +25,163 @@ func (app *app) routes() http.Handler {
 
 	v1 := g.Group("/api/v1")
 	{
-		v1.GET("/events", app.getAllEvents)
-		v1.GET("/events/:id", app.getEvent)
+		v1.POST("/auth/register", app.register)
+		v1.POST("/auth/login", app.login)
+		v1.POST("/auth/verify-email", app.verifyEmail)
 
-		v1.GET("/events/:id/attendees", app.getAttendeesForEvent)
-		v1.GET("/attendees/:id/events", app.getEventsByAttendee)
+		// The chat authenticates itself, as browsers cannot send the
+		// Authorization header when opening a WebSocket.
+		v1.GET("/events/:id/chat", app.joinChat)
+	}
 
-		v1.GET("/users", app.getAllUsers)
+	publicGroup := v1.Group("/")
+	publicGroup.Use(app.OptionalAuthMiddleware())
+	{
+		publicGroup.GET("/events", app.getAllEvents)
+		publicGroup.GET("/events/:id", app.getEvent)
 
-		v1.POST("/auth/register", app.register)
-		v1.POST("/auth/login", app.login)
+		publicGroup.GET("/events/:id/attendees", app.getAttendeesForEvent)
+		publicGroup.GET("/events/:id/ticket-types", app.getTicketTypes)
+		publicGroup.GET("/events/:id/registration-form", app.getRegistrationForm)
+		publicGroup.GET("/events/:id/feedback-survey", app.getFeedbackSurvey)
+		publicGroup.GET("/events/:id/comments", app.getComments)
+		publicGroup.GET("/events/:id/comments/:commentId/replies", app.getCommentReplies)
+		publicGroup.GET("/events/:id/announcements", app.getAnnouncements)
+		publicGroup.GET("/events/:id/stream", app.streamEvent)
+		publicGroup.GET("/attendees/:id/events", app.getEventsByAttendee)
+
+		publicGroup.GET("/invites/:token", app.getInvite)
+
+		publicGroup.POST("/payments/webhook", app.paymentWebhook)
+
+		publicGroup.GET("/users", app.getAllUsers)
 	}
 
 	authGroup := v1.Group("/")
 	authGroup.Use(app.AuthMiddleware())
 	{
 		authGroup.POST("/events", app.createEvent)
+		authGroup.POST("/events/import", app.importEvents)
 		authGroup.PUT("/events/:id", app.updateEvent)
 		authGroup.DELETE("/events/:id", app.deleteEvent)
+		authGroup.POST("/events/:id/restore", app.restoreEvent)
+		authGroup.POST("/events/:id/status", app.changeEventStatus)
+		authGroup.GET("/events/:id/status-history", app.getEventStatusHistory)
+
+		authGroup.GET("/events/:id/collaborators", app.getCollaborators)
+		authGroup.POST("/events/:id/collaborators", app.inviteCollaborator)
+		authGroup.POST("/events/:id/collaborators/accept", app.acceptCollaboration)
+		authGroup.DELETE("/events/:id/collaborators/:userId", app.removeCollaborator)
+		authGroup.POST("/events/:id/transfer", app.transferOwnership)
+		authGroup.POST("/events/:id/transfer/accept", app.acceptOwnershipTransfer)
+		authGroup.DELETE("/events/:id/transfer", app.cancelOwnershipTransfer)
+
+		authGroup.GET("/events/:id/invites", app.getEventInvites)
+		authGroup.POST("/events/:id/invites", app.inviteUser)
+		authGroup.DELETE("/events/:id/invites/:userId", app.uninviteUser)
+		authGroup.GET("/events/:id/invite-links", app.getInviteLinks)
+		authGroup.POST("/events/:id/invite-links", app.createInviteLink)
+		authGroup.DELETE("/events/:id/invite-links/:linkId", app.revokeInviteLink)
+		authGroup.POST("/invites/:token/redeem", app.redeemInvite)
+
+		authGroup.GET("/events/:id/ticket-types/sales", app.getTicketSales)
+		authGroup.POST("/events/:id/ticket-types", app.createTicketType)
+		authGroup.PUT("/events/:id/ticket-types/:typeId", app.updateTicketType)
+		authGroup.DELETE("/events/:id/ticket-types/:typeId", app.deleteTicketType)
+
+		authGroup.PUT("/events/:id/registration-form", app.updateRegistrationForm)
+		authGroup.PUT("/events/:id/feedback-survey", app.updateFeedbackSurvey)
+		authGroup.POST("/events/:id/feedback", app.giveFeedback)
+		authGroup.GET("/events/:id/feedback/results", app.getFeedbackResults)
+
+		authGroup.POST("/events/:id/comments", app.createComment)
+		authGroup.PUT("/events/:id/comments/:commentId", app.updateComment)
+		authGroup.DELETE("/events/:id/comments/:commentId", app.deleteComment)
+		authGroup.POST("/events/:id/comments/:commentId/pin", app.pinComment)
+		authGroup.DELETE("/events/:id/comments/:commentId/pin", app.unpinComment)
+		authGroup.POST("/events/:id/comments/:commentId/hide", app.hideComment)
+		authGroup.DELETE("/events/:id/comments/:commentId/hide", app.unhideComment)
+		authGroup.POST("/events/:id/comments/:commentId/report", app.reportComment)
+		authGroup.GET("/events/:id/comment-reports", app.getCommentReports)
+		authGroup.POST("/events/:id/comment-reports/:reportId/resolve", app.resolveCommentReport)
+		authGroup.GET("/comment-reports", app.getAllCommentReports)
+
+		authGroup.GET("/events/:id/chat/messages", app.getChatMessages)
+		authGroup.DELETE("/events/:id/chat/messages/:messageId", app.deleteChatMessage)
+		authGroup.GET("/events/:id/chat/mutes", app.getChatMutes)
+		authGroup.POST("/events/:id/chat/mutes", app.muteChatUser)
+		authGroup.DELETE("/events/:id/chat/mutes/:userId", app.unmuteChatUser)
+
+		authGroup.POST("/events/:id/announcements", app.createAnnouncement)
+		authGroup.GET("/events/:id/announcements/:announcementId/deliveries",
+			app.getAnnouncementDeliveries)
+
+		authGroup.POST("/events/:id/orders", app.createOrder)
+		authGroup.GET("/events/:id/orders", app.getEventOrders)
+		authGroup.POST("/events/:id/orders/:orderId/refund", app.refundOrder)
+		authGroup.GET("/orders/:orderId", app.getOrder)
+		authGroup.DELETE("/orders/:orderId", app.cancelOrder)
+
+		authGroup.GET("/events/:id/promo-codes", app.getPromoCodes)
+		authGroup.GET("/events/:id/promo-codes/redemptions", app.getPromoRedemptions)
+		authGroup.POST("/events/:id/promo-codes/check", app.checkPromoCode)
+		authGroup.POST("/events/:id/promo-codes", app.createPromoCode)
+		authGroup.PUT("/events/:id/promo-codes/:codeId", app.updatePromoCode)
+		authGroup.DELETE("/events/:id/promo-codes/:codeId", app.deletePromoCode)
+
+		authGroup.GET("/events/:id/join-requests", app.getJoinRequests)
+		authGroup.POST("/events/:id/join-requests/:requestId/approve", app.approveJoinRequest)
+		authGroup.POST("/events/:id/join-requests/:requestId/reject", app.rejectJoinRequest)
+		authGroup.DELETE("/events/:id/join-requests/:requestId", app.withdrawJoinRequest)
 
 		authGroup.POST("/events/:id/attendees/:userId", app.addAttendeeToEvent)
 		authGroup.DELETE("/events/:id/attendees/:userId", app.deleteAttendeeFromEvent)
+		authGroup.GET("/events/:id/attendees/export", app.exportAttendees)
+		authGroup.GET("/events/:id/report", app.getEventReport)
+		authGroup.POST("/events/:id/attendees/import", app.importAttendees)
+
+		authGroup.GET("/events/:id/ticket", app.getMyTicket)
+		authGroup.GET("/events/:id/ticket/qr", app.getMyTicketQR)
+		authGroup.POST("/events/:id/checkin", app.checkIn)
+		authGroup.GET("/events/:id/checkin/counts", app.getCheckInCounts)
+		authGroup.DELETE("/events/:id/checkin/:attendeeId", app.undoCheckIn)
+		authGroup.GET("/events/:id/checkin/snapshot", app.getCheckInSnapshot)
+		authGroup.GET("/events/:id/checkin/changes", app.getCheckInChanges)
+		authGroup.POST("/events/:id/checkin/batch", app.checkInBatch)
+		authGroup.GET("/events/:id/checkin/devices", app.getCheckInDevices)
+		authGroup.GET("/events/:id/checkin/devices/:deviceId/scans", app.getDeviceScans)
+
+		authGroup.GET("/users/me", app.getCurrentUser)
+		authGroup.PATCH("/users/me", app.updateCurrentUser)
+		authGroup.DELETE("/users/me", app.deleteCurrentUser)
+		authGroup.POST("/users/me/password", app.changePassword)
+		authGroup.POST("/users/me/erasure", app.eraseCurrentUser)
+
+		authGroup.GET("/users/me/collaborations", app.getMyCollaborations)
+		authGroup.GET("/users/me/join-requests", app.getMyJoinRequests)
+		authGroup.GET("/users/me/orders", app.getMyOrders)
+
+		authGroup.GET("/users/me/stream", app.streamNotifications)
+		authGroup.GET("/users/me/notifications", app.getNotifications)
+		authGroup.GET("/users/me/notifications/unread-count", app.getUnreadNotificationCount)
+		authGroup.POST("/users/me/notifications/read", app.markAllNotificationsRead)
+		authGroup.POST("/users/me/notifications/:id/read", app.markNotificationRead)
+		authGroup.GET("/users/me/notification-preferences", app.getNotificationPreferences)
+		authGroup.PUT("/users/me/notification-preferences", app.updateNotificationPreferences)
+		authGroup.GET("/users/me/notification-settings", app.getNotificationSettings)
+		authGroup.PUT("/users/me/notification-settings", app.updateNotificationSettings)
+
+		authGroup.GET("/users/me/sessions", app.getSessions)
+		authGroup.DELETE("/users/me/sessions", app.revokeOtherSessions)
+		authGroup.DELETE("/users/me/sessions/:id", app.revokeSession)
+
+		authGroup.POST("/users/me/export", app.requestDataExport)
+		authGroup.GET("/users/me/exports", app.getDataExports)
+		authGroup.GET("/users/me/exports/:id/download", app.downloadDataExport)
 
+		authGroup.GET("/users/me/imports", app.getImports)
+		authGroup.GET("/imports/:id", app.getImport)
+		authGroup.POST("/imports/:id/resume", app.resumeImport)
 	}
 
 	{
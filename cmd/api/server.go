@@ -16,6 +16,8 @@ func (app *app) serve() error {
 		WriteTimeout: 30 * time.Second,
 	}
 
+	app.startJobs()
+
 	log.Printf("Starting server on %s", s.Addr)
 
 	return s.ListenAndServe()
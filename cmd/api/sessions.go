@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionResponse struct {
+	Id         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+// GetSessions returns the user's active sessions
+//
+//	@Summary			Returns the authenticated user's active sessions
+//	@Description	Returns the devices the authenticated user is signed in on, most recently used first
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	[]sessionResponse
+//	@Router			/api/v1/users/me/sessions [get]
+//	@Security		BearerAuth
+func (app *app) getSessions(c *gin.Context) {
+	user := app.getUserFromContext(c)
+	current := app.getSessionFromContext(c)
+
+	sessions, err := app.models.Sessions.GetActiveByUser(user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	response := make([]sessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, sessionResponse{
+			Id:         s.Id,
+			Device:     s.Device,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.Id == current.Id,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession signs out one of the user's sessions
+//
+//	@Summary			Signs out one of the authenticated user's sessions
+//	@Description	Revokes a session, after which its tokens are rejected
+//	@Tags				Users
+//	@Param			id		path	string	true	"Session ID"
+//	@Success			204
+//	@Router			/api/v1/users/me/sessions/{id} [delete]
+//	@Security		BearerAuth
+func (app *app) revokeSession(c *gin.Context) {
+	user := app.getUserFromContext(c)
+
+	revoked, err := app.models.Sessions.Revoke(user.Id, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOtherSessions signs out every other session of the user
+//
+//	@Summary			Signs out every other session of the authenticated user
+//	@Description	Revokes every session except the one making the request
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	map[string]int
+//	@Router			/api/v1/users/me/sessions [delete]
+//	@Security		BearerAuth
+func (app *app) revokeOtherSessions(c *gin.Context) {
+	user := app.getUserFromContext(c)
+	current := app.getSessionFromContext(c)
+
+	n, err := app.models.Sessions.RevokeAllExcept(user.Id, current.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": n})
+}
+
+// describeDevice derives a short device description such as "Chrome on
+// Windows" from a User-Agent header.
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	var os string
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		os = "iOS"
+	case strings.Contains(userAgent, "Android"):
+		os = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		os = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"), strings.Contains(userAgent, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		os = "Linux"
+	}
+
+	var client string
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		client = "Edge"
+	case strings.Contains(userAgent, "Firefox/"):
+		client = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		client = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		client = "Safari"
+	default:
+		client, _, _ = strings.Cut(userAgent, "/")
+	}
+
+	if os == "" {
+		return client
+	}
+	return client + " on " + os
+}
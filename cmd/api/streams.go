@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/broker"
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// Types of the messages streamed to clients.
+const (
+	streamEventUpdated        = "event.updated"
+	streamAttendeesChanged    = "attendees.changed"
+	streamAnnouncementCreated = "announcement.created"
+	streamCheckInsChanged     = "checkins.changed"
+	// streamReset tells clients that messages were lost and they have to
+	// reload what they follow.
+	streamReset = "reset"
+)
+
+// attendeeCounts is the data of attendees.changed messages.
+type attendeeCounts struct {
+	Attendees int `json:"attendees"`
+	Tickets   int `json:"tickets"`
+}
+
+// StreamEvent streams the changes to an event
+//
+//	@Summary			Streams the changes to an event
+//	@Description	Streams Server-Sent Events as the event changes: event.updated with the event,
+//	@Description	announcement.created with the announcement, attendees.changed with the attendee
+//	@Description	and ticket counts if the attendees are visible, and checkins.changed with the
+//	@Description	check-in counts for check-in staff and organizers. Send the Last-Event-ID header
+//	@Description	when reconnecting to receive what was missed; a reset event means that is no
+//	@Description	longer possible and the event should be reloaded. Comments are sent as
+//	@Description	heartbeats while nothing happens.
+//	@Tags				streams
+//	@Produce			text/event-stream
+//	@Param			id				path		int		true	"Event ID"
+//	@Param			Last-Event-ID	header		string	false	"Id of the last message received"
+//	@Success			200
+//	@Router			/api/v1/events/{id}/stream [get]
+func (app *app) streamEvent(c *gin.Context) {
+	event, ok := app.visibleEvent(c)
+	if !ok {
+		return
+	}
+
+	user := app.getUserFromContext(c)
+	types := map[string]bool{streamEventUpdated: true, streamAnnouncementCreated: true}
+
+	attendees, err := app.canListAttendees(user, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	types[streamAttendeesChanged] = attendees
+
+	checkIns, err := app.can(user, event, database.PermCheckIn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	types[streamCheckInsChanged] = checkIns
+
+	app.stream(c, broker.EventTopic(event.Id), func(m broker.Message) bool {
+		return types[m.Type]
+	})
+}
+
+// StreamNotifications streams the notifications of the authenticated user
+//
+//	@Summary			Streams the notifications of the authenticated user
+//	@Description	Streams a notification.created Server-Sent Event with every notification kept in
+//	@Description	the notification center of the user. Reconnecting works like for event streams.
+//	@Tags				streams
+//	@Produce			text/event-stream
+//	@Param			Last-Event-ID	header		string	false	"Id of the last message received"
+//	@Success			200
+//	@Router			/api/v1/users/me/stream [get]
+//	@Security		BearerAuth
+func (app *app) streamNotifications(c *gin.Context) {
+	app.stream(c, broker.UserTopic(app.getUserFromContext(c).Id), nil)
+}
+
+// stream sends the messages of topic that pass filter, or all of them if
+// filter is nil, as Server-Sent Events until the client goes away.
+func (app *app) stream(c *gin.Context, topic string, filter func(broker.Message) bool) {
+	lastId, err := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	if err != nil {
+		lastId = 0
+	}
+
+	sub := app.broker.Subscribe(topic, lastId)
+	defer sub.Close()
+
+	// Streams last longer than the write timeout of the server.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("error clearing the write deadline of a stream: %v", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+
+	send := func(m broker.Message) {
+		if filter == nil || filter(m) {
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(m.Id, 10), Event: m.Type, Data: m.Data})
+		}
+	}
+
+	if sub.Missed {
+		c.Render(-1, sse.Event{Event: streamReset, Data: gin.H{"topic": topic}})
+	}
+	for _, m := range sub.Replay {
+		send(m)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(app.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case m, ok := <-sub.C:
+			if !ok {
+				// The client fell behind. It resumes from the last message it
+				// received when it reconnects.
+				return
+			}
+			send(m)
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// publishEvent streams a change of an event.
+func (app *app) publishEvent(event *database.Event) {
+	e := *event
+	e.Localize(nil)
+	app.broker.Publish(broker.EventTopic(e.Id), streamEventUpdated, &e)
+}
+
+// publishAttendees streams the attendee and check-in counts of an event after
+// attendees joined or left.
+func (app *app) publishAttendees(eventId int) {
+	counts, err := app.models.CheckIns.Counts(eventId)
+	if err != nil {
+		log.Printf("error counting the attendees of event %d: %v", eventId, err)
+		return
+	}
+
+	topic := broker.EventTopic(eventId)
+	app.broker.Publish(topic, streamAttendeesChanged,
+		attendeeCounts{Attendees: counts.Attendees, Tickets: counts.Tickets})
+	app.broker.Publish(topic, streamCheckInsChanged, counts)
+}
+
+// publishCheckIns streams the check-in counts of an event after attendees
+// were checked in or out.
+func (app *app) publishCheckIns(eventId int) {
+	counts, err := app.models.CheckIns.Counts(eventId)
+	if err != nil {
+		log.Printf("error counting the check-ins of event %d: %v", eventId, err)
+		return
+	}
+
+	app.broker.Publish(broker.EventTopic(eventId), streamCheckInsChanged, counts)
+}
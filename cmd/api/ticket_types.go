@@ -0,0 +1,325 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ticketChoice names the tickets a registration takes. Events without ticket
+// types take a single ticket and leave TicketTypeId empty.
+type ticketChoice struct {
+	TicketTypeId *int `json:"ticketTypeId"`
+	Quantity     int  `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// GetTicketTypes returns the ticket types of an event
+//
+//	@Summary			Returns the ticket types of an event
+//	@Description	Returns the ticket types of an event, cheapest first, with the number of tickets
+//	@Description	left. Prices are in the minor unit of their currency.
+//	@Tags				tickets
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.TicketType
+//	@Router			/api/v1/events/{id}/ticket-types [get]
+func (app *app) getTicketTypes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := app.models.Events.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	visible, err := app.canViewEvent(app.getUserFromContext(c), event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	ticketTypes, err := app.models.TicketTypes.GetByEvent(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ticket types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticketTypes)
+}
+
+// CreateTicketType creates a ticket type for an event
+//
+//	@Summary			Creates a ticket type for an event
+//	@Description	Creates a ticket type with a price in minor units (e.g. cents), a quantity, an
+//	@Description	optional sales window and per-order limits, which default to 1 and 10. Once an
+//	@Description	event has ticket types, every registration must pick one. Requires the owner, a
+//	@Description	co-owner or an editor.
+//	@Tags				tickets
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int					true	"Event ID"
+//	@Param			ticketType	body		database.TicketType	true	"Ticket type"
+//	@Success			201	{object}	database.TicketType
+//	@Router			/api/v1/events/{id}/ticket-types [post]
+//	@Security		BearerAuth
+func (app *app) createTicketType(c *gin.Context) {
+	var ticketType database.TicketType
+	if !bindTicketType(c, &ticketType) {
+		return
+	}
+
+	event, ok := app.ticketTypeEvent(c)
+	if !ok {
+		return
+	}
+
+	ticketType.EventId = event.Id
+	if err := app.models.TicketTypes.Insert(&ticketType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket type"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticketType)
+}
+
+// UpdateTicketType updates a ticket type
+//
+//	@Summary			Updates a ticket type
+//	@Description	Replaces a ticket type. The quantity cannot drop below the tickets already sold.
+//	@Description	Requires the owner, a co-owner or an editor.
+//	@Tags				tickets
+//	@Accept			json
+//	@Produce			json
+//	@Param			id			path		int					true	"Event ID"
+//	@Param			typeId		path		int					true	"Ticket type ID"
+//	@Param			ticketType	body		database.TicketType	true	"Ticket type"
+//	@Success			200	{object}	database.TicketType
+//	@Router			/api/v1/events/{id}/ticket-types/{typeId} [put]
+//	@Security		BearerAuth
+func (app *app) updateTicketType(c *gin.Context) {
+	var ticketType database.TicketType
+	if !bindTicketType(c, &ticketType) {
+		return
+	}
+
+	event, ok := app.ticketTypeEvent(c)
+	if !ok {
+		return
+	}
+
+	existing, ok := app.eventTicketType(c, event)
+	if !ok {
+		return
+	}
+
+	ticketType.Id = existing.Id
+	ticketType.EventId = event.Id
+	err := app.models.TicketTypes.Update(&ticketType)
+	if errors.Is(err, database.ErrQuantityTooSmall) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf(
+			"Quantity cannot be lower than the %d tickets already sold", existing.Sold)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update ticket type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticketType)
+}
+
+// DeleteTicketType deletes a ticket type
+//
+//	@Summary			Deletes a ticket type
+//	@Description	Deletes a ticket type that has not sold any ticket. Requires the owner, a
+//	@Description	co-owner or an editor.
+//	@Tags				tickets
+//	@Param			id		path	int	true	"Event ID"
+//	@Param			typeId	path	int	true	"Ticket type ID"
+//	@Success			204
+//	@Router			/api/v1/events/{id}/ticket-types/{typeId} [delete]
+//	@Security		BearerAuth
+func (app *app) deleteTicketType(c *gin.Context) {
+	event, ok := app.ticketTypeEvent(c)
+	if !ok {
+		return
+	}
+
+	ticketType, ok := app.eventTicketType(c, event)
+	if !ok {
+		return
+	}
+
+	err := app.models.TicketTypes.Delete(ticketType.Id)
+	if errors.Is(err, database.ErrTicketTypeInUse) {
+		c.JSON(http.StatusConflict,
+			gin.H{"error": "Ticket types with sold tickets cannot be deleted"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ticket type"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTicketSales returns the ticket sales of an event
+//
+//	@Summary			Returns the ticket sales of an event
+//	@Description	Returns how many tickets of each type were sold and the revenue of their paid
+//	@Description	orders after discounts, in minor units. Requires the owner or a co-owner.
+//	@Tags				tickets
+//	@Produce			json
+//	@Param			id	path		int	true	"Event ID"
+//	@Success			200	{object}	[]database.TicketSales
+//	@Router			/api/v1/events/{id}/ticket-types/sales [get]
+//	@Security		BearerAuth
+func (app *app) getTicketSales(c *gin.Context) {
+	event, ok := app.eventWithPermission(c, database.PermViewSales,
+		"You do not have permission to view the ticket sales of this event")
+	if !ok {
+		return
+	}
+
+	sales, err := app.models.TicketTypes.GetSales(event.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ticket sales"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sales)
+}
+
+// bindTicketType binds and checks a ticket type from the request body. It
+// writes the error response and returns false if the body is invalid.
+func bindTicketType(c *gin.Context, ticketType *database.TicketType) bool {
+	if err := c.ShouldBindJSON(ticketType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if ticketType.SalesStart != nil && ticketType.SalesEnd != nil &&
+		!ticketType.SalesEnd.After(*ticketType.SalesStart) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "salesEnd must be after salesStart"})
+		return false
+	}
+
+	return true
+}
+
+// ticketTypeEvent loads the event named by the id parameter and checks that
+// the user may manage its ticket types.
+func (app *app) ticketTypeEvent(c *gin.Context) (*database.Event, bool) {
+	return app.eventWithPermission(c, database.PermEditEvent,
+		"You do not have permission to manage the ticket types of this event")
+}
+
+// eventTicketType loads the ticket type named by the typeId parameter and
+// checks that it belongs to event. It writes the error response and returns
+// false otherwise.
+func (app *app) eventTicketType(c *gin.Context, event *database.Event) (*database.TicketType, bool) {
+	typeId, err := strconv.Atoi(c.Param("typeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type ID"})
+		return nil, false
+	}
+
+	ticketType, err := app.models.TicketTypes.Get(typeId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ticket type"})
+		return nil, false
+	}
+	if ticketType == nil || ticketType.EventId != event.Id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket type not found"})
+		return nil, false
+	}
+
+	return ticketType, true
+}
+
+// checkTicketChoice checks that choice names tickets event can sell and fills
+// in the default quantity. It returns the chosen ticket type, which is nil for
+// events without ticket types. Organizers may register outside the sales
+// window and the per-order limits, but never beyond the capacity. It writes
+// the error response and returns false if the choice cannot be bought.
+func (app *app) checkTicketChoice(c *gin.Context, event *database.Event, choice *ticketChoice,
+	organizer bool) (*database.TicketType, bool) {
+	if choice.Quantity == 0 {
+		choice.Quantity = 1
+	}
+
+	if choice.TicketTypeId == nil {
+		ticketTypes, err := app.models.TicketTypes.GetByEvent(event.Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ticket types"})
+			return nil, false
+		}
+		if len(ticketTypes) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ticketTypeId is required for this event"})
+			return nil, false
+		}
+		if choice.Quantity != 1 {
+			c.JSON(http.StatusBadRequest,
+				gin.H{"error": "Events without ticket types allow a single ticket"})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	ticketType, err := app.models.TicketTypes.Get(*choice.TicketTypeId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ticket type"})
+		return nil, false
+	}
+	if ticketType == nil || ticketType.EventId != event.Id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown ticket type for this event"})
+		return nil, false
+	}
+
+	if !organizer {
+		if err := ticketType.CheckOrder(choice.Quantity, time.Now()); err != nil {
+			ticketError(c, err, ticketType)
+			return nil, false
+		}
+	}
+	if ticketType.Remaining < choice.Quantity {
+		c.JSON(http.StatusConflict, gin.H{"error": "Not enough tickets left"})
+		return nil, false
+	}
+
+	return ticketType, true
+}
+
+// ticketError writes the response for an error from TicketType.CheckOrder.
+func ticketError(c *gin.Context, err error, ticketType *database.TicketType) {
+	switch {
+	case errors.Is(err, database.ErrSalesNotStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket sales have not started yet"})
+	case errors.Is(err, database.ErrSalesEnded):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ticket sales have ended"})
+	case errors.Is(err, database.ErrOrderLimit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf(
+			"Between %d and %d tickets of this type can be ordered at once",
+			ticketType.MinPerOrder, ticketType.MaxPerOrder)})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ticket order"})
+	}
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
 
 	"github.com/gin-gonic/gin"
 )
 
 // GetAllUsers Return all users
 // @Summary       Returns all users
-// @Description   Return all users
+// @Description   Return all users. Admins receive full user records, everyone else receives
+// @Description   public profiles of users who do not attend anonymously.
 // @Tags          Users
 // @Produce       json
-// @Success       200             {object} []database.User
+// @Success       200             {object} []database.PublicUser
 // @Router        /api/v1/users   [get]
 func (app *app) getAllUsers(c *gin.Context) {
 	users, err := app.models.Users.GetAll()
@@ -19,5 +25,281 @@ func (app *app) getAllUsers(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
 	}
-	c.JSON(http.StatusOK, users)
+
+	if app.getUserFromContext(c).IsAdmin {
+		c.JSON(http.StatusOK, users)
+		return
+	}
+
+	publicUsers := []*database.PublicUser{}
+	for _, u := range users {
+		if !u.Anonymous {
+			publicUsers = append(publicUsers, u.Public())
+		}
+	}
+	c.JSON(http.StatusOK, publicUsers)
+}
+
+type updateUserRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=2"`
+	Email *string `json:"email" binding:"omitempty,email"`
+
+	Anonymous *bool `json:"anonymous"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+type deleteAccountRequest struct {
+	Password         string `json:"password" binding:"required"`
+	OwnedEvents      string `json:"ownedEvents" binding:"omitempty,oneof=transfer cancel"`
+	TransferToUserId int    `json:"transferToUserId"`
+}
+
+// GetCurrentUser returns the authenticated user
+//
+//	@Summary			Returns the authenticated user
+//	@Description	Returns the authenticated user
+//	@Tags				Users
+//	@Produce			json
+//	@Success			200	{object}	database.User
+//	@Router			/api/v1/users/me [get]
+//	@Security		BearerAuth
+func (app *app) getCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, app.getUserFromContext(c))
+}
+
+// UpdateCurrentUser updates the authenticated user's profile
+//
+//	@Summary			Updates the authenticated user's profile
+//	@Description	Updates name, email and the anonymous attendance setting.
+//	@Description	A new email only takes effect once it has been verified.
+//	@Tags				Users
+//	@Accept			json
+//	@Produce			json
+//	@Param			user	body		updateUserRequest	true	"Profile fields"
+//	@Success			200	{object}	database.User
+//	@Router			/api/v1/users/me [patch]
+//	@Security		BearerAuth
+func (app *app) updateCurrentUser(c *gin.Context) {
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+
+	if req.Name != nil || req.Anonymous != nil {
+		if req.Name != nil {
+			user.Name = *req.Name
+		}
+		if req.Anonymous != nil {
+			user.Anonymous = *req.Anonymous
+		}
+
+		if err := app.models.Users.Update(user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+	}
+
+	if req.Email == nil || *req.Email == user.Email {
+		c.JSON(http.StatusOK, gin.H{"user": user})
+		return
+	}
+
+	existingUser, err := app.models.Users.GetByEmail(*req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+	if existingUser != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already in use"})
+		return
+	}
+
+	token, err := app.models.EmailVerifications.New(user.Id, *req.Email, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start email verification"})
+		return
+	}
+
+	body := fmt.Sprintf("Use this token to confirm your new email address: %s\n"+
+		"It expires in 24 hours.", token)
+	if err := app.mailer.Send(*req.Email, "Confirm your email address", body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "A verification token has been sent to the new email address",
+		"user":    user,
+	})
+}
+
+// ChangePassword changes the authenticated user's password
+//
+//	@Summary			Changes the authenticated user's password
+//	@Description	Changes the password, signs out every other session and invalidates every
+//	@Description	previously issued token. A new token for the current session is returned.
+//	@Tags				Users
+//	@Accept			json
+//	@Produce			json
+//	@Param			password	body		changePasswordRequest	true	"Passwords"
+//	@Success			200	{object}	loginResponse
+//	@Router			/api/v1/users/me/password [post]
+//	@Security		BearerAuth
+func (app *app) changePassword(c *gin.Context) {
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+
+	if match, _ := app.verifyPassword(user, req.CurrentPassword); !match {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	if err := app.passwordPolicy.Validate(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashPassword, err := app.hasher.Hash(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	if err := app.models.Users.UpdatePassword(user.Id, hashPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	session := app.getSessionFromContext(c)
+	if _, err := app.models.Sessions.RevokeAllExcept(user.Id, session.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	user.TokenVersion++
+	tokenString, err := app.issueToken(user, session.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse{Token: tokenString, UserId: user.Id})
+}
+
+// DeleteCurrentUser deletes the authenticated user's account
+//
+//	@Summary			Deletes the authenticated user's account
+//	@Description	Deletes the account. Owned events must either be transferred to another user or cancelled.
+//	@Description	Cancelled events are announced to their attendees and stay visible to them. Each event is
+//	@Description	handed to its longest-standing co-owner, drafts without one are deleted, and events nobody
+//	@Description	takes over stay with the erased account.
+//	@Tags				Users
+//	@Accept			json
+//	@Produce			json
+//	@Param			account	body	deleteAccountRequest	true	"Confirmation"
+//	@Success			204
+//	@Router			/api/v1/users/me [delete]
+//	@Security		BearerAuth
+func (app *app) deleteCurrentUser(c *gin.Context) {
+	var req deleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := app.getUserFromContext(c)
+
+	if match, _ := app.verifyPassword(user, req.Password); !match {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	ownedEvents, err := app.models.Events.GetByOwner(user.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve events"})
+		return
+	}
+
+	transferToId := 0
+	switch {
+	case len(ownedEvents) == 0:
+	case req.OwnedEvents == "":
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("You own %d events, choose whether to transfer or cancel them",
+				len(ownedEvents)),
+		})
+		return
+	case req.OwnedEvents == "transfer":
+		if req.TransferToUserId == 0 || req.TransferToUserId == user.Id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A different user to transfer events to is required"})
+			return
+		}
+
+		newOwner, err := app.models.Users.Get(req.TransferToUserId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving user"})
+			return
+		}
+		if newOwner == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		transferToId = newOwner.Id
+	}
+
+	if req.OwnedEvents == "cancel" {
+		if err := app.cancelOwnedEvents(user, ownedEvents); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel events"})
+			return
+		}
+	}
+
+	if err := app.models.Users.Delete(user.Id, transferToId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	app.audit(fmt.Sprintf("user:%d", user.Id), "account.delete", user.Id,
+		fmt.Sprintf("%d owned events, strategy %q", len(ownedEvents), req.OwnedEvents))
+
+	c.Status(http.StatusNoContent)
+}
+
+// cancelOwnedEvents cancels the published events of a user who deletes their
+// account and tells their attendees. Drafts and events that were already
+// cancelled or completed keep their status.
+func (app *app) cancelOwnedEvents(user *database.User, events []*database.Event) error {
+	const reason = "The organizer deleted their account."
+
+	for _, event := range events {
+		if !database.CanTransition(event.Status, database.EventCancelled) {
+			continue
+		}
+
+		err := app.models.Events.Transition(event, database.EventCancelled, user.Id, reason)
+		if errors.Is(err, database.ErrInvalidTransition) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		app.publishEvent(event)
+		app.notifyCancellation(event, reason)
+	}
+
+	return nil
 }
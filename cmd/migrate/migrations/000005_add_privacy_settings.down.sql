@@ -0,0 +1,4 @@
+ALTER TABLE events DROP COLUMN attendee_visibility;
+
+ALTER TABLE users DROP COLUMN is_admin;
+ALTER TABLE users DROP COLUMN anonymous;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN anonymous INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE events ADD COLUMN attendee_visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (attendee_visibility IN ('public', 'attendees', 'owner'));
\ No newline at end of file
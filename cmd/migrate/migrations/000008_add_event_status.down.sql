@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS event_status_history;
+
+ALTER TABLE events DROP COLUMN status;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE events ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'cancelled', 'completed'));
+
+CREATE TABLE IF NOT EXISTS event_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_by INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
\ No newline at end of file
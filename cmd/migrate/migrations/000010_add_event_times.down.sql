@@ -0,0 +1,14 @@
+ALTER TABLE events ADD COLUMN date DATETIME NOT NULL DEFAULT '1970-01-01';
+
+UPDATE events SET date = CASE
+    WHEN all_day THEN strftime('%Y-%m-%d', starts_at)
+    ELSE strftime('%Y-%m-%dT%H:%M:%SZ', starts_at)
+END;
+
+DROP INDEX IF EXISTS idx_events_ends_at;
+DROP INDEX IF EXISTS idx_events_starts_at;
+
+ALTER TABLE events DROP COLUMN all_day;
+ALTER TABLE events DROP COLUMN timezone;
+ALTER TABLE events DROP COLUMN ends_at;
+ALTER TABLE events DROP COLUMN starts_at;
\ No newline at end of file
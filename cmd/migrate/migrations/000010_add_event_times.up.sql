@@ -0,0 +1,21 @@
+ALTER TABLE events ADD COLUMN starts_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE events ADD COLUMN ends_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE events ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE events ADD COLUMN all_day INTEGER NOT NULL DEFAULT 0;
+
+-- Dates were stored as either YYYY-MM-DD or RFC 3339 text. Plain dates become
+-- all-day events, the others start and end at the given instant since their
+-- duration is unknown. Both are taken to be in UTC.
+UPDATE events SET all_day = length(date) = 10;
+
+UPDATE events SET
+    starts_at = strftime('%Y-%m-%d %H:%M:%S+00:00', date),
+    ends_at = CASE
+        WHEN all_day THEN strftime('%Y-%m-%d %H:%M:%S+00:00', date, '+1 day')
+        ELSE strftime('%Y-%m-%d %H:%M:%S+00:00', date)
+    END;
+
+ALTER TABLE events DROP COLUMN date;
+
+CREATE INDEX IF NOT EXISTS idx_events_starts_at ON events (starts_at);
+CREATE INDEX IF NOT EXISTS idx_events_ends_at ON events (ends_at);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS event_ownership_transfers;
+
+DROP TABLE IF EXISTS event_collaborators;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS event_collaborators (
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('co-owner', 'editor', 'checkin')),
+    status TEXT NOT NULL CHECK (status IN ('invited', 'accepted')),
+    invited_by INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    accepted_at DATETIME,
+    PRIMARY KEY (event_id, user_id),
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_collaborators_user_id ON event_collaborators (user_id);
+
+CREATE TABLE IF NOT EXISTS event_ownership_transfers (
+    event_id INTEGER PRIMARY KEY,
+    from_user_id INTEGER NOT NULL,
+    to_user_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (to_user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
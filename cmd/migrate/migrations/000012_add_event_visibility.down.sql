@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS event_invite_links;
+
+DROP TABLE IF EXISTS event_invites;
+
+ALTER TABLE events DROP COLUMN visibility;
\ No newline at end of file
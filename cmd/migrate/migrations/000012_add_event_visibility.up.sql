@@ -0,0 +1,26 @@
+ALTER TABLE events ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+CREATE TABLE IF NOT EXISTS event_invites (
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    invited_by INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (event_id, user_id),
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS event_invite_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    created_by INTEGER NOT NULL,
+    expires_at DATETIME,
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_invite_links_event_id ON event_invite_links (event_id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS join_requests;
+
+ALTER TABLE events DROP COLUMN approval_required;
\ No newline at end of file
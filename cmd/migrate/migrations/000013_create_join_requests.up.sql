@@ -0,0 +1,21 @@
+ALTER TABLE events ADD COLUMN approval_required INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS join_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    reason TEXT NOT NULL DEFAULT '',
+    decided_by INTEGER,
+    created_at DATETIME NOT NULL,
+    decided_at DATETIME,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_join_requests_event_id ON join_requests (event_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_join_requests_pending
+    ON join_requests (event_id, user_id) WHERE status = 'pending';
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE join_requests DROP COLUMN quantity;
+
+ALTER TABLE join_requests DROP COLUMN ticket_type_id;
+
+ALTER TABLE attendees DROP COLUMN quantity;
+
+ALTER TABLE attendees DROP COLUMN ticket_type_id;
+
+DROP TABLE IF EXISTS ticket_types;
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS ticket_types (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price INTEGER NOT NULL CHECK (price >= 0),
+    currency TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    sold INTEGER NOT NULL DEFAULT 0 CHECK (sold >= 0 AND sold <= quantity),
+    sales_start DATETIME,
+    sales_end DATETIME,
+    min_per_order INTEGER NOT NULL DEFAULT 1,
+    max_per_order INTEGER NOT NULL DEFAULT 10,
+    created_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_types_event_id ON ticket_types (event_id);
+
+ALTER TABLE attendees ADD COLUMN ticket_type_id INTEGER;
+
+ALTER TABLE attendees ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE join_requests ADD COLUMN ticket_type_id INTEGER;
+
+ALTER TABLE join_requests ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1;
\ No newline at end of file
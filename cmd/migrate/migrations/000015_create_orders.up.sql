@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    ticket_type_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'paid', 'expired', 'refunded')),
+    provider TEXT NOT NULL,
+    session_id TEXT NOT NULL DEFAULT '',
+    checkout_url TEXT NOT NULL DEFAULT '',
+    payment_id TEXT NOT NULL DEFAULT '',
+    attendee_id INTEGER,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    paid_at DATETIME,
+    refunded_at DATETIME,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (ticket_type_id) REFERENCES ticket_types (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_event_id ON orders (event_id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_session_id ON orders (provider, session_id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_pending_expires_at ON orders (expires_at) WHERE status = 'pending';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_pending
+    ON orders (event_id, user_id) WHERE status = 'pending';
\ No newline at end of file
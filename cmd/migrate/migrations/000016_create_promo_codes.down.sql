@@ -0,0 +1,9 @@
+ALTER TABLE orders DROP COLUMN discount;
+
+ALTER TABLE orders DROP COLUMN promo_code_id;
+
+DROP TABLE IF EXISTS promo_redemptions;
+
+DROP TABLE IF EXISTS promo_code_ticket_types;
+
+DROP TABLE IF EXISTS promo_codes;
\ No newline at end of file
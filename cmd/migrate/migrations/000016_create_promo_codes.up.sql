@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    code TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value INTEGER NOT NULL CHECK (value > 0),
+    currency TEXT NOT NULL DEFAULT '',
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    max_uses_per_user INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0),
+    starts_at DATETIME,
+    ends_at DATETIME,
+    created_by INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_codes_event_code ON promo_codes (event_id, code);
+
+CREATE TABLE IF NOT EXISTS promo_code_ticket_types (
+    promo_code_id INTEGER NOT NULL,
+    ticket_type_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    PRIMARY KEY (promo_code_id, ticket_type_id),
+    Foreign Key (promo_code_id) REFERENCES promo_codes (id) ON DELETE CASCADE,
+    Foreign Key (ticket_type_id) REFERENCES ticket_types (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    promo_code_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    order_id INTEGER NOT NULL UNIQUE,
+    attendee_id INTEGER,
+    discount INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    Foreign Key (promo_code_id) REFERENCES promo_codes (id) ON DELETE CASCADE,
+    Foreign Key (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_user ON promo_redemptions (promo_code_id, user_id);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_event_id ON promo_redemptions (event_id);
+
+ALTER TABLE orders ADD COLUMN promo_code_id INTEGER;
+
+ALTER TABLE orders ADD COLUMN discount INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS checkins (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    attendee_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    checked_in_by INTEGER NOT NULL,
+    checked_in_at DATETIME NOT NULL,
+    undone_by INTEGER,
+    undone_at DATETIME,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (attendee_id) REFERENCES attendees (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkins_event_id ON checkins (event_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_checkins_active
+    ON checkins (attendee_id) WHERE undone_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS checkin_scans;
+
+DROP TABLE IF EXISTS checkin_devices;
+
+DROP TABLE IF EXISTS checkin_log;
+
+ALTER TABLE checkins DROP COLUMN device_id;
\ No newline at end of file
@@ -0,0 +1,37 @@
+ALTER TABLE checkins ADD COLUMN device_id TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS checkin_log (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    attendee_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkin_log_event_id ON checkin_log (event_id, seq);
+
+CREATE TABLE IF NOT EXISTS checkin_devices (
+    event_id INTEGER NOT NULL,
+    device_id TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    cursor INTEGER NOT NULL DEFAULT 0,
+    first_seen_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    PRIMARY KEY (event_id, device_id),
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS checkin_scans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    device_id TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    attendee_id INTEGER,
+    checkin_id INTEGER,
+    result TEXT NOT NULL,
+    scanned_at DATETIME NOT NULL,
+    received_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkin_scans_device ON checkin_scans (event_id, device_id);
\ No newline at end of file
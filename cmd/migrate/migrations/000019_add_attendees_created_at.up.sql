@@ -0,0 +1,5 @@
+ALTER TABLE attendees ADD COLUMN created_at DATETIME;
+
+UPDATE attendees SET created_at = (
+    SELECT MIN(l.created_at) FROM checkin_log l WHERE l.attendee_id = attendees.id
+);
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('events', 'attendees')),
+    event_id INTEGER,
+    mapping TEXT NOT NULL DEFAULT '{}',
+    match_users INTEGER NOT NULL DEFAULT 0,
+    chunk_size INTEGER NOT NULL,
+    file_path TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'completed', 'failed')),
+    total_rows INTEGER NOT NULL,
+    next_row INTEGER NOT NULL DEFAULT 0,
+    imported INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    completed_at DATETIME,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_user_id ON import_jobs (user_id);
\ No newline at end of file
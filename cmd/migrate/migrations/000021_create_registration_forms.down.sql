@@ -0,0 +1,7 @@
+ALTER TABLE orders DROP COLUMN answers;
+
+ALTER TABLE join_requests DROP COLUMN answers;
+
+ALTER TABLE attendees DROP COLUMN answers;
+
+DROP TABLE IF EXISTS registration_forms;
\ No newline at end of file
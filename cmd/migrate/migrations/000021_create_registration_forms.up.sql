@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS registration_forms (
+    event_id INTEGER PRIMARY KEY,
+    questions TEXT NOT NULL DEFAULT '[]',
+    updated_by INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+ALTER TABLE attendees ADD COLUMN answers TEXT NOT NULL DEFAULT '{}';
+
+ALTER TABLE join_requests ADD COLUMN answers TEXT NOT NULL DEFAULT '{}';
+
+ALTER TABLE orders ADD COLUMN answers TEXT NOT NULL DEFAULT '{}';
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE events DROP COLUMN feedback_invited_at;
+
+ALTER TABLE events DROP COLUMN rating_count;
+
+ALTER TABLE events DROP COLUMN rating_total;
+
+DROP TABLE IF EXISTS event_feedback;
+
+DROP TABLE IF EXISTS feedback_surveys;
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS feedback_surveys (
+    event_id INTEGER PRIMARY KEY,
+    questions TEXT NOT NULL DEFAULT '[]',
+    updated_by INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS event_feedback (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    answers TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME NOT NULL,
+    UNIQUE (event_id, user_id),
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+ALTER TABLE events ADD COLUMN rating_total INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE events ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE events ADD COLUMN feedback_invited_at DATETIME;
+
+UPDATE events SET feedback_invited_at = ends_at WHERE ends_at < CURRENT_TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER,
+    body TEXT NOT NULL,
+    html TEXT NOT NULL,
+    pinned_at DATETIME,
+    hidden_at DATETIME,
+    hidden_by INTEGER,
+    created_at DATETIME NOT NULL,
+    edited_at DATETIME,
+    deleted_at DATETIME,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE,
+    Foreign Key (parent_id) REFERENCES comments (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_event_id ON comments (event_id, parent_id);
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+
+CREATE TABLE IF NOT EXISTS comment_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'dismissed', 'actioned')),
+    resolved_by INTEGER,
+    created_at DATETIME NOT NULL,
+    resolved_at DATETIME,
+    UNIQUE (comment_id, user_id),
+    Foreign Key (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_reports_status ON comment_reports (status, event_id);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notification_preferences;
+
+DROP TABLE IF EXISTS announcement_deliveries;
+
+DROP TABLE IF EXISTS announcements;
\ No newline at end of file
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS announcements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    automatic INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_event_id ON announcements (event_id);
+
+CREATE TABLE IF NOT EXISTS announcement_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    announcement_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sent', 'failed', 'skipped')),
+    channels TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL,
+    UNIQUE (announcement_id, user_id),
+    Foreign Key (announcement_id) REFERENCES announcements (id) ON DELETE CASCADE,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcement_deliveries_status
+    ON announcement_deliveries (status, announcement_id);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    enabled INTEGER NOT NULL,
+    PRIMARY KEY (user_id, type, channel),
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
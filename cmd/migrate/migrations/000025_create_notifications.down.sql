@@ -0,0 +1,7 @@
+ALTER TABLE events DROP COLUMN reminded_at;
+
+DROP TABLE IF EXISTS notification_settings;
+
+DROP TABLE IF EXISTS notification_digest_items;
+
+DROP TABLE IF EXISTS notifications;
\ No newline at end of file
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    event_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    read_at DATETIME,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id, id);
+
+CREATE TABLE IF NOT EXISTS notification_digest_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    event_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_digest_items_user_id
+    ON notification_digest_items (user_id);
+
+CREATE TABLE IF NOT EXISTS notification_settings (
+    user_id INTEGER PRIMARY KEY,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    webhook_secret TEXT NOT NULL DEFAULT '',
+    digest INTEGER NOT NULL DEFAULT 0,
+    digest_sent_at DATETIME,
+    Foreign Key (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+ALTER TABLE events ADD COLUMN reminded_at DATETIME;
+
+UPDATE events SET reminded_at = starts_at WHERE starts_at < CURRENT_TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS chat_mutes;
+
+DROP TABLE IF EXISTS chat_messages;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS chat_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    deleted_at DATETIME,
+    deleted_by INTEGER,
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_event_id ON chat_messages (event_id, id);
+CREATE INDEX IF NOT EXISTS idx_chat_messages_user_id ON chat_messages (user_id);
+
+CREATE TABLE IF NOT EXISTS chat_mutes (
+    event_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    muted_by INTEGER NOT NULL,
+    until DATETIME,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (event_id, user_id),
+    Foreign Key (event_id) REFERENCES events (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE promo_redemptions DROP COLUMN released_at;
\ No newline at end of file
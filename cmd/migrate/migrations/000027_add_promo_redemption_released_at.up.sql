@@ -0,0 +1 @@
+ALTER TABLE promo_redemptions ADD COLUMN released_at DATETIME;
\ No newline at end of file
@@ -0,0 +1 @@
+-- Registration times backfilled by the up migration are kept.
\ No newline at end of file
@@ -0,0 +1,8 @@
+UPDATE attendees SET created_at = COALESCE(
+    (SELECT MIN(o.paid_at) FROM orders o
+     WHERE o.attendee_id = attendees.id AND o.paid_at IS NOT NULL),
+    (SELECT MAX(r.decided_at) FROM join_requests r
+     WHERE r.event_id = attendees.event_id AND r.user_id = attendees.user_id
+       AND r.status = 'approved')
+)
+WHERE created_at IS NULL;
\ No newline at end of file
@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/api/v1/attendees/{id}/events": {
             "get": {
-                "description": "Returns all events for a given attendee",
+                "description": "Returns all events for a given attendee. Other users only see public events with a\npublic attendee list, and nothing at all for users who attend anonymously.",
                 "consumes": [
                     "application/json"
                 ],
@@ -35,6 +35,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone to also render the event times in",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -118,9 +124,84 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/auth/verify-email": {
+            "post": {
+                "description": "Confirms a pending email address change using the token sent to the new address",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Confirms a pending email address change",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.verifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/comment-reports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the reports of comments on all events, oldest first, with the reported\ncomments. Requires an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Returns the moderation queue of every event",
+                "parameters": [
+                    {
+                        "enum": [
+                            "open",
+                            "dismissed",
+                            "actioned"
+                        ],
+                        "type": "string",
+                        "description": "Only reports in this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.CommentReport"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/events": {
             "get": {
-                "description": "Returns all events",
+                "description": "Returns all published and completed public events. Drafts, unlisted and private\nevents are never listed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -131,6 +212,30 @@ const docTemplate = `{
                     "events"
                 ],
                 "summary": "Returns all events",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Also list cancelled events",
+                        "name": "includeCancelled",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "upcoming",
+                            "past"
+                        ],
+                        "type": "string",
+                        "description": "Only events that have not ended yet or that have ended",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone to also render the event times in",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -149,7 +254,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Creates a new event",
+                "description": "Creates a new event. Events are created as drafts unless status is \"published\".",
                 "consumes": [
                     "application/json"
                 ],
@@ -181,9 +286,70 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/events/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an event owned by the authenticated user for every row of a CSV file\nwith a header. The columns are read by field name (name, description, location,\nstartsAt, endsAt, timezone, allDay, attendeeVisibility, visibility,\napprovalRequired, status) unless mapping names another column for a field.\nRows are checked by the same rules as events created one by one, and nothing is\nimported if any row is invalid. A dry run only returns the report. The import\nthen runs in the background in chunks of 500 rows, each in its own transaction.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Imports events from a CSV file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping fields to columns",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dryRun",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/database.ImportJob"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.invalidImportResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/events/{id}": {
             "get": {
-                "description": "Returns a single event",
+                "description": "Returns a single event. Private events are only returned to their organizers,\nattendees and invited users.",
                 "consumes": [
                     "application/json"
                 ],
@@ -201,6 +367,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone to also render the event times in",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -218,7 +390,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Updates an existing event",
+                "description": "Updates an existing event. Requires the owner, a co-owner or an editor. A change\nof date or location is notified to the attendees, or announced to them with\nannounce, which requires the owner or a co-owner.",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,6 +417,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/database.Event"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Announce a new date or location to the attendees",
+                        "name": "announce",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -262,7 +440,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes an existing event",
+                "description": "Moves an event to the trash. It can be restored until it is purged after the\nretention period. Events with paid orders cannot be deleted until the orders are\nrefunded. Requires the owner or a co-owner.",
                 "consumes": [
                     "application/json"
                 ],
@@ -289,19 +467,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/events/{id}/attendees": {
+        "/api/v1/events/{id}/announcements": {
             "get": {
-                "description": "Returns all attendees for a given event",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the announcements the organizers made to the attendees, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "attendees"
+                    "announcements"
                 ],
-                "summary": "Returns all attendees for a given event",
+                "summary": "Returns the announcements of an event",
                 "parameters": [
                     {
                         "type": "integer",
@@ -317,21 +492,19 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.User"
+                                "$ref": "#/definitions/database.Announcement"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/events/{id}/attendees/{userId}": {
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Adds an attendee to an event",
+                "description": "Posts an announcement on the event and sends it to every attendee through the\nchannels they enabled for announcements. Delivery happens in the background and\nis tracked per attendee. Requires the owner or a co-owner.",
                 "consumes": [
                     "application/json"
                 ],
@@ -339,9 +512,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "attendees"
+                    "announcements"
                 ],
-                "summary": "Adds an attendee to an event",
+                "summary": "Announces something to the attendees of an event",
                 "parameters": [
                     {
                         "type": "integer",
@@ -351,39 +524,40 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "description": "Announcement",
+                        "name": "announcement",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.announcementRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/database.Attendee"
+                            "$ref": "#/definitions/database.Announcement"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/events/{id}/announcements/{announcementId}/deliveries": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes an attendee from an event",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns whether the announcement reached each attendee: pending, sent, failed\nwith the error, or skipped for attendees who turned off every channel, along\nwith the channels it went through. Requires the owner or a co-owner.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "attendees"
+                    "announcements"
                 ],
-                "summary": "Deletes an attendee from an event",
+                "summary": "Returns the delivery status of an announcement",
                 "parameters": [
                     {
                         "type": "integer",
@@ -394,127 +568,6041 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
+                        "description": "Announcement ID",
+                        "name": "announcementId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "sent",
+                            "failed",
+                            "skipped"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries in this status",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.deliveryReport"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/users": {
+        "/api/v1/events/{id}/attendees": {
             "get": {
-                "description": "Return all users",
+                "description": "Returns all attendees for a given event, subject to the event's attendee visibility.\nThe owner, co-owners and admins receive full user records including emails,\nwith each attendee's tickets and answers to the registration form, everyone\nelse receives public profiles with anonymous attendees masked.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "attendees"
+                ],
+                "summary": "Returns all attendees for a given event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Returns all users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.User"
+                                "$ref": "#/definitions/database.Registration"
                             }
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "database.Attendee": {
-            "type": "object",
-            "properties": {
-                "eventId": {
-                    "type": "integer"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "userId": {
-                    "type": "integer"
-                }
-            }
-        },
-        "database.Event": {
-            "type": "object",
-            "required": [
-                "date",
-                "description",
-                "location",
-                "name"
-            ],
-            "properties": {
-                "date": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string",
-                    "minLength": 10
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "location": {
-                    "type": "string",
-                    "minLength": 3
-                },
-                "name": {
-                    "type": "string",
-                    "minLength": 3
-                },
-                "ownerId": {
-                    "type": "integer"
-                }
-            }
         },
-        "database.User": {
-            "type": "object",
-            "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
+        "/api/v1/events/{id}/attendees/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams every attendee of the event with their email, RSVP status, ticket type,\nregistration and check-in time, followed by the pending and rejected join\nrequests, as CSV, JSON Lines or XLSX. Times are in the event's time zone. CSV\nand XLSX exports have a column per question of the registration form, named by\nits id. Requires the owner.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "attendees"
+                ],
+                "summary": "Exports the attendee list of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
                 }
             }
         },
-        "main.loginRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
+        "/api/v1/events/{id}/attendees/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds the user of every row of a CSV file with a header to the event. Users are\nidentified by the userId column, or by the email column when matchUsers is set.\nThe ticketTypeId and quantity columns choose their tickets. Columns are read by\nfield name unless mapping names another column for a field. Nothing is imported\nif any row is invalid, and a dry run only returns the report. The import then\nruns in the background in chunks of 500 rows, each in its own transaction, and\nskips users who joined meanwhile. Requires the owner, a co-owner or an editor,\nand the owner or a co-owner to match users by email.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Imports attendees of an event from a CSV file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object mapping fields to columns",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Identify users by email",
+                        "name": "matchUsers",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dryRun",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/database.ImportJob"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.invalidImportResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/attendees/{userId}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds an attendee to an event. The owner, co-owners and editors may add anyone,\nother users may RSVP to published events they can see by adding themselves.\nRSVPs to events that require approval create a pending join request instead,\noptionally with a message for the organizers. Events with ticket types need a\nticketTypeId and optionally a quantity; tickets are taken when the attendee is\nadded. Paid tickets are bought with an order instead, except by organizers.\nAnswers to the event's registration form are checked against its rules. Organizers\nadding someone else may leave required questions out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attendees"
+                ],
+                "summary": "Adds an attendee to an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tickets, answers and message for the organizers",
+                        "name": "rsvp",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.rsvpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Attendee"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/database.JoinRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.invalidAnswersResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes an attendee from an event. Users may always remove themselves, otherwise\nthe owner, a co-owner or an editor is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attendees"
+                ],
+                "summary": "Deletes an attendee from an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/chat": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrades to a WebSocket in the chat room of the event, open to its attendees and\norganizers. Authenticate with the Authorization header, or from browsers by\noffering the subprotocols \"bearer\" and the token. Send {\"body\": \"...\"} frames to\npost messages. The server sends {\"type\": ..., \"data\": ...} frames: message with a\nnew message, deleted with the id of a deleted message, muted and unmuted with the\nuser concerned, and error when a message could not be posted, for instance because\nthe user posts too often or is muted. Pass the id of the last message received as\nafter when reconnecting to receive what was missed first. Clients that fall behind\nare disconnected with close code 1013 and should reconnect. Users who may no\nlonger chat, or whose session was revoked, are disconnected with close code 1008\nwithin a minute.",
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Joins the chat room of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of the last message received",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/chat/messages": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a page of the messages in the chat room of the event, newest first. Pass\nnextCursor as cursor to get the next page. Open to its attendees and organizers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Returns the history of the chat room of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Messages to return, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.chatPage"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/chat/messages/{messageId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a message from the chat room of the event. Its author, the owner,\nco-owners and admins can delete it.",
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Deletes a chat message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/chat/mutes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the mutes in force in the chat room of the event, oldest first. Requires\nthe owner, a co-owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Returns the users muted in the chat room of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.ChatMute"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Keeps a user from posting in the chat room of the event for the given minutes,\nor until they are unmuted if none are given. Muting them again replaces their\nmute. Moderators cannot be muted. Requires the owner, a co-owner or an admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Mutes a user in the chat room of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User to mute",
+                        "name": "mute",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.muteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.ChatMute"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/chat/mutes/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lets a muted user post in the chat room of the event again. Requires the owner, a\nco-owner or an admin.",
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Unmutes a user in the chat room of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Checks in the attendee a scanned ticket token belongs to and records who checked\nthem in. Scanning a ticket that was already checked in returns the original\ncheck-in with duplicate set and status 200. Tickets of removed attendees are\nrejected. Every scan is audited under deviceId. Requires check-in staff or an\norganizer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Checks an attendee in",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Scanned ticket token",
+                        "name": "ticket",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.checkInRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.checkInResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.checkInResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Applies up to 500 queued scans and returns the result of each, in order:\nchecked_in, duplicate, superseded (the check-in was undone after the scan),\nrevoked (the attendee was removed) or invalid. When devices scanned the same\nticket, the earliest scan is the check-in whatever order they upload in, ties\ngoing to the lower device id. Uploading the same scans again is harmless.\nRequires check-in staff or an organizer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Uploads the scans a device made offline",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Queued scans",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.checkInBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.checkInBatchResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the attendees that joined, left or were checked in or out since the\ncursor, in their current state, and the cursor to continue from. While more is\nset there are further changes. Requires check-in staff or an organizer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the roster changes of an event since a cursor",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device asking for changes",
+                        "name": "deviceId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor of the last snapshot or changes",
+                        "name": "cursor",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 500,
+                        "description": "Attendees to return, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.rosterChanges"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/counts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many attendees and tickets are checked in out of the total, for\ndashboards at the entrance. Requires check-in staff or an organizer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the check-in counts of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.CheckInCounts"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/devices": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every device that synced with or scanned at the event, with who used it\nlast, how far it has synced and the totals of its scans. Requires the owner, a\nco-owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the check-in devices of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Device"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/devices/{deviceId}/scans": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every scan a device made at the event, newest first, with its result.\nRequires the owner, a co-owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the scans of a check-in device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "deviceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Scan"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every attendee with the hash of their signed ticket and their check-in,\nso a device can check tickets in while offline, and a cursor to ask for changes\nfrom. Requires check-in staff or an organizer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the check-in roster of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device asking for the roster",
+                        "name": "deviceId",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.rosterSnapshot"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/checkin/{attendeeId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reverts the check-in of an attendee, for example after a mistaken scan. The\nreverted check-in is kept with undoneAt set and the ticket can be scanned again.\nRequires check-in staff or an organizer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Reverts a check-in",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attendee ID",
+                        "name": "attendeeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.CheckIn"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/collaborators": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the collaborators of an event, including pending invitations.\nOnly the event's organizers and admins may list them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Returns the collaborators of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Collaborator"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Invites a user as co-owner, editor or check-in staff. The invitation takes effect once\nthe user accepts it. Co-owners and the owner may invite editors and check-in staff,\nonly the owner may invite co-owners.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Invites a user to help organize an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User and role",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.inviteCollaboratorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Collaborator"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/collaborators/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accepts the authenticated user's pending invitation to an event",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Accepts an invitation to help organize an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Collaborator"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/collaborators/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a collaborator or withdraws an invitation. Users may always remove\nthemselves, which also declines a pending invitation. Otherwise the same rules\nas for inviting apply.",
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Removes a collaborator from an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comment-reports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the reports of comments on the event, oldest first, with the reported\ncomments. Requires the owner, a co-owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Returns the moderation queue of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "dismissed",
+                            "actioned"
+                        ],
+                        "type": "string",
+                        "description": "Only reports in this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.CommentReport"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comment-reports/{reportId}/resolve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dismisses an open report, or hides or deletes the reported comment, which\ncloses every open report of it. Requires the owner, a co-owner or an admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Resolves a report of a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Report ID",
+                        "name": "reportId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "What to do",
+                        "name": "resolution",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.resolveReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.CommentReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comments": {
+            "get": {
+                "description": "Returns a page of the threads of an event, newest first, with the number of\nreplies of each. The first page also lists the pinned threads. Pass nextCursor\nas cursor to get the next page. Hidden comments are only shown to the owner,\nco-owners and admins, and deleted ones only while they have replies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Returns the discussion of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Threads to return, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.commentPage"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts a thread, or replies to one if parentId is set. The body is markdown,\nreturned along with its rendering as sanitized HTML. Anyone who can see the\nevent may comment. Users can post a limited number of comments per minute.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Comments on an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.commentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comments/{commentId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the body of a comment and marks it as edited. Only its author can\nedit it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Edits a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New body",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.editCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Erases a comment. Its replies stay in the thread. Its author, the owner,\nco-owners and admins can delete it. Open reports of it are closed.",
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Deletes a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comments/{commentId}/hide": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Hides a comment, and a thread's replies with it, from everyone but the owner,\nco-owners and admins. Open reports of it are closed. Requires the owner, a\nco-owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Hides a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Makes a hidden comment visible to everyone who can see the event. Requires the\nowner, a co-owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Shows a hidden comment again",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comments/{commentId}/pin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Pins a top-level comment to the top of the discussion. Requires the owner, a\nco-owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Pins a thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves a pinned comment back among the other threads. Requires the owner, a\nco-owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Unpins a thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comments/{commentId}/replies": {
+            "get": {
+                "description": "Returns a page of the replies to a comment, oldest first. Pass nextCursor as\ncursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Returns the replies to a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Replies to return, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.commentPage"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/comments/{commentId}/report": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Puts a comment in the moderation queue of the event's organizers and admins.\nUsers can report a comment once and file a limited number of reports per minute.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Reports an abusive comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Why the comment is abusive",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.reportCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.CommentReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/feedback": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Records a rating from 1 to 5 and the answers to the feedback survey. Only\nattendees can give feedback, once, after the event has ended. The rating counts\ntowards the public rating of the event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feedback"
+                ],
+                "summary": "Rates an event and answers its feedback survey",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rating and answers",
+                        "name": "feedback",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.feedbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Feedback"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.invalidAnswersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/feedback-survey": {
+            "get": {
+                "description": "Returns the questions attendees answer along with their rating once the event\nhas ended. Events without questions return an empty survey.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feedback"
+                ],
+                "summary": "Returns the feedback survey of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.FeedbackSurvey"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the questions attendees answer along with their rating. Questions\nfollow the rules of registration form questions. Feedback given before is kept.\nRequires the owner, a co-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feedback"
+                ],
+                "summary": "Replaces the feedback survey of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Feedback survey",
+                        "name": "survey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.FeedbackSurvey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.FeedbackSurvey"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/feedback/results": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many attendees gave feedback, the average rating and how often each\nrating was given, and the results of each survey question: how often each\noption was chosen, the average, lowest and highest number or the latest text\nanswers, up to 100. Requires the owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feedback"
+                ],
+                "summary": "Sums up the feedback on an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.feedbackResults"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/invite-links": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the invite links of an event with their tokens, newest first.\nRequires the owner, a co-owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Returns the invite links of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.InviteLink"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a link whose token makes whoever redeems it an attendee, even of a\nprivate event. The link can expire and be limited to a number of uses (0 for no\nlimit). Requires the owner, a co-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Creates a shareable invite link for an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Link settings",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createInviteLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.InviteLink"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/invite-links/{linkId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stops an invite link from being redeemed. Attendees who already redeemed it stay.",
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Revokes an invite link",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Invite link ID",
+                        "name": "linkId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/invites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the users invited to an event. Requires the owner, a co-owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Returns the users invited to an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.EventInvite"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lets a user open the event even if it is private, and notifies them.\nRequires the owner, a co-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Invites a user to an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User to invite",
+                        "name": "invite",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.inviteUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.EventInvite"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/invites/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Withdraws an invitation. Users who already attend keep attending.",
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Withdraws the invitation of a user to an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/join-requests": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the join requests of an event, oldest first. Requires the owner, a\nco-owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "join requests"
+                ],
+                "summary": "Returns the join requests of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Only requests in this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.JoinRequest"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/join-requests/{requestId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lets the applicant withdraw their own pending join request",
+                "tags": [
+                    "join requests"
+                ],
+                "summary": "Withdraws a pending join request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Join request ID",
+                        "name": "requestId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/join-requests/{requestId}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Makes the applicant an attendee with the tickets they asked for and notifies\nthem. Fails if too few tickets are left. Applicants for paid tickets are not\nadded but may then buy them with an order, and the request is returned. Requires\nthe owner, a co-owner or an editor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "join requests"
+                ],
+                "summary": "Approves a join request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Join request ID",
+                        "name": "requestId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.JoinRequest"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Attendee"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/join-requests/{requestId}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rejects a join request and notifies the applicant, with the reason if one is\ngiven. Requires the owner, a co-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "join requests"
+                ],
+                "summary": "Rejects a join request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Join request ID",
+                        "name": "requestId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "rejection",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.rejectJoinRequestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.JoinRequest"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/orders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the orders of an event, newest first. Requires the owner or a co-owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Returns the orders of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "paid",
+                            "expired",
+                            "refunded"
+                        ],
+                        "type": "string",
+                        "description": "Only orders in this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Order"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a pending order that holds the tickets for a limited time and starts the\npayment. The buyer completes it at checkoutUrl, after which they become an\nattendee. Providers that settle right away return the order already paid, and so\nare orders a promo code makes free. Events that require approval need an approved\njoin request first. Promo code errors carry a reason like POST promo-codes/check.\nThe event's registration form is answered in answers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Starts buying paid tickets",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tickets to buy",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.orderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.invalidAnswersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/orders/{orderId}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Pays the order back in full through the payment provider, removes the attendee\nand releases the tickets. Requires the owner or a co-owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Refunds a paid order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Order"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/promo-codes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the promo codes of an event with how often each was redeemed. Requires\nthe owner or a co-owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo codes"
+                ],
+                "summary": "Returns the promo codes of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PromoCode"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a code that takes a percentage or a fixed amount per order off paid\ntickets. Codes are case-insensitive and may be limited to some ticket types, to a\nnumber of uses overall and per user, and to a date window. Requires the owner or\na co-owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo codes"
+                ],
+                "summary": "Creates a promo code for an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Promo code",
+                        "name": "promoCode",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.PromoCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.PromoCode"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/promo-codes/check": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Checks that a promo code can be used on the given tickets and returns the price\nafter its discount, without redeeming it. Errors carry a reason: not_found,\nnot_started, expired, exhausted or not_applicable.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo codes"
+                ],
+                "summary": "Prices tickets with a promo code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Promo code and tickets",
+                        "name": "check",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.promoCheckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.promoQuote"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/promo-codes/redemptions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every redemption of the event's promo codes, newest first, with the order\nit was made on and its status, the attendee it issued once paid and the discount\ngiven. Redemptions of expired and refunded orders are given back and carry the\ntime they were released. Requires the owner or a co-owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo codes"
+                ],
+                "summary": "Returns the promo code redemptions of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PromoRedemption"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/promo-codes/{codeId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces a promo code. Redemptions made so far are kept and count towards the new\ncaps. Requires the owner or a co-owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "promo codes"
+                ],
+                "summary": "Updates a promo code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Promo code ID",
+                        "name": "codeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Promo code",
+                        "name": "promoCode",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.PromoCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.PromoCode"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a promo code that was never redeemed. Redeemed codes can be ended by\nsetting endsAt instead. Requires the owner or a co-owner.",
+                "tags": [
+                    "promo codes"
+                ],
+                "summary": "Deletes a promo code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Promo code ID",
+                        "name": "codeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/registration-form": {
+            "get": {
+                "description": "Returns the questions users answer when they register for the event, with their\ntypes, whether they are required and the rules their answers must follow. Events\nwithout questions return an empty form.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attendees"
+                ],
+                "summary": "Returns the registration form of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RegistrationForm"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the questions users answer when they register. Questions are text,\nselect, multi_select, checkbox or number questions, identified by an id of\nletters, digits, _ and -. Text questions take minLength, maxLength and a regular\nexpression pattern the whole answer must match, multi-select questions\nminChoices and maxChoices, and number questions min, max and integer. Required\ncheckboxes must be checked. Answers given before are kept. Requires the owner, a\nco-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attendees"
+                ],
+                "summary": "Replaces the registration form of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Registration form",
+                        "name": "form",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.RegistrationForm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RegistrationForm"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/report": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the registrations and tickets of the event, its join requests, how many\nattendees were checked in and the show-up rate, with the registrations per day\nin the event's time zone and per ticket type. Registrations made before their\ntime was recorded are counted as undated. Requires the owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attendees"
+                ],
+                "summary": "Returns the registration report of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.eventReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restores a deleted event together with its attendees, as long as it has not been purged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Restores a deleted event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/status": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Publishes a draft, or cancels or completes a published event.\nAttendees are notified when an event is cancelled. Requires the owner or a co-owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Moves an event through its lifecycle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.eventStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/status-history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every status change of an event, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Returns the status changes of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.EventStatusChange"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/stream": {
+            "get": {
+                "description": "Streams Server-Sent Events as the event changes: event.updated with the event,\nannouncement.created with the announcement, attendees.changed with the attendee\nand ticket counts if the attendees are visible, and checkins.changed with the\ncheck-in counts for check-in staff and organizers. Send the Last-Event-ID header\nwhen reconnecting to receive what was missed; a reset event means that is no\nlonger possible and the event should be reloaded. Comments are sent as\nheartbeats while nothing happens.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "Streams the changes to an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last message received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/ticket": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the signed ticket token of the user's registration, which staff scan at\nthe entrance, and its check-in if the user was checked in.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the authenticated user's ticket for an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ticketResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/ticket-types": {
+            "get": {
+                "description": "Returns the ticket types of an event, cheapest first, with the number of tickets\nleft. Prices are in the minor unit of their currency.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Returns the ticket types of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.TicketType"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a ticket type with a price in minor units (e.g. cents), a quantity, an\noptional sales window and per-order limits, which default to 1 and 10. Once an\nevent has ticket types, every registration must pick one. Requires the owner, a\nco-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Creates a ticket type for an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ticket type",
+                        "name": "ticketType",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.TicketType"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.TicketType"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/ticket-types/sales": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many tickets of each type were sold and the revenue of their paid\norders after discounts, in minor units. Requires the owner or a co-owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Returns the ticket sales of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.TicketSales"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/ticket-types/{typeId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces a ticket type. The quantity cannot drop below the tickets already sold.\nRequires the owner, a co-owner or an editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Updates a ticket type",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ticket type ID",
+                        "name": "typeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ticket type",
+                        "name": "ticketType",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.TicketType"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.TicketType"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a ticket type that has not sold any ticket. Requires the owner, a\nco-owner or an editor.",
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Deletes a ticket type",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ticket type ID",
+                        "name": "typeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/ticket/qr": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a PNG QR code holding the signed ticket token of the user's registration",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "check-in"
+                ],
+                "summary": "Returns the authenticated user's ticket as a QR code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 256,
+                        "description": "Width and height in pixels, 128 to 1024",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Offers the event to another user, replacing any earlier offer. Ownership changes\nonce the user accepts, after which the previous owner stays on as a co-owner.\nOnly the owner may transfer an event.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Offers the ownership of an event to another user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New owner",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.transferOwnershipRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.OwnershipTransfer"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lets the owner withdraw a pending transfer, or its recipient decline it",
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Withdraws or declines the transfer of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/{id}/transfer/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Makes the authenticated user the owner of an event offered to them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collaborators"
+                ],
+                "summary": "Accepts the ownership of an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/imports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the progress of an import the authenticated user started, and why it\nfailed if it did",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Returns an import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.ImportJob"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/imports/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Runs a failed import again from the first chunk that was not imported, for\nexample after making room for more tickets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Resumes a failed import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/database.ImportJob"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/invites/{token}": {
+            "get": {
+                "description": "Returns the event of a valid invite link, so that it can be shown before the\nlink is redeemed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Returns the event an invite link leads to",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invite link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone to also render the event times in",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/invites/{token}/redeem": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Makes the authenticated user an attendee of the event the link leads to.\nRedeeming a link again does not use it up further. Events with ticket types\nneed a ticketTypeId and optionally a quantity. Paid tickets are bought with an\norder instead. The event's registration form is answered in answers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Redeems an invite link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invite link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tickets to take and answers",
+                        "name": "tickets",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.redeemInviteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Attendee"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.invalidAnswersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/orders/{orderId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns an order to its buyer or to organizers who may see the event's sales",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Returns an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Order"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lets the buyer give up a pending order, which releases its tickets right away",
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Cancels a pending order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/payments/webhook": {
+            "post": {
+                "description": "Called by the payment provider when a checkout is paid or expires or a payment\nis refunded. Requests must carry the provider's signature.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Receives payment updates from the payment provider",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/users": {
+            "get": {
+                "description": "Return all users. Admins receive full user records, everyone else receives\npublic profiles of users who do not attend anonymously.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns all users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.PublicUser"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes the account. Owned events must either be transferred to another user or cancelled.\nCancelled events are announced to their attendees and stay visible to them. Each event is\nhanded to its longest-standing co-owner, drafts without one are deleted, and events nobody\ntakes over stay with the erased account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Deletes the authenticated user's account",
+                "parameters": [
+                    {
+                        "description": "Confirmation",
+                        "name": "account",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.deleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates name, email and the anonymous attendance setting.\nA new email only takes effect once it has been verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Updates the authenticated user's profile",
+                "parameters": [
+                    {
+                        "description": "Profile fields",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/collaborations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the authenticated user's collaborations and pending invitations, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns the events the authenticated user helps organize",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Collaborator"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/erasure": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Anonymizes the account instead of deleting it, so events keep their attendance\nfigures. The account can no longer be used afterwards.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Erases the authenticated user's personal data",
+                "parameters": [
+                    {
+                        "description": "Confirmation",
+                        "name": "account",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.eraseAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/export": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts building a zip archive with the profile, owned events, attendances and\nlogin history of the authenticated user. Poll the export until it is ready.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Starts building an archive of the authenticated user's data",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/database.DataExport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/exports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the authenticated user's data exports and their status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns the authenticated user's data exports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.DataExport"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/exports/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Downloads a finished data export as a zip archive",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Downloads a finished data export",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Export ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/imports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the imports the authenticated user started, newest first, with their\nprogress",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Returns the authenticated user's imports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.ImportJob"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/join-requests": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the join requests the authenticated user has made, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns the authenticated user's join requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.JoinRequest"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/notification-preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns for each type of notification whether each channel is enabled. Every\nchannel is enabled until the user turns it off.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Returns the notification preferences of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.NotificationPreferences"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turns channels on or off per type of notification, such as\n{\"announcement\": {\"email\": false}}. Preferences left out stay as they are. The\ntypes are announcement, attendee_added, event_updated, event_cancelled, reminder,\njoin_request, order_refunded, invite, collaboration and feedback_request, the\nchannels in_app, email and webhook.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Changes the notification preferences of the authenticated user",
+                "parameters": [
+                    {
+                        "description": "Preferences to change",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.NotificationPreferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.NotificationPreferences"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/notification-settings": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the webhook URL and secret of the webhook channel and whether emails are\ncollected into a daily digest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Returns the notification settings of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.NotificationSettings"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets the URL notifications of the webhook channel are posted to, or clears it\nwith an empty one, and chooses whether emails are collected into a daily\ndigest. Setting a new URL generates a new secret. Webhooks carry the hex encoded\nHMAC-SHA256 of their body, keyed with the secret, in the X-Signature header.\nWebhooks are not sent to local or private addresses and do not follow\nredirects.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Changes the notification settings of the authenticated user",
+                "parameters": [
+                    {
+                        "description": "Settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.NotificationSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.NotificationSettings"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a page of the notification center of the user, newest first. Pass\nnextCursor as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Returns the notifications of the authenticated user",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, up to 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.notificationPage"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/notifications/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Marks every notification as read and returns how many were unread.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Marks every notification of the authenticated user as read",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/notifications/unread-count": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Counts the unread notifications of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/notifications/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Marks a notification as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.Notification"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/orders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the orders the authenticated user has placed, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns the authenticated user's orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Order"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the password, signs out every other session and invalidates every\npreviously issued token. A new token for the current session is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Changes the authenticated user's password",
+                "parameters": [
+                    {
+                        "description": "Passwords",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.changePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.loginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the devices the authenticated user is signed in on, most recently used first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Returns the authenticated user's active sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.sessionResponse"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes every session except the one making the request",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Signs out every other session of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes a session, after which its tokens are rejected",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Signs out one of the authenticated user's sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams a notification.created Server-Sent Event with every notification kept in\nthe notification center of the user. Reconnecting works like for event streams.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "Streams the notifications of the authenticated user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the last message received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "database.Announcement": {
+            "type": "object",
+            "properties": {
+                "authorId": {
+                    "type": "integer"
+                },
+                "automatic": {
+                    "description": "Automatic announcements were made by changing the date or location of\nthe event.",
+                    "type": "boolean"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Answers": {
+            "type": "object",
+            "additionalProperties": {}
+        },
+        "database.Attendee": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers are the attendee's answers to the event's registration form.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Answers"
+                        }
+                    ]
+                },
+                "createdAt": {
+                    "description": "CreatedAt is unset for attendees registered before it was recorded.",
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "ticketTypeId": {
+                    "description": "TicketTypeId is set when the event sells tickets. Quantity is the\nnumber of tickets of that type the registration holds.",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.ChatMessage": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "userName": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.ChatMute": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "mutedBy": {
+                    "type": "integer"
+                },
+                "until": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "userName": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.CheckIn": {
+            "type": "object",
+            "properties": {
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "checkedInAt": {
+                    "description": "CheckedInAt is when the ticket was scanned, by the clock of the device\nfor scans uploaded later.",
+                    "type": "string"
+                },
+                "checkedInBy": {
+                    "type": "integer"
+                },
+                "deviceId": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "undoneAt": {
+                    "type": "string"
+                },
+                "undoneBy": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.CheckInCounts": {
+            "type": "object",
+            "properties": {
+                "attendees": {
+                    "type": "integer"
+                },
+                "checkedIn": {
+                    "type": "integer"
+                },
+                "checkedInTickets": {
+                    "type": "integer"
+                },
+                "tickets": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Collaborator": {
+            "type": "object",
+            "properties": {
+                "acceptedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "invitedBy": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Comment": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body is the markdown the author wrote and HTML its sanitized rendering.\nBoth are empty once the comment is deleted.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "description": "Deleted comments are kept while they have replies, so that their\nthread stays together.",
+                    "type": "boolean"
+                },
+                "editedAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "hiddenAt": {
+                    "type": "string"
+                },
+                "html": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "parentId": {
+                    "type": "integer"
+                },
+                "pinnedAt": {
+                    "type": "string"
+                },
+                "replies": {
+                    "description": "Replies counts the visible replies of a top-level comment.",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "userName": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.CommentReport": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "Comment is the reported comment, set in the moderation queue.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Comment"
+                        }
+                    ]
+                },
+                "commentId": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolvedAt": {
+                    "type": "string"
+                },
+                "resolvedBy": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.DataExport": {
+            "type": "object",
+            "properties": {
+                "completedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Delivery": {
+            "type": "object",
+            "properties": {
+                "announcementId": {
+                    "type": "integer"
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Device": {
+            "type": "object",
+            "properties": {
+                "checkedIn": {
+                    "type": "integer"
+                },
+                "cursor": {
+                    "type": "integer"
+                },
+                "deviceId": {
+                    "type": "string"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "firstSeenAt": {
+                    "type": "string"
+                },
+                "lastSeenAt": {
+                    "type": "string"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "scans": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Event": {
+            "type": "object",
+            "required": [
+                "description",
+                "endsAt",
+                "location",
+                "name",
+                "startsAt",
+                "timezone"
+            ],
+            "properties": {
+                "allDay": {
+                    "description": "AllDay events last whole days in their time zone. Only the dates of\nStartsAt and EndsAt matter, and EndsAt names the last day.",
+                    "type": "boolean"
+                },
+                "approvalRequired": {
+                    "description": "ApprovalRequired turns RSVPs into join requests that an organizer has\nto approve.",
+                    "type": "boolean"
+                },
+                "attendeeVisibility": {
+                    "description": "AttendeeVisibility controls who may list the attendees of the event.",
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "attendees",
+                        "owner"
+                    ]
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "minLength": 10
+                },
+                "endsAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "name": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "ownerId": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "description": "Rating is the average of the ratings attendees gave the event after it\nended, unset until the first one, and Ratings how many there are.",
+                    "type": "number"
+                },
+                "ratings": {
+                    "type": "integer"
+                },
+                "startsAt": {
+                    "description": "StartsAt and EndsAt are stored in UTC and rendered in Timezone, an IANA\ntime zone name, by Localize.",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status can only be chosen when the event is created. Afterwards it\nchanges through EventModel.Transition.",
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "published"
+                    ]
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "viewerTimes": {
+                    "description": "ViewerTimes holds StartsAt and EndsAt in the time zone of the viewer\nwhen one was requested.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.EventTimes"
+                        }
+                    ]
+                },
+                "visibility": {
+                    "description": "Visibility controls who may find and open the event. Unlisted events\nare left out of listings, private events are only reachable by their\norganizers, attendees and invited users.",
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "unlisted",
+                        "private"
+                    ]
+                }
+            }
+        },
+        "database.EventInvite": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "invitedBy": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.EventStatusChange": {
+            "type": "object",
+            "properties": {
+                "changedBy": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "fromStatus": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "toStatus": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.EventTimes": {
+            "type": "object",
+            "properties": {
+                "endsAt": {
+                    "type": "string"
+                },
+                "startsAt": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Feedback": {
+            "type": "object",
+            "required": [
+                "rating"
+            ],
+            "properties": {
+                "answers": {
+                    "$ref": "#/definitions/database.Answers"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.FeedbackSurvey": {
+            "type": "object",
+            "properties": {
+                "eventId": {
+                    "type": "integer"
+                },
+                "questions": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "items": {
+                        "$ref": "#/definitions/database.Question"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.ImportJob": {
+            "type": "object",
+            "properties": {
+                "chunkSize": {
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "description": "EventId is the event attendees are imported into.",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "mapping": {
+                    "description": "Mapping maps fields to the CSV columns holding them. Fields that are\nnot mapped are read from the column of the same name.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "matchUsers": {
+                    "description": "MatchUsers identifies imported attendees by email instead of user id.",
+                    "type": "boolean"
+                },
+                "nextRow": {
+                    "description": "NextRow counts the rows done, in file order.",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "totalRows": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.InviteLink": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "maxUses": {
+                    "description": "MaxUses caps how often the link can be redeemed. Zero means no cap.",
+                    "type": "integer"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token is filled in by the API when the link is shown to its organizers.",
+                    "type": "string"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.JoinRequest": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers are the answers to the event's registration form, which the\nattendee takes over once the request is approved.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Answers"
+                        }
+                    ]
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "decidedAt": {
+                    "type": "string"
+                },
+                "decidedBy": {
+                    "type": "integer"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticketTypeId": {
+                    "description": "TicketTypeId and Quantity are the tickets asked for, if the event sells\ntickets. They are only reserved once the request is approved.",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Notification": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "Id is only set for notifications kept in the notification center.",
+                    "type": "integer"
+                },
+                "readAt": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.NotificationPreferences": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "object",
+                "additionalProperties": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.NotificationSettings": {
+            "type": "object",
+            "properties": {
+                "digest": {
+                    "description": "Digest collects email notifications into one email a day.",
+                    "type": "boolean"
+                },
+                "digestSentAt": {
+                    "type": "string"
+                },
+                "webhookSecret": {
+                    "type": "string"
+                },
+                "webhookUrl": {
+                    "description": "WebhookURL receives the notifications sent through ChannelWebhook,\nsigned with WebhookSecret.",
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "database.Order": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is the total price after Discount, in the minor unit of Currency.",
+                    "type": "integer"
+                },
+                "answers": {
+                    "description": "Answers are the buyer's answers to the event's registration form, which\nthe attendee takes over once the order is paid.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Answers"
+                        }
+                    ]
+                },
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "checkoutUrl": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "paidAt": {
+                    "type": "string"
+                },
+                "promoCodeId": {
+                    "description": "PromoCodeId is the promo code redeemed on the order and Discount the\namount it took off.",
+                    "type": "integer"
+                },
+                "provider": {
+                    "description": "Provider is the payment provider that handles the order, SessionId and\nPaymentId are its references for the checkout and the payment.",
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "refundedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.OwnershipTransfer": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "fromUserId": {
+                    "type": "integer"
+                },
+                "toUserId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.PromoCode": {
+            "type": "object",
+            "required": [
+                "code",
+                "kind",
+                "value"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "endsAt": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ]
+                },
+                "maxUses": {
+                    "description": "MaxUses and MaxUsesPerUser cap the redemptions overall and per user.\nZero means no cap. Uses counts pending orders too.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxUsesPerUser": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "startsAt": {
+                    "type": "string"
+                },
+                "ticketTypeIds": {
+                    "description": "TicketTypeIds limits the code to these ticket types. Empty means every\nticket type of the event.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "uses": {
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "Value is the percentage taken off for percent codes, and the amount\ntaken off each order in the minor unit of Currency for fixed codes.",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "database.PromoRedemption": {
+            "type": "object",
+            "properties": {
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "orderId": {
+                    "type": "integer"
+                },
+                "orderStatus": {
+                    "type": "string"
+                },
+                "promoCodeId": {
+                    "type": "integer"
+                },
+                "releasedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.PublicUser": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Question": {
+            "type": "object",
+            "required": [
+                "id",
+                "label",
+                "options",
+                "type"
+            ],
+            "properties": {
+                "id": {
+                    "description": "Id is the key of the answer, which stays the same when the question is\nreworded.",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "integer": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "max": {
+                    "type": "number"
+                },
+                "maxChoices": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxLength": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                },
+                "min": {
+                    "type": "number"
+                },
+                "minChoices": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "minLength": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "options": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "pattern": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "text",
+                        "select",
+                        "multi_select",
+                        "checkbox",
+                        "number"
+                    ]
+                }
+            }
+        },
+        "database.Registration": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "Anonymous hides the user's identity from attendee lists shown to\nanyone but event owners and admins.",
+                    "type": "boolean"
+                },
+                "answers": {
+                    "$ref": "#/definitions/database.Answers"
+                },
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isAdmin": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.RegistrationForm": {
+            "type": "object",
+            "properties": {
+                "eventId": {
+                    "type": "integer"
+                },
+                "questions": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "items": {
+                        "$ref": "#/definitions/database.Question"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Scan": {
+            "type": "object",
+            "properties": {
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "checkIn": {
+                    "description": "CheckIn is the attendee's check-in after the scan was applied.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.CheckIn"
+                        }
+                    ]
+                },
+                "checkInId": {
+                    "type": "integer"
+                },
+                "deviceId": {
+                    "description": "DeviceId is chosen by the device, UserId is the staff member using it.",
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "receivedAt": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "Result is one of the Scan constants. It is decided by RecordScans\nunless the caller already rejected the ticket as invalid.",
+                    "type": "string"
+                },
+                "scannedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.TicketSales": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "description": "Revenue is what the paid orders of the ticket type brought in after\ndiscounts, in the minor unit of Currency. Refunded orders are left out.",
+                    "type": "integer"
+                },
+                "sold": {
+                    "type": "integer"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.TicketType": {
+            "type": "object",
+            "required": [
+                "currency",
+                "name",
+                "quantity"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "maxPerOrder": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "minPerOrder": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "price": {
+                    "description": "Price is in the minor unit of Currency, e.g. cents for EUR.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "remaining": {
+                    "description": "Remaining is filled in when the ticket type is read.",
+                    "type": "integer"
+                },
+                "salesEnd": {
+                    "type": "string"
+                },
+                "salesStart": {
+                    "description": "SalesStart and SalesEnd bound when the ticket type can be bought. Either\nmay be left open.",
+                    "type": "string"
+                }
+            }
+        },
+        "database.User": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "description": "Anonymous hides the user's identity from attendee lists shown to\nanyone but event owners and admins.",
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isAdmin": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "importer.Report": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "description": "Errors lists the problems found, at most the first thousand.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.RowError"
+                    }
+                },
+                "invalidRows": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "importer.RowError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "line": {
+                    "description": "Line is the line of the file the row starts on, the header being line 1.",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.announcementRequest": {
+            "type": "object",
+            "required": [
+                "body",
+                "title"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "main.changePasswordRequest": {
+            "type": "object",
+            "required": [
+                "currentPassword",
+                "newPassword"
+            ],
+            "properties": {
+                "currentPassword": {
+                    "type": "string"
+                },
+                "newPassword": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.chatPage": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.ChatMessage"
+                    }
+                },
+                "nextCursor": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.checkInBatchRequest": {
+            "type": "object",
+            "required": [
+                "deviceId",
+                "scans"
+            ],
+            "properties": {
+                "deviceId": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "scans": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.offlineScan"
+                    }
+                }
+            }
+        },
+        "main.checkInBatchResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Scan"
+                    }
+                }
+            }
+        },
+        "main.checkInRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "deviceId": {
+                    "description": "DeviceId names the scanning device in the audit, it defaults to online.",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.checkInResponse": {
+            "type": "object",
+            "properties": {
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "checkedInAt": {
+                    "description": "CheckedInAt is when the ticket was scanned, by the clock of the device\nfor scans uploaded later.",
+                    "type": "string"
+                },
+                "checkedInBy": {
+                    "type": "integer"
+                },
+                "deviceId": {
+                    "type": "string"
+                },
+                "duplicate": {
+                    "description": "Duplicate is set when the ticket had already been checked in, in which\ncase the check-in is the original one.",
+                    "type": "boolean"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                },
+                "undoneAt": {
+                    "type": "string"
+                },
+                "undoneBy": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.commentPage": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Comment"
+                    }
+                },
+                "nextCursor": {
+                    "type": "integer"
+                },
+                "pinned": {
+                    "description": "Pinned holds the pinned threads, on the first page of threads only.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Comment"
+                    }
+                }
+            }
+        },
+        "main.commentRequest": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "parentId": {
+                    "description": "ParentId makes the comment a reply. Replies to a reply join its thread.",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.createInviteLinkRequest": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "maxUses": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "main.deleteAccountRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "ownedEvents": {
+                    "type": "string",
+                    "enum": [
+                        "transfer",
+                        "cancel"
+                    ]
+                },
+                "password": {
+                    "type": "string"
+                },
+                "transferToUserId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.deliveryReport": {
+            "type": "object",
+            "properties": {
+                "announcementId": {
+                    "type": "integer"
+                },
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Delivery"
+                    }
+                }
+            }
+        },
+        "main.editCommentRequest": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 5000
+                }
+            }
+        },
+        "main.eraseAccountRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.eventReport": {
+            "type": "object",
+            "properties": {
+                "byDay": {
+                    "description": "ByDay counts the registrations per day in the event's time zone, leaving\nout days without any. Undated counts the registrations whose time is not\nknown, which ByDay leaves out as well.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.reportDay"
+                    }
+                },
+                "byTicketType": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.reportTicketType"
+                    }
+                },
+                "checkedIn": {
+                    "type": "integer"
+                },
+                "checkedInTickets": {
+                    "type": "integer"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "pendingRequests": {
+                    "type": "integer"
+                },
+                "registrations": {
+                    "description": "Registrations and Tickets count the attendees and the tickets they hold.",
+                    "type": "integer"
+                },
+                "rejectedRequests": {
+                    "type": "integer"
+                },
+                "showUpRate": {
+                    "description": "ShowUpRate is the share of registrations that were checked in.",
+                    "type": "number"
+                },
+                "tickets": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "undated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.eventStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "published",
+                        "cancelled",
+                        "completed"
+                    ]
+                }
+            }
+        },
+        "main.feedbackRequest": {
+            "type": "object",
+            "required": [
+                "rating"
+            ],
+            "properties": {
+                "answers": {
+                    "$ref": "#/definitions/database.Answers"
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.feedbackResults": {
+            "type": "object",
+            "properties": {
+                "attendees": {
+                    "type": "integer"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.questionResults"
+                    }
+                },
+                "rating": {
+                    "description": "Rating is the average rating, unset without responses. RatingCounts\ncounts the responses per rating from 1 to 5.",
+                    "type": "number"
+                },
+                "ratingCounts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "responseRate": {
+                    "type": "number"
+                },
+                "responses": {
+                    "description": "Responses is how many attendees gave feedback, out of Attendees.",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.invalidAnswersResponse": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers maps the ids of the questions answered wrongly to the problem.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.invalidImportResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "report": {
+                    "$ref": "#/definitions/importer.Report"
+                }
+            }
+        },
+        "main.inviteCollaboratorRequest": {
+            "type": "object",
+            "required": [
+                "role",
+                "userId"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "co-owner",
+                        "editor",
+                        "checkin"
+                    ]
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.inviteUserRequest": {
+            "type": "object",
+            "required": [
+                "userId"
+            ],
+            "properties": {
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.loginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "device": {
+                    "description": "Device is an optional name for the signed-in device, such as \"Work laptop\".",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.loginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.muteRequest": {
+            "type": "object",
+            "required": [
+                "userId"
             ],
             "properties": {
-                "email": {
+                "minutes": {
+                    "description": "Minutes is how long the user stays muted. They stay muted until they\nare unmuted if it is zero.",
+                    "type": "integer",
+                    "maximum": 525600,
+                    "minimum": 0
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.notificationPage": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "integer"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Notification"
+                    }
+                }
+            }
+        },
+        "main.offlineScan": {
+            "type": "object",
+            "required": [
+                "scannedAt",
+                "token"
+            ],
+            "properties": {
+                "scannedAt": {
+                    "description": "ScannedAt is when the device scanned the ticket, by its own clock.",
                     "type": "string"
                 },
-                "password": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.orderRequest": {
+            "type": "object",
+            "required": [
+                "ticketTypeId"
+            ],
+            "properties": {
+                "answers": {
+                    "description": "Answers are the answers to the event's registration form. They default\nto the answers given in the approved join request, if any.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Answers"
+                        }
+                    ]
+                },
+                "promoCode": {
+                    "description": "PromoCode is an optional promo code of the event to redeem.",
                     "type": "string",
-                    "minLength": 8
+                    "maxLength": 32
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "ticketTypeId": {
+                    "type": "integer"
                 }
             }
         },
-        "main.loginResponse": {
+        "main.promoCheckRequest": {
             "type": "object",
+            "required": [
+                "code",
+                "ticketTypeId"
+            ],
             "properties": {
-                "token": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.promoQuote": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "code": {
                     "type": "string"
                 },
-                "userId": {
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "promoCodeId": {
+                    "type": "integer"
+                },
+                "subtotal": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.questionResults": {
+            "type": "object",
+            "properties": {
+                "answered": {
+                    "type": "integer"
+                },
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "average": {
+                    "type": "number"
+                },
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.redeemInviteRequest": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers are the answers to the event's registration form.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Answers"
+                        }
+                    ]
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "ticketTypeId": {
                     "type": "integer"
                 }
             }
@@ -535,8 +6623,255 @@ const docTemplate = `{
                     "minLength": 2
                 },
                 "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.rejectJoinRequestRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "main.reportCommentRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "main.reportDay": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "registrations": {
+                    "type": "integer"
+                },
+                "tickets": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.reportTicketType": {
+            "type": "object",
+            "properties": {
+                "checkedIn": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "registrations": {
+                    "type": "integer"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                },
+                "tickets": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.resolveReportRequest": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "dismiss",
+                        "hide",
+                        "delete"
+                    ]
+                }
+            }
+        },
+        "main.rosterChanges": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.rosterEntry"
+                    }
+                },
+                "cursor": {
+                    "type": "integer"
+                },
+                "more": {
+                    "type": "boolean"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "main.rosterEntry": {
+            "type": "object",
+            "properties": {
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "checkIn": {
+                    "$ref": "#/definitions/database.CheckIn"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "ticketHash": {
+                    "type": "string"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.rosterSnapshot": {
+            "type": "object",
+            "properties": {
+                "attendees": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.rosterEntry"
+                    }
+                },
+                "cursor": {
+                    "description": "Cursor is where to ask for changes from after loading the snapshot.",
+                    "type": "integer"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "generatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.rsvpRequest": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers are the answers to the event's registration form.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/database.Answers"
+                        }
+                    ]
+                },
+                "message": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.sessionResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastSeenAt": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ticketResponse": {
+            "type": "object",
+            "properties": {
+                "attendeeId": {
+                    "type": "integer"
+                },
+                "checkIn": {
+                    "$ref": "#/definitions/database.CheckIn"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "ticketTypeId": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.transferOwnershipRequest": {
+            "type": "object",
+            "required": [
+                "userId"
+            ],
+            "properties": {
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.updateUserRequest": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string",
-                    "minLength": 8
+                    "minLength": 2
+                }
+            }
+        },
+        "main.verifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
                 }
             }
         }
@@ -0,0 +1,189 @@
+// Package broker fans messages out to the subscribers of a topic, such as the
+// clients streaming the changes to an event. Subscribers that reconnect can
+// resume from the last message they received as long as it is still buffered.
+package broker
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Message is a message published on a topic. Ids increase with every message
+// of a topic, so subscribers can resume after the last one they received.
+type Message struct {
+	Id    uint64 `json:"id"`
+	Topic string `json:"topic"`
+	Type  string `json:"type"`
+	Data  any    `json:"data"`
+}
+
+// Broker publishes messages to the subscribers of their topic.
+type Broker interface {
+	Publish(topic, typ string, data any) Message
+	// Subscribe subscribes to the messages of a topic published after the
+	// message lastId. A lastId of zero only subscribes to new messages.
+	Subscribe(topic string, lastId uint64) *Subscription
+}
+
+// EventTopic is the topic of the changes to an event.
+func EventTopic(eventId int) string {
+	return "event:" + strconv.Itoa(eventId)
+}
+
+// UserTopic is the topic of the messages to a user.
+func UserTopic(userId int) string {
+	return "user:" + strconv.Itoa(userId)
+}
+
+// ChatTopic is the topic of the chat room of an event.
+func ChatTopic(eventId int) string {
+	return "chat:" + strconv.Itoa(eventId)
+}
+
+// Subscription receives the messages of a topic.
+type Subscription struct {
+	// Replay holds the buffered messages published after the message the
+	// subscription resumes from, oldest first.
+	Replay []Message
+	// Missed is set when some of the messages to replay are no longer
+	// buffered, or the message to resume from is unknown, so the subscriber
+	// has to reload the state it follows.
+	Missed bool
+	// C receives the messages published from now on. It is closed when the
+	// subscription is closed, or when the subscriber falls too far behind.
+	C <-chan Message
+
+	close func()
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.close()
+}
+
+// queueSize is how many messages a subscriber may fall behind before it is
+// dropped.
+const queueSize = 64
+
+// Memory is a Broker within a single process.
+type Memory struct {
+	mu     sync.Mutex
+	topics map[string]*topic
+	// replay is how many messages are kept per topic, and retention how long
+	// a topic without subscribers is kept after its last message.
+	replay    int
+	retention time.Duration
+	swept     time.Time
+}
+
+type topic struct {
+	last    uint64
+	buffer  []Message
+	subs    map[chan Message]struct{}
+	touched time.Time
+}
+
+// NewMemory returns a Broker that keeps the last replay messages of every
+// topic, for as long as retention after its last message if nobody
+// subscribes.
+func NewMemory(replay int, retention time.Duration) *Memory {
+	return &Memory{
+		topics:    map[string]*topic{},
+		replay:    replay,
+		retention: retention,
+		swept:     time.Now(),
+	}
+}
+
+func (b *Memory) Publish(name, typ string, data any) Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(name)
+	t.last++
+	m := Message{Id: t.last, Topic: name, Type: typ, Data: data}
+
+	t.buffer = append(t.buffer, m)
+	if len(t.buffer) > b.replay {
+		t.buffer = t.buffer[len(t.buffer)-b.replay:]
+	}
+
+	for ch := range t.subs {
+		select {
+		case ch <- m:
+		default:
+			delete(t.subs, ch)
+			close(ch)
+		}
+	}
+
+	return m
+}
+
+func (b *Memory) Subscribe(name string, lastId uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(name)
+	s := &Subscription{Replay: []Message{}}
+
+	if lastId > 0 {
+		first := t.last + 1 - uint64(len(t.buffer))
+		s.Missed = lastId > t.last || lastId+1 < first
+		for _, m := range t.buffer {
+			if m.Id > lastId {
+				s.Replay = append(s.Replay, m)
+			}
+		}
+	}
+
+	ch := make(chan Message, queueSize)
+	t.subs[ch] = struct{}{}
+	s.C = ch
+	s.close = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := t.subs[ch]; ok {
+			delete(t.subs, ch)
+			close(ch)
+		}
+		t.touched = time.Now()
+	}
+
+	return s
+}
+
+// topic returns the topic called name, creating it if needed. Ids of new
+// topics start from the current time, so that they keep increasing when the
+// process restarts and ids handed out before are recognized as unknown.
+// b.mu must be held.
+func (b *Memory) topic(name string) *topic {
+	now := time.Now()
+	if now.Sub(b.swept) > b.retention {
+		b.sweep(now)
+	}
+
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{
+			last: uint64(now.UnixMicro()),
+			subs: map[chan Message]struct{}{},
+		}
+		b.topics[name] = t
+	}
+	t.touched = now
+	return t
+}
+
+// sweep forgets the topics nobody subscribed to or published on within the
+// retention. b.mu must be held.
+func (b *Memory) sweep(now time.Time) {
+	for name, t := range b.topics {
+		if len(t.subs) == 0 && now.Sub(t.touched) > b.retention {
+			delete(b.topics, name)
+		}
+	}
+	b.swept = now
+}
@@ -0,0 +1,167 @@
+package broker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscribeReplay(t *testing.T) {
+	const topic = "event:1"
+
+	tests := []struct {
+		name      string
+		replay    int
+		published int
+		// resume is the message to resume from, counting from 1 for the first
+		// message published; 0 only subscribes to new messages and -1 resumes
+		// from a message published before the topic existed.
+		resume     int
+		wantReplay []int
+		wantMissed bool
+	}{
+		{
+			name:       "new messages only",
+			replay:     5,
+			published:  3,
+			resume:     0,
+			wantReplay: []int{},
+		},
+		{
+			name:       "resume within the buffer",
+			replay:     5,
+			published:  4,
+			resume:     2,
+			wantReplay: []int{3, 4},
+		},
+		{
+			name:       "resume from the last message",
+			replay:     5,
+			published:  4,
+			resume:     4,
+			wantReplay: []int{},
+		},
+		{
+			name:       "resume from the oldest buffered message",
+			replay:     3,
+			published:  5,
+			resume:     2,
+			wantReplay: []int{3, 4, 5},
+		},
+		{
+			name:       "resume from before the buffer",
+			replay:     3,
+			published:  5,
+			resume:     1,
+			wantReplay: []int{3, 4, 5},
+			wantMissed: true,
+		},
+		{
+			name:       "resume from a message not published yet",
+			replay:     5,
+			published:  2,
+			resume:     7,
+			wantReplay: []int{},
+			wantMissed: true,
+		},
+		{
+			name:       "resume from before a restart",
+			replay:     5,
+			published:  2,
+			resume:     -1,
+			wantReplay: []int{1, 2},
+			wantMissed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewMemory(tt.replay, time.Hour)
+
+			var ids []uint64
+			for i := range tt.published {
+				ids = append(ids, b.Publish(topic, "update", i+1).Id)
+			}
+
+			var lastId uint64
+			switch {
+			case tt.resume == -1:
+				lastId = 1
+			case tt.resume > 0:
+				// Ids of a topic follow each other.
+				lastId = ids[0] + uint64(tt.resume) - 1
+			}
+
+			s := b.Subscribe(topic, lastId)
+			defer s.Close()
+
+			replay := []int{}
+			for _, m := range s.Replay {
+				replay = append(replay, m.Data.(int))
+			}
+			if !reflect.DeepEqual(replay, tt.wantReplay) {
+				t.Errorf("replay = %v, want %v", replay, tt.wantReplay)
+			}
+			if s.Missed != tt.wantMissed {
+				t.Errorf("missed = %v, want %v", s.Missed, tt.wantMissed)
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	b := NewMemory(10, time.Hour)
+
+	s := b.Subscribe("event:1", 0)
+	other := b.Subscribe("event:2", 0)
+	defer other.Close()
+
+	first := b.Publish("event:1", "update", "a")
+	second := b.Publish("event:1", "update", "b")
+	if second.Id != first.Id+1 {
+		t.Errorf("ids %d and %d do not follow each other", first.Id, second.Id)
+	}
+
+	for _, want := range []Message{first, second} {
+		select {
+		case m := <-s.C:
+			if !reflect.DeepEqual(m, want) {
+				t.Errorf("received %+v, want %+v", m, want)
+			}
+		default:
+			t.Fatalf("message %d was not delivered", want.Id)
+		}
+	}
+
+	select {
+	case m := <-other.C:
+		t.Errorf("subscriber of another topic received %+v", m)
+	default:
+	}
+
+	s.Close()
+	if _, ok := <-s.C; ok {
+		t.Error("channel still open after Close")
+	}
+	// Closing twice must not panic.
+	s.Close()
+}
+
+func TestPublishDropsSlowSubscribers(t *testing.T) {
+	b := NewMemory(10, time.Hour)
+
+	slow := b.Subscribe("event:1", 0)
+	defer slow.Close()
+
+	for i := range queueSize + 1 {
+		b.Publish("event:1", "update", i)
+	}
+
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != queueSize {
+		t.Errorf("received %d messages before being dropped, want %d", n, queueSize)
+	}
+}
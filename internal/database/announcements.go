@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type AnnouncementModel struct {
+	DB *sql.DB
+}
+
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	// DeliverySkipped is the status of recipients who turned off every
+	// channel for announcements.
+	DeliverySkipped = "skipped"
+)
+
+// Announcement is a message from the organizers of an event to all of its
+// attendees.
+type Announcement struct {
+	Id       int    `json:"id"`
+	EventId  int    `json:"eventId"`
+	AuthorId int    `json:"authorId"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	// Automatic announcements were made by changing the date or location of
+	// the event.
+	Automatic bool      `json:"automatic"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Delivery tracks an announcement to one recipient. Channels lists the
+// channels it was sent through, and Error why it failed on any of them.
+type Delivery struct {
+	Id             int       `json:"id"`
+	AnnouncementId int       `json:"announcementId"`
+	EventId        int       `json:"eventId"`
+	UserId         int       `json:"userId"`
+	Status         string    `json:"status"`
+	Channels       []string  `json:"channels"`
+	Error          string    `json:"error,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+const announcementColumns = `id, event_id, author_id, title, body, automatic, created_at`
+
+func scanAnnouncement(s scanner, a *Announcement) error {
+	return s.Scan(&a.Id, &a.EventId, &a.AuthorId, &a.Title, &a.Body, &a.Automatic, &a.CreatedAt)
+}
+
+const deliveryColumns = `id, announcement_id, event_id, user_id, status, channels, error,
+	updated_at`
+
+func scanDelivery(s scanner, d *Delivery) error {
+	var channels string
+
+	err := s.Scan(&d.Id, &d.AnnouncementId, &d.EventId, &d.UserId, &d.Status, &channels, &d.Error,
+		&d.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	d.Channels = []string{}
+	if channels != "" {
+		d.Channels = strings.Split(channels, ",")
+	}
+	return nil
+}
+
+// Insert records an announcement together with a pending delivery to each
+// recipient.
+func (am *AnnouncementModel) Insert(a *Announcement, recipients []int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := am.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	a.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO announcements (event_id, author_id, title, body, automatic, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, a.EventId, a.AuthorId, a.Title, a.Body, a.Automatic,
+		a.CreatedAt).Scan(&a.Id)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO announcement_deliveries (announcement_id, event_id, user_id, status,
+			 updated_at)
+			 VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`
+
+	for _, userId := range recipients {
+		_, err := tx.ExecContext(ctx, query, a.Id, a.EventId, userId, DeliveryPending, a.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (am *AnnouncementModel) Get(id int) (*Announcement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + announcementColumns + ` FROM announcements WHERE id = $1`
+
+	var a Announcement
+	err := scanAnnouncement(am.DB.QueryRowContext(ctx, query, id), &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// GetByEvent returns the announcements of an event, newest first.
+func (am *AnnouncementModel) GetByEvent(eventId int) ([]*Announcement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + announcementColumns + ` FROM announcements
+			  WHERE event_id = $1 ORDER BY id DESC`
+
+	rows, err := am.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []*Announcement{}
+
+	for rows.Next() {
+		var a Announcement
+		if err := scanAnnouncement(rows, &a); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, &a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return announcements, nil
+}
+
+// GetDeliveries returns the deliveries of an announcement. An empty status
+// returns deliveries in every status.
+func (am *AnnouncementModel) GetDeliveries(announcementId int, status string) ([]*Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM announcement_deliveries
+			  WHERE announcement_id = $1 AND ($2 = '' OR status = $2) ORDER BY id`
+	return am.getDeliveries(query, announcementId, status)
+}
+
+// GetPendingAnnouncements returns the ids of the announcements that still
+// have pending deliveries, oldest first.
+func (am *AnnouncementModel) GetPendingAnnouncements() ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT DISTINCT announcement_id FROM announcement_deliveries
+			  WHERE status = $1 ORDER BY announcement_id`
+
+	rows, err := am.DB.QueryContext(ctx, query, DeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// CountDeliveries counts the deliveries of an announcement per status.
+func (am *AnnouncementModel) CountDeliveries(announcementId int) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT status, COUNT(*) FROM announcement_deliveries
+			  WHERE announcement_id = $1 GROUP BY status`
+
+	rows, err := am.DB.QueryContext(ctx, query, announcementId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{
+		DeliveryPending: 0, DeliverySent: 0, DeliveryFailed: 0, DeliverySkipped: 0,
+	}
+
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// FinishDelivery records the outcome of a delivery.
+func (am *AnnouncementModel) FinishDelivery(d *Delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	d.UpdatedAt = time.Now().UTC()
+
+	query := `UPDATE announcement_deliveries SET status = $1, channels = $2, error = $3,
+			  updated_at = $4 WHERE id = $5`
+
+	_, err := am.DB.ExecContext(ctx, query, d.Status, strings.Join(d.Channels, ","), d.Error,
+		d.UpdatedAt, d.Id)
+	return err
+}
+
+func (am *AnnouncementModel) getDeliveries(query string, args ...any) ([]*Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := am.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*Delivery{}
+
+	for rows.Next() {
+		var d Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
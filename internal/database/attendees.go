@@ -14,27 +14,106 @@ type Attendee struct {
 	Id      int `json:"id"`
 	UserId  int `json:"userId"`
 	EventId int `json:"eventId"`
+	// TicketTypeId is set when the event sells tickets. Quantity is the
+	// number of tickets of that type the registration holds.
+	TicketTypeId *int `json:"ticketTypeId,omitempty"`
+	Quantity     int  `json:"quantity"`
+	// Answers are the attendee's answers to the event's registration form.
+	Answers Answers `json:"answers"`
+	// CreatedAt is unset for attendees registered before it was recorded.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
 }
 
+const attendeeColumns = `id, user_id, event_id, ticket_type_id, quantity, answers, created_at`
+
+func scanAttendee(s scanner, a *Attendee) error {
+	var ticketTypeId sql.NullInt64
+	var answers string
+	var createdAt sql.NullTime
+
+	err := s.Scan(&a.Id, &a.UserId, &a.EventId, &ticketTypeId, &a.Quantity, &answers, &createdAt)
+	if err != nil {
+		return err
+	}
+
+	a.TicketTypeId, a.CreatedAt = nil, nil
+	if ticketTypeId.Valid {
+		id := int(ticketTypeId.Int64)
+		a.TicketTypeId = &id
+	}
+	if createdAt.Valid {
+		a.CreatedAt = &createdAt.Time
+	}
+
+	a.Answers, err = decodeAnswers(answers)
+	return err
+}
+
+// Insert registers an attendee, reserving their tickets if they hold any. It
+// returns ErrSoldOut if not enough tickets are left.
 func (am *AttendeeModel) Insert(a *Attendee) (*Attendee, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `INSERT INTO attendees (event_id,user_id ) VALUES ($1, $2) RETURNING id`
-
-	err := am.DB.QueryRowContext(ctx, query, a.EventId, a.UserId).Scan(&a.Id)
+	tx, err := am.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	if err := insertAttendee(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	return a, tx.Commit()
+}
+
+// insertAttendee reserves the tickets of a and inserts it within tx.
+func insertAttendee(ctx context.Context, tx *sql.Tx, a *Attendee) error {
+	if a.Quantity == 0 {
+		a.Quantity = 1
+	}
 
-	return a, nil
+	if a.TicketTypeId != nil {
+		if err := reserveTickets(ctx, tx, *a.TicketTypeId, a.Quantity); err != nil {
+			return err
+		}
+	}
+
+	return insertAttendeeRow(ctx, tx, a)
+}
+
+// insertAttendeeRow inserts a within tx, for tickets that are already reserved.
+func insertAttendeeRow(ctx context.Context, tx *sql.Tx, a *Attendee) error {
+	now := time.Now().UTC()
+	a.CreatedAt = &now
+	if a.Answers == nil {
+		a.Answers = Answers{}
+	}
+
+	answers, err := encodeAnswers(a.Answers)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO attendees (event_id, user_id, ticket_type_id, quantity, answers,
+			  created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, a.EventId, a.UserId, a.TicketTypeId, a.Quantity,
+		answers, a.CreatedAt).Scan(&a.Id)
+	if err != nil {
+		return err
+	}
+
+	return logAttendeeChange(ctx, tx, a.EventId, a.Id)
 }
 
 func (am *AttendeeModel) GetByEvent(eventId int) ([]*User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT u.id, u.name, u.email FROM users u
+	query := `SELECT u.id, u.name, u.email, u.anonymous FROM users u
 		JOIN attendees a ON u.id = a.user_id
 		WHERE a.event_id = $1`
 
@@ -48,7 +127,7 @@ func (am *AttendeeModel) GetByEvent(eventId int) ([]*User, error) {
 
 	for rows.Next() {
 		var u User
-		if err := rows.Scan(&u.Id, &u.Name, &u.Email); err != nil {
+		if err := rows.Scan(&u.Id, &u.Name, &u.Email, &u.Anonymous); err != nil {
 			return nil, err
 		}
 		users = append(users, &u)
@@ -57,14 +136,121 @@ func (am *AttendeeModel) GetByEvent(eventId int) ([]*User, error) {
 	return users, nil
 }
 
+// Registration is an attendee as the event's organizers see them, with the
+// user's full record, their tickets and their answers to the registration
+// form.
+type Registration struct {
+	User
+	AttendeeId   int     `json:"attendeeId"`
+	TicketTypeId *int    `json:"ticketTypeId,omitempty"`
+	Quantity     int     `json:"quantity"`
+	Answers      Answers `json:"answers"`
+}
+
+// GetRegistrations returns the attendees of an event in the order they
+// registered.
+func (am *AttendeeModel) GetRegistrations(eventId int) ([]*Registration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT u.id, u.name, u.email, u.anonymous, a.id, a.ticket_type_id, a.quantity,
+			  a.answers
+			  FROM users u
+			  JOIN attendees a ON u.id = a.user_id
+			  WHERE a.event_id = $1 ORDER BY a.id`
+
+	rows, err := am.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registrations := []*Registration{}
+
+	for rows.Next() {
+		var r Registration
+		var ticketTypeId sql.NullInt64
+		var answers string
+
+		err := rows.Scan(&r.Id, &r.Name, &r.Email, &r.Anonymous, &r.AttendeeId, &ticketTypeId,
+			&r.Quantity, &answers)
+		if err != nil {
+			return nil, err
+		}
+
+		if ticketTypeId.Valid {
+			id := int(ticketTypeId.Int64)
+			r.TicketTypeId = &id
+		}
+		if r.Answers, err = decodeAnswers(answers); err != nil {
+			return nil, err
+		}
+		registrations = append(registrations, &r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return registrations, nil
+}
+
+// GetByUser returns the registrations of a user, oldest first.
+func (am *AttendeeModel) GetByUser(userId int) ([]*Attendee, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + attendeeColumns + ` FROM attendees WHERE user_id = $1 ORDER BY id`
+
+	rows, err := am.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendees := []*Attendee{}
+
+	for rows.Next() {
+		var a Attendee
+		if err := scanAttendee(rows, &a); err != nil {
+			return nil, err
+		}
+		attendees = append(attendees, &a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attendees, nil
+}
+
+func (am *AttendeeModel) Get(id int) (*Attendee, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + attendeeColumns + ` FROM attendees WHERE id = $1`
+
+	var a Attendee
+	err := scanAttendee(am.DB.QueryRowContext(ctx, query, id), &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &a, nil
+}
+
 func (am *AttendeeModel) GetByEventAndUser(eventId, userId int) (*Attendee, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT * FROM attendees WHERE event_id = $1 AND user_id = $2`
+	query := `SELECT ` + attendeeColumns + ` FROM attendees WHERE event_id = $1 AND user_id = $2`
 
 	var a Attendee
-	err := am.DB.QueryRowContext(ctx, query, eventId, userId).Scan(&a.Id, &a.UserId, &a.EventId)
+	err := scanAttendee(am.DB.QueryRowContext(ctx, query, eventId, userId), &a)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -75,17 +261,47 @@ func (am *AttendeeModel) GetByEventAndUser(eventId, userId int) (*Attendee, erro
 	return &a, nil
 }
 
+// Delete removes an attendee and gives their tickets back.
 func (am *AttendeeModel) Delete(userId, eventId int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `DELETE FROM attendees WHERE user_id = $1 AND event_id = $2`
+	tx, err := am.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteAttendee(ctx, tx, `user_id = $1 AND event_id = $2`, userId, eventId); err != nil {
+		return err
+	}
 
-	_, err := am.DB.ExecContext(ctx, query, userId, eventId)
+	return tx.Commit()
+}
+
+// deleteAttendee removes the attendee matching where within tx, if any, and
+// gives their tickets back.
+func deleteAttendee(ctx context.Context, tx *sql.Tx, where string, args ...any) error {
+	query := `DELETE FROM attendees WHERE ` + where + `
+			  RETURNING id, event_id, ticket_type_id, quantity`
+
+	var id, eventId, quantity int
+	var ticketTypeId sql.NullInt64
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&id, &eventId, &ticketTypeId, &quantity)
+	if err == sql.ErrNoRows {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
+	if err := logAttendeeChange(ctx, tx, eventId, id); err != nil {
+		return err
+	}
+
+	if ticketTypeId.Valid {
+		return releaseTickets(ctx, tx, int(ticketTypeId.Int64), quantity)
+	}
 	return nil
 }
 
@@ -93,10 +309,10 @@ func (am *AttendeeModel) GetEventsByUserId(userId int) ([]*Event, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT e.id, e.name, e.description, e.date, e.location 
+	query := `SELECT ` + eventColumns + `
 		FROM events e
 		JOIN attendees a ON e.id = a.event_id
-		WHERE a.user_id = $1`
+		WHERE a.user_id = $1 AND e.deleted_at IS NULL`
 
 	rows, err := am.DB.QueryContext(ctx, query, userId)
 	if err != nil {
@@ -104,11 +320,11 @@ func (am *AttendeeModel) GetEventsByUserId(userId int) ([]*Event, error) {
 	}
 	defer rows.Close()
 
-	var events []*Event
+	events := []*Event{}
 
 	for rows.Next() {
 		var e Event
-		if err := rows.Scan(&e.Id, &e.Name, &e.Description, &e.Date, &e.Location); err != nil {
+		if err := scanEvent(rows, &e); err != nil {
 			return nil, err
 		}
 		events = append(events, &e)
@@ -116,3 +332,131 @@ func (am *AttendeeModel) GetEventsByUserId(userId int) ([]*Event, error) {
 
 	return events, nil
 }
+
+// RSVP statuses of attendee records. Pending and rejected records are join
+// requests of users who are not attendees.
+const (
+	RSVPGoing    = "going"
+	RSVPPending  = JoinRequestPending
+	RSVPRejected = JoinRequestRejected
+)
+
+// AttendeeRecord is a row of an event's attendee list as its owner exports
+// it. AttendeeId and CheckedInAt are only set for attendees.
+type AttendeeRecord struct {
+	AttendeeId   *int       `json:"attendeeId,omitempty"`
+	UserId       int        `json:"userId"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	RSVPStatus   string     `json:"rsvpStatus"`
+	TicketTypeId *int       `json:"ticketTypeId,omitempty"`
+	TicketType   string     `json:"ticketType,omitempty"`
+	Quantity     int        `json:"quantity"`
+	RegisteredAt *time.Time `json:"registeredAt,omitempty"`
+	CheckedInAt  *time.Time `json:"checkedInAt,omitempty"`
+	Answers      Answers    `json:"answers"`
+}
+
+// exportBatch is how many rows EachRecord reads per query. Records are passed
+// to fn as they are read, and starting a new query every exportBatch rows
+// bounds how long a slow client holds a read open, and with it every writer
+// waiting.
+const exportBatch = 500
+
+// EachRecord calls fn with the attendees of an event and then its pending and
+// rejected join requests, stopping at the first error fn returns.
+func (am *AttendeeModel) EachRecord(eventId int, fn func(*AttendeeRecord) error) error {
+	attendees := `SELECT a.id, a.id, a.user_id, u.name, u.email, $1, a.ticket_type_id, t.name,
+			  a.quantity, a.created_at, c.checked_in_at, a.answers
+			  FROM attendees a
+			  JOIN users u ON u.id = a.user_id
+			  LEFT JOIN ticket_types t ON t.id = a.ticket_type_id
+			  LEFT JOIN checkins c ON c.attendee_id = a.id AND c.undone_at IS NULL
+			  WHERE a.event_id = $2 AND a.id > $3
+			  ORDER BY a.id LIMIT $4`
+
+	if err := am.eachRecordBatch(attendees, fn, RSVPGoing, eventId); err != nil {
+		return err
+	}
+
+	requests := `SELECT r.id, NULL, r.user_id, u.name, u.email, r.status, r.ticket_type_id, t.name,
+			  r.quantity, r.created_at, NULL, r.answers
+			  FROM join_requests r
+			  JOIN users u ON u.id = r.user_id
+			  LEFT JOIN ticket_types t ON t.id = r.ticket_type_id
+			  WHERE r.event_id = $1 AND r.status IN ($2, $3) AND r.id > $4
+			  ORDER BY r.id LIMIT $5`
+
+	return am.eachRecordBatch(requests, fn, eventId, RSVPPending, RSVPRejected)
+}
+
+// eachRecordBatch calls fn with each record query selects as it is read,
+// starting a new query after every exportBatch rows. query selects the id to
+// continue after followed by the record, and takes that id and the batch size
+// after args.
+func (am *AttendeeModel) eachRecordBatch(query string, fn func(*AttendeeRecord) error, args ...any) error {
+	last := 0
+	for {
+		n, err := am.recordBatch(query, fn, &last, append(args[:len(args):len(args)], last, exportBatch)...)
+		if err != nil {
+			return err
+		}
+		if n < exportBatch {
+			return nil
+		}
+	}
+}
+
+// recordBatch calls fn with each record query selects, setting last to the id
+// of the record, and returns how many it read.
+func (am *AttendeeModel) recordBatch(query string, fn func(*AttendeeRecord) error, last *int, args ...any) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := am.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var r AttendeeRecord
+		var attendeeId, ticketTypeId sql.NullInt64
+		var ticketType sql.NullString
+		var registeredAt, checkedInAt sql.NullTime
+		var answers string
+
+		err := rows.Scan(last, &attendeeId, &r.UserId, &r.Name, &r.Email, &r.RSVPStatus,
+			&ticketTypeId, &ticketType, &r.Quantity, &registeredAt, &checkedInAt, &answers)
+		if err != nil {
+			return n, err
+		}
+		if r.Answers, err = decodeAnswers(answers); err != nil {
+			return n, err
+		}
+
+		if attendeeId.Valid {
+			id := int(attendeeId.Int64)
+			r.AttendeeId = &id
+		}
+		if ticketTypeId.Valid {
+			id := int(ticketTypeId.Int64)
+			r.TicketTypeId = &id
+		}
+		r.TicketType = ticketType.String
+		if registeredAt.Valid {
+			r.RegisteredAt = &registeredAt.Time
+		}
+		if checkedInAt.Valid {
+			r.CheckedInAt = &checkedInAt.Time
+		}
+
+		if err := fn(&r); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, rows.Err()
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEachRecord(t *testing.T) {
+	db := newTestDB(t)
+	am := AttendeeModel{DB: db}
+	ownerId, eventId := insertTestEvent(t, db)
+
+	// More attendees than fit in one batch, followed by join requests.
+	attendees := 2*exportBatch + 1
+	for i := range attendees {
+		userId := insertTestUser(t, db, fmt.Sprintf("attendee-%d@example.com", i))
+		insertTestAttendee(t, db, userId, eventId)
+	}
+	for _, status := range []string{JoinRequestPending, JoinRequestRejected, JoinRequestApproved} {
+		_, err := db.Exec(`INSERT INTO join_requests (event_id, user_id, status, created_at)
+			VALUES ($1, $2, $3, $4)`, eventId, ownerId, status, time.Now().UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stop := errors.New("stop")
+
+	tests := []struct {
+		name      string
+		stopAfter int
+		wantCount int
+		wantErr   error
+	}{
+		{"every record", 0, attendees + 2, nil},
+		{"stops within a batch", 10, 10, stop},
+		{"stops at the end of a batch", exportBatch, exportBatch, stop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, lastAttendee := 0, 0
+			statuses := map[string]int{}
+
+			err := am.EachRecord(eventId, func(r *AttendeeRecord) error {
+				if tt.stopAfter != 0 && count == tt.stopAfter {
+					return stop
+				}
+				count++
+				statuses[r.RSVPStatus]++
+
+				if r.AttendeeId != nil {
+					if *r.AttendeeId <= lastAttendee {
+						t.Fatalf("attendee %d after %d", *r.AttendeeId, lastAttendee)
+					}
+					lastAttendee = *r.AttendeeId
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EachRecord = %v, want %v", err, tt.wantErr)
+			}
+			if count != tt.wantCount {
+				t.Errorf("got %d records, want %d", count, tt.wantCount)
+			}
+			if tt.wantErr == nil && (statuses[RSVPGoing] != attendees ||
+				statuses[RSVPPending] != 1 || statuses[RSVPRejected] != 1) {
+				t.Errorf("statuses = %v", statuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AuditModel struct {
+	DB *sql.DB
+}
+
+// AuditEntry records an action taken on a user's data. Entries reference
+// users by id only and are kept after the user is deleted or erased.
+type AuditEntry struct {
+	Id        int       `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	UserId    int       `json:"userId"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (am *AuditModel) Insert(e *AuditEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO audit_log (actor, action, user_id, details)
+			  VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	return am.DB.QueryRowContext(ctx, query, e.Actor, e.Action, e.UserId, e.Details).
+		Scan(&e.Id, &e.CreatedAt)
+}
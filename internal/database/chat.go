@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ChatModel struct {
+	DB *sql.DB
+}
+
+// ChatMessage is a message in the chat room of an event. Deleted messages are
+// kept for the record but no longer returned.
+type ChatMessage struct {
+	Id        int       `json:"id"`
+	EventId   int       `json:"eventId"`
+	UserId    int       `json:"userId"`
+	UserName  string    `json:"userName"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ChatMute keeps a user from posting in the chat room of an event until it
+// expires, or until they are unmuted if Until is unset.
+type ChatMute struct {
+	EventId   int        `json:"eventId"`
+	UserId    int        `json:"userId"`
+	UserName  string     `json:"userName"`
+	MutedBy   int        `json:"mutedBy"`
+	Until     *time.Time `json:"until"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// chatMessageColumns lists the columns scanned by scanChatMessage. Queries
+// select them from chat_messages aliased as m left joined with users aliased
+// as u.
+const chatMessageColumns = `m.id, m.event_id, m.user_id, COALESCE(u.name, 'Deleted user'),
+	m.body, m.created_at`
+
+const chatMessagesFrom = ` FROM chat_messages m LEFT JOIN users u ON u.id = m.user_id `
+
+func scanChatMessage(s scanner, m *ChatMessage) error {
+	return s.Scan(&m.Id, &m.EventId, &m.UserId, &m.UserName, &m.Body, &m.CreatedAt)
+}
+
+const chatMuteColumns = `mu.event_id, mu.user_id, COALESCE(u.name, 'Deleted user'), mu.muted_by,
+	mu.until, mu.created_at`
+
+const chatMutesFrom = ` FROM chat_mutes mu LEFT JOIN users u ON u.id = mu.user_id `
+
+func scanChatMute(s scanner, m *ChatMute) error {
+	var until sql.NullTime
+
+	err := s.Scan(&m.EventId, &m.UserId, &m.UserName, &m.MutedBy, &until, &m.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	m.Until = nil
+	if until.Valid {
+		m.Until = &until.Time
+	}
+	return nil
+}
+
+// Insert posts a message to the chat room of its event.
+func (cm *ChatModel) Insert(m *ChatMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	m.CreatedAt = time.Now().UTC()
+	query := `INSERT INTO chat_messages (event_id, user_id, body, created_at)
+			  VALUES ($1, $2, $3, $4) RETURNING id`
+
+	return cm.DB.QueryRowContext(ctx, query, m.EventId, m.UserId, m.Body, m.CreatedAt).Scan(&m.Id)
+}
+
+// Get returns a chat message, or nil if there is none or it was deleted.
+func (cm *ChatModel) Get(id int) (*ChatMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + chatMessageColumns + chatMessagesFrom +
+		`WHERE m.id = $1 AND m.deleted_at IS NULL`
+
+	var m ChatMessage
+	err := scanChatMessage(cm.DB.QueryRowContext(ctx, query, id), &m)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// GetByEvent returns up to limit messages of the chat room of an event that
+// came before the message before, newest first.
+func (cm *ChatModel) GetByEvent(eventId, before, limit int) ([]*ChatMessage, error) {
+	query := `SELECT ` + chatMessageColumns + chatMessagesFrom +
+		`WHERE m.event_id = $1 AND m.deleted_at IS NULL AND ($2 = 0 OR m.id < $2)
+		 ORDER BY m.id DESC LIMIT $3`
+	return cm.getMessages(query, eventId, before, limit)
+}
+
+// GetAfter returns up to limit messages of the chat room of an event that came
+// after the message after, oldest first.
+func (cm *ChatModel) GetAfter(eventId, after, limit int) ([]*ChatMessage, error) {
+	query := `SELECT ` + chatMessageColumns + chatMessagesFrom +
+		`WHERE m.event_id = $1 AND m.deleted_at IS NULL AND m.id > $2
+		 ORDER BY m.id LIMIT $3`
+	return cm.getMessages(query, eventId, after, limit)
+}
+
+// GetByUser returns the chat messages a user posted that are not deleted,
+// oldest first.
+func (cm *ChatModel) GetByUser(userId int) ([]*ChatMessage, error) {
+	query := `SELECT ` + chatMessageColumns + chatMessagesFrom +
+		`WHERE m.user_id = $1 AND m.deleted_at IS NULL ORDER BY m.id`
+	return cm.getMessages(query, userId)
+}
+
+// Delete erases the body of a chat message, deleted by deletedBy.
+func (cm *ChatModel) Delete(id, deletedBy int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE chat_messages SET body = '', deleted_at = $1, deleted_by = $2
+			  WHERE id = $3 AND deleted_at IS NULL`
+
+	_, err := cm.DB.ExecContext(ctx, query, time.Now().UTC(), deletedBy, id)
+	return err
+}
+
+// Mute mutes a user in the chat room of an event, replacing any mute they
+// already had there.
+func (cm *ChatModel) Mute(m *ChatMute) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	m.CreatedAt = time.Now().UTC()
+	query := `INSERT INTO chat_mutes (event_id, user_id, muted_by, until, created_at)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (event_id, user_id) DO UPDATE SET muted_by = excluded.muted_by,
+			  until = excluded.until, created_at = excluded.created_at`
+
+	_, err := cm.DB.ExecContext(ctx, query, m.EventId, m.UserId, m.MutedBy, m.Until, m.CreatedAt)
+	return err
+}
+
+// Unmute lifts the mute of a user in the chat room of an event and reports
+// whether they were muted.
+func (cm *ChatModel) Unmute(eventId, userId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM chat_mutes WHERE event_id = $1 AND user_id = $2
+			  AND (until IS NULL OR until > $3)`
+
+	result, err := cm.DB.ExecContext(ctx, query, eventId, userId, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetMute returns the mute a user is under in the chat room of an event, or
+// nil if they are not muted.
+func (cm *ChatModel) GetMute(eventId, userId int) (*ChatMute, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + chatMuteColumns + chatMutesFrom +
+		`WHERE mu.event_id = $1 AND mu.user_id = $2 AND (mu.until IS NULL OR mu.until > $3)`
+
+	var m ChatMute
+	err := scanChatMute(cm.DB.QueryRowContext(ctx, query, eventId, userId, time.Now().UTC()), &m)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// GetMutes returns the mutes in force in the chat room of an event, oldest
+// first.
+func (cm *ChatModel) GetMutes(eventId int) ([]*ChatMute, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + chatMuteColumns + chatMutesFrom +
+		`WHERE mu.event_id = $1 AND (mu.until IS NULL OR mu.until > $2) ORDER BY mu.created_at`
+
+	rows, err := cm.DB.QueryContext(ctx, query, eventId, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mutes := []*ChatMute{}
+
+	for rows.Next() {
+		var m ChatMute
+		if err := scanChatMute(rows, &m); err != nil {
+			return nil, err
+		}
+		mutes = append(mutes, &m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mutes, nil
+}
+
+func (cm *ChatModel) getMessages(query string, args ...any) ([]*ChatMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := cm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*ChatMessage{}
+
+	for rows.Next() {
+		var m ChatMessage
+		if err := scanChatMessage(rows, &m); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
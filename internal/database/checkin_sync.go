@@ -0,0 +1,301 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RosterEntry is an attendee as check-in devices see them. CheckIn is nil
+// while they are not checked in.
+type RosterEntry struct {
+	AttendeeId   int      `json:"attendeeId"`
+	UserId       int      `json:"userId"`
+	Name         string   `json:"name"`
+	TicketTypeId *int     `json:"ticketTypeId,omitempty"`
+	Quantity     int      `json:"quantity"`
+	CheckIn      *CheckIn `json:"checkIn,omitempty"`
+}
+
+// RosterChanges are the attendees that changed since a cursor. Removed lists
+// the ids of attendees that were removed from the event.
+type RosterChanges struct {
+	Cursor  int64          `json:"cursor"`
+	More    bool           `json:"more"`
+	Changed []*RosterEntry `json:"changed"`
+	Removed []int          `json:"removed"`
+}
+
+// Device is a check-in device of an event with the totals of its scans.
+type Device struct {
+	DeviceId    string    `json:"deviceId"`
+	UserId      int       `json:"userId"`
+	Cursor      int64     `json:"cursor"`
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+	Scans       int       `json:"scans"`
+	CheckedIn   int       `json:"checkedIn"`
+	Duplicates  int       `json:"duplicates"`
+	Rejected    int       `json:"rejected"`
+}
+
+const rosterColumns = `a.id, a.user_id, u.name, a.ticket_type_id, a.quantity,
+	c.id, c.checked_in_by, c.device_id, c.checked_in_at`
+
+const rosterJoins = `JOIN users u ON u.id = a.user_id
+	LEFT JOIN checkins c ON c.attendee_id = a.id AND c.undone_at IS NULL`
+
+// scanRosterEntry scans rosterColumns after dest. It returns nil if the
+// attendee no longer exists.
+func scanRosterEntry(s scanner, eventId int, dest ...any) (*RosterEntry, error) {
+	var attendeeId, userId, ticketTypeId, quantity sql.NullInt64
+	var checkInId, checkedInBy sql.NullInt64
+	var name, deviceId sql.NullString
+	var checkedInAt sql.NullTime
+
+	dest = append(dest, &attendeeId, &userId, &name, &ticketTypeId, &quantity,
+		&checkInId, &checkedInBy, &deviceId, &checkedInAt)
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if !attendeeId.Valid {
+		return nil, nil
+	}
+
+	e := RosterEntry{
+		AttendeeId: int(attendeeId.Int64),
+		UserId:     int(userId.Int64),
+		Name:       name.String,
+		Quantity:   int(quantity.Int64),
+	}
+	if ticketTypeId.Valid {
+		id := int(ticketTypeId.Int64)
+		e.TicketTypeId = &id
+	}
+	if checkInId.Valid {
+		e.CheckIn = &CheckIn{
+			Id:          int(checkInId.Int64),
+			EventId:     eventId,
+			AttendeeId:  e.AttendeeId,
+			UserId:      e.UserId,
+			CheckedInBy: int(checkedInBy.Int64),
+			DeviceId:    deviceId.String,
+			CheckedInAt: checkedInAt.Time,
+		}
+	}
+	return &e, nil
+}
+
+// Roster returns every attendee of an event with their check-in, and the
+// cursor to ask for changes from afterwards.
+func (cm *CheckInModel) Roster(eventId int) ([]*RosterEntry, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Reading both in one transaction keeps the cursor in step with the roster.
+	tx, err := cm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var cursor int64
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM checkin_log
+		WHERE event_id = $1`, eventId).Scan(&cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + rosterColumns + ` FROM attendees a ` + rosterJoins + `
+			  WHERE a.event_id = $1 ORDER BY a.id`
+
+	rows, err := tx.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	roster := []*RosterEntry{}
+
+	for rows.Next() {
+		e, err := scanRosterEntry(rows, eventId)
+		if err != nil {
+			return nil, 0, err
+		}
+		roster = append(roster, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return roster, cursor, nil
+}
+
+// Changes returns the current state of at most limit attendees that changed
+// after cursor, oldest change first. More is set if there are further
+// changes to ask for from the returned cursor.
+func (cm *CheckInModel) Changes(eventId int, cursor int64, limit int) (*RosterChanges, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `WITH changed AS (
+				SELECT attendee_id, MAX(seq) AS seq FROM checkin_log
+				WHERE event_id = $1 AND seq > $2
+				GROUP BY attendee_id ORDER BY seq LIMIT $3
+			  )
+			  SELECT changed.attendee_id, changed.seq, ` + rosterColumns + `
+			  FROM changed
+			  LEFT JOIN attendees a ON a.id = changed.attendee_id
+			  LEFT ` + rosterJoins + `
+			  ORDER BY changed.seq`
+
+	rows, err := cm.DB.QueryContext(ctx, query, eventId, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := RosterChanges{Cursor: cursor, Changed: []*RosterEntry{}, Removed: []int{}}
+
+	for n := 0; rows.Next(); n++ {
+		if n == limit {
+			changes.More = true
+			break
+		}
+
+		var attendeeId int
+		var seq int64
+		e, err := scanRosterEntry(rows, eventId, &attendeeId, &seq)
+		if err != nil {
+			return nil, err
+		}
+
+		if e == nil {
+			changes.Removed = append(changes.Removed, attendeeId)
+		} else {
+			changes.Changed = append(changes.Changed, e)
+		}
+		changes.Cursor = seq
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &changes, nil
+}
+
+// TouchDevice records that a device synced with the event, moving its cursor
+// forward to cursor if that is later.
+func (cm *CheckInModel) TouchDevice(eventId int, deviceId string, userId int, cursor int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `INSERT INTO checkin_devices (event_id, device_id, user_id, cursor, first_seen_at,
+			  last_seen_at)
+			  VALUES ($1, $2, $3, $4, $5, $5)
+			  ON CONFLICT (event_id, device_id) DO UPDATE SET user_id = excluded.user_id,
+			  cursor = MAX(checkin_devices.cursor, excluded.cursor),
+			  last_seen_at = excluded.last_seen_at`
+
+	_, err := cm.DB.ExecContext(ctx, query, eventId, deviceId, userId, cursor, now)
+	return err
+}
+
+// GetDevices returns the check-in devices of an event, most recently seen
+// first.
+func (cm *CheckInModel) GetDevices(eventId int) ([]*Device, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT d.device_id, d.user_id, d.cursor, d.first_seen_at, d.last_seen_at,
+			  COUNT(s.id),
+			  COUNT(CASE WHEN s.result = $1 THEN 1 END),
+			  COUNT(CASE WHEN s.result = $2 THEN 1 END),
+			  COUNT(CASE WHEN s.result IN ($3, $4) THEN 1 END)
+			  FROM checkin_devices d
+			  LEFT JOIN checkin_scans s ON s.event_id = d.event_id AND s.device_id = d.device_id
+			  WHERE d.event_id = $5
+			  GROUP BY d.device_id ORDER BY d.last_seen_at DESC`
+
+	rows, err := cm.DB.QueryContext(ctx, query,
+		ScanCheckedIn, ScanDuplicate, ScanRevoked, ScanInvalid, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*Device{}
+
+	for rows.Next() {
+		var d Device
+		err := rows.Scan(&d.DeviceId, &d.UserId, &d.Cursor, &d.FirstSeenAt, &d.LastSeenAt,
+			&d.Scans, &d.CheckedIn, &d.Duplicates, &d.Rejected)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// GetScans returns the scans a device made at an event, newest first.
+func (cm *CheckInModel) GetScans(eventId int, deviceId string) ([]*Scan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, event_id, device_id, user_id, attendee_id, checkin_id, result,
+			  scanned_at, received_at
+			  FROM checkin_scans WHERE event_id = $1 AND device_id = $2
+			  ORDER BY received_at DESC, id DESC`
+
+	rows, err := cm.DB.QueryContext(ctx, query, eventId, deviceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scans := []*Scan{}
+
+	for rows.Next() {
+		var s Scan
+		var attendeeId, checkInId sql.NullInt64
+
+		err := rows.Scan(&s.Id, &s.EventId, &s.DeviceId, &s.UserId, &attendeeId, &checkInId,
+			&s.Result, &s.ScannedAt, &s.ReceivedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if attendeeId.Valid {
+			id := int(attendeeId.Int64)
+			s.AttendeeId = &id
+		}
+		if checkInId.Valid {
+			id := int(checkInId.Int64)
+			s.CheckInId = &id
+		}
+		scans = append(scans, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return scans, nil
+}
+
+// logAttendeeChange records within tx that an attendee joined, left or was
+// checked in or out, so check-in devices pick it up with their next changes.
+func logAttendeeChange(ctx context.Context, tx *sql.Tx, eventId, attendeeId int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO checkin_log (event_id, attendee_id, created_at)
+		VALUES ($1, $2, $3)`, eventId, attendeeId, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,285 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type CheckInModel struct {
+	DB *sql.DB
+}
+
+// CheckIn records that an attendee showed up at the event. Undone check-ins
+// are kept with UndoneAt set, an attendee has at most one active check-in.
+type CheckIn struct {
+	Id          int    `json:"id"`
+	EventId     int    `json:"eventId"`
+	AttendeeId  int    `json:"attendeeId"`
+	UserId      int    `json:"userId"`
+	CheckedInBy int    `json:"checkedInBy"`
+	DeviceId    string `json:"deviceId"`
+	// CheckedInAt is when the ticket was scanned, by the clock of the device
+	// for scans uploaded later.
+	CheckedInAt time.Time  `json:"checkedInAt"`
+	UndoneBy    *int       `json:"undoneBy,omitempty"`
+	UndoneAt    *time.Time `json:"undoneAt,omitempty"`
+}
+
+// CheckInCounts sums up the check-ins of an event, both by registration and
+// by ticket.
+type CheckInCounts struct {
+	Attendees        int `json:"attendees"`
+	CheckedIn        int `json:"checkedIn"`
+	Tickets          int `json:"tickets"`
+	CheckedInTickets int `json:"checkedInTickets"`
+}
+
+const (
+	// ScanCheckedIn means the scan is the attendee's check-in.
+	ScanCheckedIn = "checked_in"
+	// ScanDuplicate means the attendee was checked in by an earlier scan.
+	ScanDuplicate = "duplicate"
+	// ScanSuperseded means the check-in was undone after the scan was made.
+	ScanSuperseded = "superseded"
+	// ScanRevoked means the ticket was valid but the attendee was removed.
+	ScanRevoked = "revoked"
+	// ScanInvalid means the ticket is forged or for another event.
+	ScanInvalid = "invalid"
+)
+
+// Scan is a ticket scanned by a check-in device, online or uploaded later.
+// Every scan is kept as the audit trail of its device.
+type Scan struct {
+	Id      int `json:"id"`
+	EventId int `json:"eventId"`
+	// DeviceId is chosen by the device, UserId is the staff member using it.
+	DeviceId   string `json:"deviceId"`
+	UserId     int    `json:"userId"`
+	AttendeeId *int   `json:"attendeeId,omitempty"`
+	CheckInId  *int   `json:"checkInId,omitempty"`
+	// Result is one of the Scan constants. It is decided by RecordScans
+	// unless the caller already rejected the ticket as invalid.
+	Result     string    `json:"result"`
+	ScannedAt  time.Time `json:"scannedAt"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	// CheckIn is the attendee's check-in after the scan was applied.
+	CheckIn *CheckIn `json:"checkIn,omitempty"`
+}
+
+const checkInColumns = `id, event_id, attendee_id, user_id, checked_in_by, device_id,
+	checked_in_at, undone_by, undone_at`
+
+func scanCheckIn(s scanner, ci *CheckIn) error {
+	var undoneBy sql.NullInt64
+	var undoneAt sql.NullTime
+
+	err := s.Scan(&ci.Id, &ci.EventId, &ci.AttendeeId, &ci.UserId, &ci.CheckedInBy,
+		&ci.DeviceId, &ci.CheckedInAt, &undoneBy, &undoneAt)
+	if err != nil {
+		return err
+	}
+
+	ci.UndoneBy, ci.UndoneAt = nil, nil
+	if undoneBy.Valid {
+		id := int(undoneBy.Int64)
+		ci.UndoneBy = &id
+	}
+	if undoneAt.Valid {
+		ci.UndoneAt = &undoneAt.Time
+	}
+	return nil
+}
+
+// RecordScans applies scans in order and stores them. Scans of the same ticket
+// are resolved the same way whatever order they arrive in: the earliest scan
+// is the check-in, ties going to the lower device id, and scans made before
+// the check-in was last undone are superseded. Scans made in the future by
+// the device clock count as made when they were received.
+func (cm *CheckInModel) RecordScans(scans []*Scan) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := cm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, s := range scans {
+		s.ReceivedAt = now
+		if s.ScannedAt.IsZero() || s.ScannedAt.After(now) {
+			s.ScannedAt = now
+		}
+		s.ScannedAt = s.ScannedAt.UTC()
+
+		if s.Result == "" {
+			if err := applyScan(ctx, tx, s); err != nil {
+				return err
+			}
+		}
+
+		query := `INSERT INTO checkin_scans (event_id, device_id, user_id, attendee_id, checkin_id,
+				  result, scanned_at, received_at)
+				  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+		err := tx.QueryRowContext(ctx, query, s.EventId, s.DeviceId, s.UserId, s.AttendeeId,
+			s.CheckInId, s.Result, s.ScannedAt, s.ReceivedAt).Scan(&s.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// applyScan decides the result of a scan of a valid ticket within tx and
+// updates the attendee's check-in accordingly.
+func applyScan(ctx context.Context, tx *sql.Tx, s *Scan) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM attendees
+		WHERE id = $1 AND event_id = $2)`, *s.AttendeeId, s.EventId).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		s.Result = ScanRevoked
+		return nil
+	}
+
+	// Scans made before the latest undo are superseded by it, whether or not
+	// the attendee was checked in again since.
+	var undoneAt time.Time
+	err = tx.QueryRowContext(ctx, `SELECT undone_at FROM checkins
+		WHERE attendee_id = $1 AND undone_at IS NOT NULL
+		ORDER BY undone_at DESC LIMIT 1`, *s.AttendeeId).Scan(&undoneAt)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil && !s.ScannedAt.After(undoneAt) {
+		s.Result = ScanSuperseded
+		return nil
+	}
+
+	var active CheckIn
+	err = scanCheckIn(tx.QueryRowContext(ctx, `SELECT `+checkInColumns+` FROM checkins
+		WHERE attendee_id = $1 AND undone_at IS NULL`, *s.AttendeeId), &active)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if err == sql.ErrNoRows {
+		active = CheckIn{
+			EventId:     s.EventId,
+			AttendeeId:  *s.AttendeeId,
+			CheckedInBy: s.UserId,
+			DeviceId:    s.DeviceId,
+			CheckedInAt: s.ScannedAt,
+		}
+		query := `INSERT INTO checkins (event_id, attendee_id, user_id, checked_in_by, device_id,
+				  checked_in_at)
+				  SELECT event_id, id, user_id, $1, $2, $3 FROM attendees WHERE id = $4
+				  RETURNING id, user_id`
+		err = tx.QueryRowContext(ctx, query, s.UserId, s.DeviceId, s.ScannedAt, *s.AttendeeId).
+			Scan(&active.Id, &active.UserId)
+		if err != nil {
+			return err
+		}
+		s.Result = ScanCheckedIn
+	} else if s.ScannedAt.Before(active.CheckedInAt) ||
+		s.ScannedAt.Equal(active.CheckedInAt) && s.DeviceId < active.DeviceId {
+		// An earlier scan from another device arrived late, it takes over.
+		_, err := tx.ExecContext(ctx, `UPDATE checkins
+			SET checked_in_by = $1, device_id = $2, checked_in_at = $3 WHERE id = $4`,
+			s.UserId, s.DeviceId, s.ScannedAt, active.Id)
+		if err != nil {
+			return err
+		}
+		active.CheckedInBy = s.UserId
+		active.DeviceId = s.DeviceId
+		active.CheckedInAt = s.ScannedAt
+		s.Result = ScanCheckedIn
+	} else {
+		s.CheckInId = &active.Id
+		s.CheckIn = &active
+		s.Result = ScanDuplicate
+		return nil
+	}
+
+	s.CheckInId = &active.Id
+	s.CheckIn = &active
+	return logAttendeeChange(ctx, tx, s.EventId, *s.AttendeeId)
+}
+
+// GetActive returns the active check-in of an attendee, or nil if they are
+// not checked in.
+func (cm *CheckInModel) GetActive(attendeeId int) (*CheckIn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + checkInColumns + ` FROM checkins
+			  WHERE attendee_id = $1 AND undone_at IS NULL`
+
+	var ci CheckIn
+	err := scanCheckIn(cm.DB.QueryRowContext(ctx, query, attendeeId), &ci)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &ci, nil
+}
+
+// Undo reverts the active check-in of an attendee and returns it, or nil if
+// they are not checked in.
+func (cm *CheckInModel) Undo(attendeeId, undoneBy int) (*CheckIn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := cm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE checkins SET undone_by = $1, undone_at = $2
+			  WHERE attendee_id = $3 AND undone_at IS NULL RETURNING ` + checkInColumns
+
+	var ci CheckIn
+	err = scanCheckIn(tx.QueryRowContext(ctx, query, undoneBy, time.Now().UTC(), attendeeId), &ci)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := logAttendeeChange(ctx, tx, ci.EventId, ci.AttendeeId); err != nil {
+		return nil, err
+	}
+
+	return &ci, tx.Commit()
+}
+
+// Counts returns how many of the event's current attendees are checked in.
+func (cm *CheckInModel) Counts(eventId int) (*CheckInCounts, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT COUNT(*), COUNT(c.id), COALESCE(SUM(a.quantity), 0),
+			  COALESCE(SUM(CASE WHEN c.id IS NOT NULL THEN a.quantity END), 0)
+			  FROM attendees a
+			  LEFT JOIN checkins c ON c.attendee_id = a.id AND c.undone_at IS NULL
+			  WHERE a.event_id = $1`
+
+	var counts CheckInCounts
+	err := cm.DB.QueryRowContext(ctx, query, eventId).Scan(&counts.Attendees,
+		&counts.CheckedIn, &counts.Tickets, &counts.CheckedInTickets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// permutations returns every order of the indexes 0 to n-1.
+func permutations(n int) [][]int {
+	if n == 0 {
+		return [][]int{{}}
+	}
+
+	var all [][]int
+	for _, p := range permutations(n - 1) {
+		for i := 0; i <= len(p); i++ {
+			q := append(append(append([]int{}, p[:i]...), n-1), p[i:]...)
+			all = append(all, q)
+		}
+	}
+	return all
+}
+
+func insertTestAttendee(t *testing.T, db *sql.DB, userId, eventId int) int {
+	t.Helper()
+
+	var id int
+	err := db.QueryRow(`INSERT INTO attendees (user_id, event_id)
+		VALUES ($1, $2) RETURNING id`, userId, eventId).Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRecordScansOrder(t *testing.T) {
+	db := newTestDB(t)
+	userId, eventId := insertTestEvent(t, db)
+	cm := CheckInModel{DB: db}
+
+	// Every time in the cases is an offset from base, which lies in the past
+	// so that no scan counts as made in the future.
+	base := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Second)
+
+	type scan struct {
+		device string
+		at     time.Duration
+	}
+
+	tests := []struct {
+		name string
+		// undoneAt, if set, undoes a check-in made before any of the scans.
+		undoneAt   time.Duration
+		scans      []scan
+		wantDevice string
+		wantAt     time.Duration
+		// wantResults are the results of the scans in the order listed, or
+		// nil where they depend on the order the scans arrive in.
+		wantResults []string
+	}{
+		{
+			name:        "single scan",
+			scans:       []scan{{"a", 10 * time.Minute}},
+			wantDevice:  "a",
+			wantAt:      10 * time.Minute,
+			wantResults: []string{ScanCheckedIn},
+		},
+		{
+			name:       "earliest scan wins",
+			scans:      []scan{{"a", 10 * time.Minute}, {"b", 5 * time.Minute}, {"c", 7 * time.Minute}},
+			wantDevice: "b",
+			wantAt:     5 * time.Minute,
+		},
+		{
+			name:       "tie goes to the lower device id",
+			scans:      []scan{{"b", 5 * time.Minute}, {"a", 5 * time.Minute}},
+			wantDevice: "a",
+			wantAt:     5 * time.Minute,
+		},
+		{
+			name:        "scans before the undo are superseded",
+			undoneAt:    30 * time.Minute,
+			scans:       []scan{{"a", 10 * time.Minute}, {"b", 20 * time.Minute}},
+			wantResults: []string{ScanSuperseded, ScanSuperseded},
+		},
+		{
+			name:       "scans after the undo check in again",
+			undoneAt:   30 * time.Minute,
+			scans:      []scan{{"a", 50 * time.Minute}, {"b", 40 * time.Minute}},
+			wantDevice: "b",
+			wantAt:     40 * time.Minute,
+		},
+		{
+			name:        "scans before the undo stay superseded after a check-in again",
+			undoneAt:    30 * time.Minute,
+			scans:       []scan{{"a", 10 * time.Minute}, {"b", 40 * time.Minute}, {"c", 30 * time.Minute}},
+			wantDevice:  "b",
+			wantAt:      40 * time.Minute,
+			wantResults: []string{ScanSuperseded, ScanCheckedIn, ScanSuperseded},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, order := range permutations(len(tt.scans)) {
+			t.Run(fmt.Sprintf("%s/%v", tt.name, order), func(t *testing.T) {
+				attendeeId := insertTestAttendee(t, db, userId, eventId)
+
+				if tt.undoneAt != 0 {
+					_, err := db.Exec(`INSERT INTO checkins (event_id, attendee_id, user_id,
+						checked_in_by, device_id, checked_in_at, undone_by, undone_at)
+						VALUES ($1, $2, $3, $3, 'x', $4, $3, $5)`,
+						eventId, attendeeId, userId, base, base.Add(tt.undoneAt))
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				results := make([]string, len(tt.scans))
+				for _, i := range order {
+					s := &Scan{
+						EventId:    eventId,
+						DeviceId:   tt.scans[i].device,
+						UserId:     userId,
+						AttendeeId: &attendeeId,
+						ScannedAt:  base.Add(tt.scans[i].at),
+					}
+					if err := cm.RecordScans([]*Scan{s}); err != nil {
+						t.Fatal(err)
+					}
+					results[i] = s.Result
+				}
+
+				active, err := cm.GetActive(attendeeId)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.wantDevice == "" {
+					if active != nil {
+						t.Errorf("checked in by %s at %v, want no check-in", active.DeviceId, active.CheckedInAt)
+					}
+				} else if active == nil {
+					t.Errorf("not checked in, want check-in by %s", tt.wantDevice)
+				} else if active.DeviceId != tt.wantDevice || !active.CheckedInAt.Equal(base.Add(tt.wantAt)) {
+					t.Errorf("checked in by %s at %v, want %s at %v", active.DeviceId,
+						active.CheckedInAt, tt.wantDevice, base.Add(tt.wantAt))
+				}
+
+				if tt.wantResults != nil {
+					for i, want := range tt.wantResults {
+						if results[i] != want {
+							t.Errorf("scan %d = %s, want %s", i, results[i], want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRecordScansRevoked(t *testing.T) {
+	db := newTestDB(t)
+	userId, eventId := insertTestEvent(t, db)
+	cm := CheckInModel{DB: db}
+
+	attendeeId := insertTestAttendee(t, db, userId, eventId)
+	if _, err := db.Exec(`DELETE FROM attendees WHERE id = $1`, attendeeId); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Scan{EventId: eventId, DeviceId: "a", UserId: userId, AttendeeId: &attendeeId}
+	if err := cm.RecordScans([]*Scan{s}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Result != ScanRevoked {
+		t.Errorf("result = %s, want %s", s.Result, ScanRevoked)
+	}
+}
@@ -0,0 +1,398 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type CollaboratorModel struct {
+	DB *sql.DB
+}
+
+// Roles a user can hold on an event. RoleOwner is implied by Event.OwnerID and
+// RoleAdmin by User.IsAdmin; neither is stored as a collaborator.
+const (
+	RoleOwner   = "owner"
+	RoleCoOwner = "co-owner"
+	RoleEditor  = "editor"
+	RoleCheckIn = "checkin"
+	RoleAdmin   = "admin"
+)
+
+const (
+	CollaboratorInvited  = "invited"
+	CollaboratorAccepted = "accepted"
+)
+
+// Permission is something a user may be allowed to do to an event.
+type Permission int
+
+const (
+	// PermViewEvent allows seeing the event while it is a draft.
+	PermViewEvent Permission = iota
+	PermEditEvent
+	PermChangeStatus
+	PermDeleteEvent
+	PermRestoreEvent
+	PermViewHistory
+	// PermViewAttendees allows listing the attendees regardless of the
+	// event's attendee visibility, with their full user records.
+	PermViewAttendees
+	PermManageAttendees
+	PermCheckIn
+	PermViewCollaborators
+	// PermManageCollaborators allows inviting and removing editors and
+	// check-in staff, PermManageCoOwners also co-owners.
+	PermManageCollaborators
+	PermManageCoOwners
+	PermTransferOwnership
+	// PermViewSales allows seeing how many tickets of each type were sold and
+	// the orders and promo code redemptions, PermRefundOrders also refunding
+	// orders and PermManagePromoCodes creating and changing promo codes.
+	PermViewSales
+	PermRefundOrders
+	PermManagePromoCodes
+	// PermExportAttendees allows exporting the attendee list with emails and
+	// the event report. Only the owner holds it.
+	PermExportAttendees
+	// PermViewFeedback allows reading the ratings and survey answers
+	// attendees gave. Only the owner holds it.
+	PermViewFeedback
+	// PermModerateComments allows pinning, hiding and deleting the comments
+	// on the event and resolving reports of them.
+	PermModerateComments
+	// PermAnnounce allows posting announcements to the attendees and seeing
+	// whether they were delivered.
+	PermAnnounce
+	// PermViewRegistrations allows seeing the emails of the attendees and
+	// their answers to the registration form.
+	PermViewRegistrations
+	// PermChat allows joining the chat room of the event without attending
+	// it, PermModerateChat also deleting messages and muting users there.
+	PermChat
+	PermModerateChat
+)
+
+// rolePermissions lists what each role may do. Admins hold RoleAdmin on every
+// event in addition to any role of their own.
+var rolePermissions = map[string][]Permission{
+	RoleOwner: {
+		PermViewEvent, PermEditEvent, PermChangeStatus, PermDeleteEvent, PermRestoreEvent,
+		PermViewHistory, PermViewAttendees, PermManageAttendees, PermCheckIn,
+		PermViewCollaborators, PermManageCollaborators, PermManageCoOwners, PermTransferOwnership,
+		PermViewSales, PermRefundOrders, PermManagePromoCodes, PermExportAttendees,
+		PermViewFeedback, PermModerateComments, PermAnnounce, PermViewRegistrations, PermChat,
+		PermModerateChat,
+	},
+	RoleCoOwner: {
+		PermViewEvent, PermEditEvent, PermChangeStatus, PermDeleteEvent, PermRestoreEvent,
+		PermViewHistory, PermViewAttendees, PermManageAttendees, PermCheckIn,
+		PermViewCollaborators, PermManageCollaborators, PermViewSales, PermRefundOrders,
+		PermManagePromoCodes, PermModerateComments, PermAnnounce, PermViewRegistrations, PermChat,
+		PermModerateChat,
+	},
+	RoleEditor: {
+		PermViewEvent, PermEditEvent, PermViewHistory, PermViewAttendees, PermManageAttendees,
+		PermViewCollaborators, PermChat,
+	},
+	RoleCheckIn: {
+		PermViewEvent, PermViewAttendees, PermCheckIn, PermViewCollaborators,
+	},
+	RoleAdmin: {
+		PermViewEvent, PermRestoreEvent, PermViewHistory, PermViewAttendees, PermViewCollaborators,
+		PermViewSales, PermModerateComments, PermViewRegistrations, PermChat, PermModerateChat,
+	},
+}
+
+// RoleAllows reports whether role grants perm. The empty role grants nothing.
+func RoleAllows(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	ErrAlreadyCollaborator = errors.New("user is already a collaborator of the event")
+	// ErrStaleTransfer is returned when an ownership transfer is accepted
+	// after the event changed owner by other means.
+	ErrStaleTransfer = errors.New("event owner changed since the transfer was offered")
+)
+
+// Collaborator is a user who helps organize an event with a role below the
+// owner. Invited collaborators hold no permissions until they accept.
+type Collaborator struct {
+	EventId    int        `json:"eventId"`
+	UserId     int        `json:"userId"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	InvitedBy  int        `json:"invitedBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// OwnershipTransfer is an offer by the owner of an event to hand it over to
+// another user. It takes effect when that user accepts it.
+type OwnershipTransfer struct {
+	EventId    int       `json:"eventId"`
+	FromUserId int       `json:"fromUserId"`
+	ToUserId   int       `json:"toUserId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+const collaboratorColumns = `event_id, user_id, role, status, invited_by, created_at, accepted_at`
+
+func scanCollaborator(s scanner, c *Collaborator) error {
+	var acceptedAt sql.NullTime
+
+	err := s.Scan(&c.EventId, &c.UserId, &c.Role, &c.Status, &c.InvitedBy,
+		&c.CreatedAt, &acceptedAt)
+	if err != nil {
+		return err
+	}
+
+	if acceptedAt.Valid {
+		c.AcceptedAt = &acceptedAt.Time
+	}
+	return nil
+}
+
+// Invite records a pending invitation. It returns ErrAlreadyCollaborator if
+// the user is already invited to or collaborating on the event.
+func (cm *CollaboratorModel) Invite(c *Collaborator) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	c.Status = CollaboratorInvited
+	c.CreatedAt = time.Now().UTC()
+	c.AcceptedAt = nil
+
+	query := `INSERT INTO event_collaborators (event_id, user_id, role, status, invited_by, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (event_id, user_id) DO NOTHING`
+
+	result, err := cm.DB.ExecContext(ctx, query,
+		c.EventId, c.UserId, c.Role, c.Status, c.InvitedBy, c.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAlreadyCollaborator
+	}
+	return nil
+}
+
+func (cm *CollaboratorModel) Get(eventId, userId int) (*Collaborator, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + collaboratorColumns + ` FROM event_collaborators
+			  WHERE event_id = $1 AND user_id = $2`
+
+	var c Collaborator
+	err := scanCollaborator(cm.DB.QueryRowContext(ctx, query, eventId, userId), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (cm *CollaboratorModel) GetByEvent(eventId int) ([]*Collaborator, error) {
+	query := `SELECT ` + collaboratorColumns + ` FROM event_collaborators
+			  WHERE event_id = $1 ORDER BY created_at`
+	return cm.getCollaborators(query, eventId)
+}
+
+// GetByUser returns the invitations and collaborations of a user across all
+// events that have not been deleted.
+func (cm *CollaboratorModel) GetByUser(userId int) ([]*Collaborator, error) {
+	query := `SELECT ` + collaboratorColumns + ` FROM event_collaborators
+			  WHERE user_id = $1 AND event_id IN (SELECT id FROM events WHERE deleted_at IS NULL)
+			  ORDER BY created_at DESC`
+	return cm.getCollaborators(query, userId)
+}
+
+// Accept turns a pending invitation into an active collaboration. It reports
+// whether a pending invitation existed.
+func (cm *CollaboratorModel) Accept(eventId, userId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE event_collaborators SET status = $1, accepted_at = $2
+			  WHERE event_id = $3 AND user_id = $4 AND status = $5`
+
+	result, err := cm.DB.ExecContext(ctx, query,
+		CollaboratorAccepted, time.Now().UTC(), eventId, userId, CollaboratorInvited)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Delete removes a collaborator or withdraws an invitation. It reports
+// whether one existed.
+func (cm *CollaboratorModel) Delete(eventId, userId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM event_collaborators WHERE event_id = $1 AND user_id = $2`
+
+	result, err := cm.DB.ExecContext(ctx, query, eventId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// OfferTransfer offers the ownership of an event to another user, replacing
+// any earlier offer for the same event.
+func (cm *CollaboratorModel) OfferTransfer(t *OwnershipTransfer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO event_ownership_transfers (event_id, from_user_id, to_user_id, created_at)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (event_id) DO UPDATE SET from_user_id = excluded.from_user_id,
+			  to_user_id = excluded.to_user_id, created_at = excluded.created_at`
+
+	_, err := cm.DB.ExecContext(ctx, query, t.EventId, t.FromUserId, t.ToUserId, t.CreatedAt)
+	return err
+}
+
+func (cm *CollaboratorModel) GetTransfer(eventId int) (*OwnershipTransfer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT event_id, from_user_id, to_user_id, created_at
+			  FROM event_ownership_transfers WHERE event_id = $1`
+
+	var t OwnershipTransfer
+	err := cm.DB.QueryRowContext(ctx, query, eventId).
+		Scan(&t.EventId, &t.FromUserId, &t.ToUserId, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// CancelTransfer withdraws or declines the pending transfer of an event. It
+// reports whether one existed.
+func (cm *CollaboratorModel) CancelTransfer(eventId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := cm.DB.ExecContext(ctx,
+		`DELETE FROM event_ownership_transfers WHERE event_id = $1`, eventId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// AcceptTransfer makes the recipient of a transfer the owner of the event.
+// The previous owner stays on as a co-owner, and any collaboration the new
+// owner had on the event is dropped. It returns ErrStaleTransfer, and
+// discards the offer, if the event no longer belongs to the user who made it.
+func (cm *CollaboratorModel) AcceptTransfer(t *OwnershipTransfer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := cm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM event_ownership_transfers WHERE event_id = $1`, t.EventId)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE events SET owner_id = $1
+		WHERE id = $2 AND owner_id = $3 AND deleted_at IS NULL`,
+		t.ToUserId, t.EventId, t.FromUserId)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrStaleTransfer
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM event_collaborators WHERE event_id = $1 AND user_id = $2`,
+		t.EventId, t.ToUserId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := `INSERT INTO event_collaborators
+			  (event_id, user_id, role, status, invited_by, created_at, accepted_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $6)
+			  ON CONFLICT (event_id, user_id) DO UPDATE SET role = excluded.role,
+			  status = excluded.status, accepted_at = excluded.accepted_at`
+
+	_, err = tx.ExecContext(ctx, query,
+		t.EventId, t.FromUserId, RoleCoOwner, CollaboratorAccepted, t.ToUserId, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (cm *CollaboratorModel) getCollaborators(query string, args ...any) ([]*Collaborator, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := cm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collaborators := []*Collaborator{}
+
+	for rows.Next() {
+		var c Collaborator
+		if err := scanCollaborator(rows, &c); err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collaborators, nil
+}
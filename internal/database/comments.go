@@ -0,0 +1,431 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type CommentModel struct {
+	DB *sql.DB
+}
+
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportActioned  = "actioned"
+)
+
+var (
+	ErrCommentReported = errors.New("user already reported the comment")
+	ErrReportResolved  = errors.New("report has already been resolved")
+)
+
+// Comment is a message in the discussion of an event. Top-level comments
+// start a thread and replies belong to one, so threads are a single level
+// deep.
+type Comment struct {
+	Id       int    `json:"id"`
+	EventId  int    `json:"eventId"`
+	UserId   int    `json:"userId"`
+	UserName string `json:"userName"`
+	ParentId *int   `json:"parentId,omitempty"`
+	// Body is the markdown the author wrote and HTML its sanitized rendering.
+	// Both are empty once the comment is deleted.
+	Body string `json:"body"`
+	HTML string `json:"html"`
+	// Replies counts the visible replies of a top-level comment.
+	Replies   int        `json:"replies"`
+	PinnedAt  *time.Time `json:"pinnedAt,omitempty"`
+	HiddenAt  *time.Time `json:"hiddenAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	// Deleted comments are kept while they have replies, so that their
+	// thread stays together.
+	Deleted bool `json:"deleted"`
+}
+
+// CommentReport is a user's report of an abusive comment, waiting in the
+// moderation queue until an organizer resolves it.
+type CommentReport struct {
+	Id         int        `json:"id"`
+	CommentId  int        `json:"commentId"`
+	EventId    int        `json:"eventId"`
+	UserId     int        `json:"userId"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	ResolvedBy *int       `json:"resolvedBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	// Comment is the reported comment, set in the moderation queue.
+	Comment *Comment `json:"comment,omitempty"`
+}
+
+// commentColumns lists the columns scanned by scanComment. Queries select
+// them from comments aliased as c left joined with users aliased as u.
+const commentColumns = `c.id, c.event_id, c.user_id, COALESCE(u.name, 'Deleted user'),
+	c.parent_id, c.body, c.html,
+	(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL
+		AND r.hidden_at IS NULL),
+	c.pinned_at, c.hidden_at, c.created_at, c.edited_at, c.deleted_at`
+
+const commentsFrom = ` FROM comments c LEFT JOIN users u ON u.id = c.user_id `
+
+func scanComment(s scanner, c *Comment) error {
+	var parentId sql.NullInt64
+	var pinnedAt, hiddenAt, editedAt, deletedAt sql.NullTime
+
+	err := s.Scan(&c.Id, &c.EventId, &c.UserId, &c.UserName, &parentId, &c.Body, &c.HTML,
+		&c.Replies, &pinnedAt, &hiddenAt, &c.CreatedAt, &editedAt, &deletedAt)
+	if err != nil {
+		return err
+	}
+
+	c.ParentId, c.PinnedAt, c.HiddenAt, c.EditedAt = nil, nil, nil, nil
+	if parentId.Valid {
+		id := int(parentId.Int64)
+		c.ParentId = &id
+	}
+	if pinnedAt.Valid {
+		c.PinnedAt = &pinnedAt.Time
+	}
+	if hiddenAt.Valid {
+		c.HiddenAt = &hiddenAt.Time
+	}
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.Time
+	}
+	c.Deleted = deletedAt.Valid
+	return nil
+}
+
+const commentReportColumns = `id, comment_id, event_id, user_id, reason, status, resolved_by,
+	created_at, resolved_at`
+
+func scanCommentReport(s scanner, r *CommentReport) error {
+	var resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+
+	err := s.Scan(&r.Id, &r.CommentId, &r.EventId, &r.UserId, &r.Reason, &r.Status, &resolvedBy,
+		&r.CreatedAt, &resolvedAt)
+	if err != nil {
+		return err
+	}
+
+	if resolvedBy.Valid {
+		id := int(resolvedBy.Int64)
+		r.ResolvedBy = &id
+	}
+	if resolvedAt.Valid {
+		r.ResolvedAt = &resolvedAt.Time
+	}
+	return nil
+}
+
+func (cm *CommentModel) Insert(c *Comment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	c.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO comments (event_id, user_id, parent_id, body, html, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	return cm.DB.QueryRowContext(ctx, query, c.EventId, c.UserId, c.ParentId, c.Body, c.HTML,
+		c.CreatedAt).Scan(&c.Id)
+}
+
+// Get returns a comment, including a deleted one.
+func (cm *CommentModel) Get(id int) (*Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + commentColumns + commentsFrom + `WHERE c.id = $1`
+
+	var c Comment
+	err := scanComment(cm.DB.QueryRowContext(ctx, query, id), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetPinned returns the pinned threads of an event, most recently pinned
+// first. Hidden ones are only included if withHidden is set.
+func (cm *CommentModel) GetPinned(eventId int, withHidden bool) ([]*Comment, error) {
+	query := `SELECT ` + commentColumns + commentsFrom + `WHERE c.event_id = $1
+			  AND c.parent_id IS NULL AND c.pinned_at IS NOT NULL AND c.deleted_at IS NULL
+			  AND ($2 OR c.hidden_at IS NULL) ORDER BY c.pinned_at DESC`
+	return cm.getComments(query, eventId, withHidden)
+}
+
+// GetThreads returns up to limit unpinned threads of an event older than the
+// comment before, newest first, or the newest ones if before is 0. Deleted
+// comments are only included while they have replies, hidden ones only if
+// withHidden is set.
+func (cm *CommentModel) GetThreads(eventId int, withHidden bool, before, limit int) ([]*Comment, error) {
+	query := `SELECT ` + commentColumns + commentsFrom + `WHERE c.event_id = $1
+			  AND c.parent_id IS NULL AND c.pinned_at IS NULL AND ($2 OR c.hidden_at IS NULL)
+			  AND (c.deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments r
+			  WHERE r.parent_id = c.id AND r.deleted_at IS NULL))
+			  AND ($3 = 0 OR c.id < $3) ORDER BY c.id DESC LIMIT $4`
+	return cm.getComments(query, eventId, withHidden, before, limit)
+}
+
+// GetReplies returns up to limit replies to a comment that came after the
+// reply after, oldest first. Hidden ones are only included if withHidden is
+// set.
+func (cm *CommentModel) GetReplies(parentId int, withHidden bool, after, limit int) ([]*Comment, error) {
+	query := `SELECT ` + commentColumns + commentsFrom + `WHERE c.parent_id = $1
+			  AND ($2 OR c.hidden_at IS NULL) AND c.deleted_at IS NULL AND c.id > $3
+			  ORDER BY c.id LIMIT $4`
+	return cm.getComments(query, parentId, withHidden, after, limit)
+}
+
+// GetByUser returns the comments a user wrote that are not deleted, oldest
+// first.
+func (cm *CommentModel) GetByUser(userId int) ([]*Comment, error) {
+	query := `SELECT ` + commentColumns + commentsFrom + `WHERE c.user_id = $1
+			  AND c.deleted_at IS NULL ORDER BY c.id`
+	return cm.getComments(query, userId)
+}
+
+// Update replaces the body of a comment that is not deleted and marks it as
+// edited.
+func (cm *CommentModel) Update(c *Comment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `UPDATE comments SET body = $1, html = $2, edited_at = $3
+			  WHERE id = $4 AND deleted_at IS NULL`
+
+	if _, err := cm.DB.ExecContext(ctx, query, c.Body, c.HTML, now, c.Id); err != nil {
+		return err
+	}
+
+	c.EditedAt = &now
+	return nil
+}
+
+// Delete erases the body of a comment and unpins it, and closes its open
+// reports as actioned by deletedBy.
+func (cm *CommentModel) Delete(id, deletedBy int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := cm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	query := `UPDATE comments SET body = '', html = '', pinned_at = NULL, deleted_at = $1
+			  WHERE id = $2 AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+		return err
+	}
+
+	if err := closeReports(ctx, tx, id, deletedBy, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (cm *CommentModel) SetPinned(c *Comment, pinned bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var pinnedAt *time.Time
+	if pinned {
+		now := time.Now().UTC()
+		pinnedAt = &now
+	}
+
+	query := `UPDATE comments SET pinned_at = $1 WHERE id = $2`
+	if _, err := cm.DB.ExecContext(ctx, query, pinnedAt, c.Id); err != nil {
+		return err
+	}
+
+	c.PinnedAt = pinnedAt
+	return nil
+}
+
+// SetHidden hides a comment from everyone but the organizers of its event,
+// or shows it again. Hiding it closes its open reports as actioned by
+// hiddenBy.
+func (cm *CommentModel) SetHidden(c *Comment, hidden bool, hiddenBy int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := cm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	var hiddenAt *time.Time
+	if hidden {
+		hiddenAt = &now
+		if err := closeReports(ctx, tx, c.Id, hiddenBy, now); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE comments SET hidden_at = $1, hidden_by = $2 WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, hiddenAt, hiddenBy, c.Id); err != nil {
+		return err
+	}
+
+	c.HiddenAt = hiddenAt
+	return tx.Commit()
+}
+
+// closeReports marks the open reports of a comment as actioned.
+func closeReports(ctx context.Context, tx *sql.Tx, commentId, resolvedBy int, now time.Time) error {
+	query := `UPDATE comment_reports SET status = $1, resolved_by = $2, resolved_at = $3
+			  WHERE comment_id = $4 AND status = $5`
+
+	_, err := tx.ExecContext(ctx, query, ReportActioned, resolvedBy, now, commentId, ReportOpen)
+	return err
+}
+
+func (cm *CommentModel) getComments(query string, args ...any) ([]*Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := cm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*Comment{}
+
+	for rows.Next() {
+		var c Comment
+		if err := scanComment(rows, &c); err != nil {
+			return nil, err
+		}
+		comments = append(comments, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Report files an open report. It returns ErrCommentReported if the user
+// already reported the comment.
+func (cm *CommentModel) Report(r *CommentReport) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	r.Status = ReportOpen
+	r.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO comment_reports (comment_id, event_id, user_id, reason, status,
+			  created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING RETURNING id`
+
+	err := cm.DB.QueryRowContext(ctx, query, r.CommentId, r.EventId, r.UserId, r.Reason,
+		r.Status, r.CreatedAt).Scan(&r.Id)
+	if err == sql.ErrNoRows {
+		return ErrCommentReported
+	}
+	return err
+}
+
+func (cm *CommentModel) GetReport(id int) (*CommentReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + commentReportColumns + ` FROM comment_reports WHERE id = $1`
+
+	var r CommentReport
+	err := scanCommentReport(cm.DB.QueryRowContext(ctx, query, id), &r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetReports returns the reports of an event, or of every event if eventId
+// is 0, oldest first and together with the reported comments. An empty
+// status returns reports in every status.
+func (cm *CommentModel) GetReports(eventId int, status string) ([]*CommentReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + commentReportColumns + ` FROM comment_reports
+			  WHERE ($1 = 0 OR event_id = $1) AND ($2 = '' OR status = $2) ORDER BY id`
+
+	rows, err := cm.DB.QueryContext(ctx, query, eventId, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*CommentReport{}
+
+	for rows.Next() {
+		var r CommentReport
+		if err := scanCommentReport(rows, &r); err != nil {
+			return nil, err
+		}
+		reports = append(reports, &r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, r := range reports {
+		if r.Comment, err = cm.Get(r.CommentId); err != nil {
+			return nil, err
+		}
+	}
+
+	return reports, nil
+}
+
+// Dismiss closes an open report without acting on the comment. It returns
+// ErrReportResolved if the report is no longer open.
+func (cm *CommentModel) Dismiss(r *CommentReport, resolvedBy int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `UPDATE comment_reports SET status = $1, resolved_by = $2, resolved_at = $3
+			  WHERE id = $4 AND status = $5`
+
+	result, err := cm.DB.ExecContext(ctx, query, ReportDismissed, resolvedBy, now, r.Id, ReportOpen)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrReportResolved
+	}
+
+	r.Status = ReportDismissed
+	r.ResolvedBy = &resolvedBy
+	r.ResolvedAt = &now
+	return nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DataExportModel struct {
+	DB *sql.DB
+}
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+type DataExport struct {
+	Id          int        `json:"id"`
+	UserId      int        `json:"userId"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+const dataExportColumns = `id, user_id, status, file_path, created_at, completed_at`
+
+func scanDataExport(s scanner, e *DataExport) error {
+	var completedAt sql.NullTime
+
+	err := s.Scan(&e.Id, &e.UserId, &e.Status, &e.FilePath, &e.CreatedAt, &completedAt)
+	if err != nil {
+		return err
+	}
+
+	if completedAt.Valid {
+		e.CompletedAt = &completedAt.Time
+	}
+	return nil
+}
+
+func (dm *DataExportModel) Insert(e *DataExport) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	e.Status = ExportPending
+	query := `INSERT INTO data_exports (user_id, status) VALUES ($1, $2)
+			  RETURNING id, created_at`
+
+	return dm.DB.QueryRowContext(ctx, query, e.UserId, e.Status).Scan(&e.Id, &e.CreatedAt)
+}
+
+func (dm *DataExportModel) Get(id int) (*DataExport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE id = $1`
+
+	var e DataExport
+	err := scanDataExport(dm.DB.QueryRowContext(ctx, query, id), &e)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (dm *DataExportModel) GetByUser(userId int) ([]*DataExport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports
+			  WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := dm.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := []*DataExport{}
+
+	for rows.Next() {
+		var e DataExport
+		if err := scanDataExport(rows, &e); err != nil {
+			return nil, err
+		}
+		exports = append(exports, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// Complete records the outcome of building an export. An empty filePath marks
+// the export as failed.
+func (dm *DataExportModel) Complete(id int, filePath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	status := ExportReady
+	if filePath == "" {
+		status = ExportFailed
+	}
+
+	query := `UPDATE data_exports SET status = $1, file_path = $2, completed_at = $3
+			  WHERE id = $4`
+
+	_, err := dm.DB.ExecContext(ctx, query, status, filePath, time.Now().UTC(), id)
+	return err
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB returns an in-memory database with every up migration applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each connection to :memory: opens a database of its own.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../../cmd/migrate/migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no migrations found")
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		query, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(query)); err != nil {
+			t.Fatalf("applying %s: %v", filepath.Base(f), err)
+		}
+	}
+
+	return db
+}
+
+// insertTestEvent creates a user and an event they own and returns their ids.
+func insertTestEvent(t *testing.T, db *sql.DB) (userId, eventId int) {
+	t.Helper()
+
+	err := db.QueryRow(`INSERT INTO users (email, name, password)
+		VALUES ('owner-' || lower(hex(randomblob(8))) || '@example.com', 'Owner', '')
+		RETURNING id`).Scan(&userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	err = db.QueryRow(`INSERT INTO events (owner_id, name, description, location, starts_at, ends_at)
+		VALUES ($1, 'Event', '', 'Online', $2, $3) RETURNING id`,
+		userId, now.Add(24*time.Hour), now.Add(26*time.Hour)).Scan(&eventId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return userId, eventId
+}
+
+// insertTestUser creates a user and returns their id. The email doubles as
+// their name.
+func insertTestUser(t *testing.T, db *sql.DB, email string) int {
+	t.Helper()
+
+	var id int
+	err := db.QueryRow(`INSERT INTO users (email, name, password)
+		VALUES ($1, $1, '') RETURNING id`, email).Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+type EmailVerificationModel struct {
+	DB *sql.DB
+}
+
+type EmailVerification struct {
+	Id        int       `json:"id"`
+	UserId    int       `json:"userId"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// New records a pending email address for the user and returns the plaintext
+// token that confirms it. Only a hash of the token is stored. Any earlier
+// pending address of the same user is discarded.
+func (evm *EmailVerificationModel) New(userId int, email string, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := evm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM email_verifications WHERE user_id = $1`, userId)
+	if err != nil {
+		return "", err
+	}
+
+	query := `INSERT INTO email_verifications (user_id, email, token_hash, expires_at)
+			  VALUES ($1, $2, $3, $4)`
+
+	_, err = tx.ExecContext(ctx, query, userId, email, hashToken(token), time.Now().Add(ttl).UTC())
+	if err != nil {
+		return "", err
+	}
+
+	return token, tx.Commit()
+}
+
+// Consume looks up an unexpired verification by its token and deletes it so
+// that the token cannot be used twice. It returns nil if no such verification
+// exists.
+func (evm *EmailVerificationModel) Consume(token string) (*EmailVerification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM email_verifications WHERE token_hash = $1 AND expires_at > $2
+			  RETURNING id, user_id, email, expires_at`
+
+	var v EmailVerification
+	err := evm.DB.QueryRowContext(ctx, query, hashToken(token), time.Now().UTC()).
+		Scan(&v.Id, &v.UserId, &v.Email, &v.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	EventDraft     = "draft"
+	EventPublished = "published"
+	EventCancelled = "cancelled"
+	EventCompleted = "completed"
+)
+
+// eventTransitions lists the statuses an event may move to from each status.
+// Cancelled and completed events are final.
+var eventTransitions = map[string][]string{
+	EventDraft:     {EventPublished},
+	EventPublished: {EventCompleted, EventCancelled},
+}
+
+var ErrInvalidTransition = errors.New("invalid event status transition")
+
+type EventStatusChange struct {
+	Id         int       `json:"id"`
+	EventId    int       `json:"eventId"`
+	FromStatus string    `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	ChangedBy  int       `json:"changedBy"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func CanTransition(from, to string) bool {
+	for _, s := range eventTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsFinal reports whether the event can no longer change, either because it
+// was cancelled or because it took place.
+func (e *Event) IsFinal() bool {
+	return e.Status == EventCancelled || e.Status == EventCompleted
+}
+
+// TookPlace reports whether attendees can look back on the event: it was
+// completed, or it was published and has ended by now.
+func (e *Event) TookPlace(now time.Time) bool {
+	return e.Status == EventCompleted || e.Status == EventPublished && !now.Before(e.EndsAt)
+}
+
+// Transition moves an event to a new status and records the change. It
+// returns ErrInvalidTransition if the event's current status does not allow
+// the move, including when the status changed concurrently.
+func (em *EventModel) Transition(event *Event, to string, changedBy int, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if !CanTransition(event.Status, to) {
+		return ErrInvalidTransition
+	}
+
+	tx, err := em.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE events SET status = $1
+		WHERE id = $2 AND status = $3 AND deleted_at IS NULL`,
+		to, event.Id, event.Status)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrInvalidTransition
+	}
+
+	query := `INSERT INTO event_status_history (event_id, from_status, to_status, changed_by, reason)
+			  VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.ExecContext(ctx, query, event.Id, event.Status, to, changedBy, reason)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	event.Status = to
+	return nil
+}
+
+func (em *EventModel) GetStatusHistory(eventId int) ([]*EventStatusChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, event_id, from_status, to_status, changed_by, reason, created_at
+			  FROM event_status_history WHERE event_id = $1 ORDER BY id`
+
+	rows, err := em.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*EventStatusChange{}
+
+	for rows.Next() {
+		var sc EventStatusChange
+		err := rows.Scan(&sc.Id, &sc.EventId, &sc.FromStatus, &sc.ToStatus,
+			&sc.ChangedBy, &sc.Reason, &sc.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &sc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{EventDraft, EventPublished, true},
+		{EventDraft, EventCancelled, false},
+		{EventDraft, EventCompleted, false},
+		{EventDraft, EventDraft, false},
+		{EventPublished, EventCompleted, true},
+		{EventPublished, EventCancelled, true},
+		{EventPublished, EventDraft, false},
+		{EventPublished, EventPublished, false},
+		{EventCancelled, EventPublished, false},
+		{EventCancelled, EventDraft, false},
+		{EventCancelled, EventCompleted, false},
+		{EventCompleted, EventPublished, false},
+		{EventCompleted, EventCancelled, false},
+		{"unknown", EventPublished, false},
+		{EventDraft, "unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import "time"
+
+// Loc returns the event's time zone, falling back to UTC for an unknown one.
+func (e *Event) Loc() *time.Location {
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Normalize converts the times of an event received from a client into the
+// form they are stored in. All-day events are stretched from midnight of
+// their first day to midnight after their last day in the event's time zone,
+// which is not always 24 hours apart when daylight saving time changes.
+func (e *Event) Normalize() error {
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return err
+	}
+
+	if e.AllDay {
+		y, m, d := e.StartsAt.Date()
+		e.StartsAt = time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+		y, m, d = e.EndsAt.Date()
+		e.EndsAt = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	}
+
+	e.StartsAt = e.StartsAt.UTC()
+	e.EndsAt = e.EndsAt.UTC()
+
+	return nil
+}
+
+// Localize renders the times of a stored event in its own time zone and, if
+// viewer is not nil, in the viewer's time zone as well. For all-day events
+// EndsAt becomes midnight of the last day again, mirroring Normalize.
+func (e *Event) Localize(viewer *time.Location) {
+	times := func(loc *time.Location) (time.Time, time.Time) {
+		startsAt, endsAt := e.StartsAt.In(loc), e.EndsAt.In(loc)
+		if e.AllDay {
+			endsAt = endsAt.AddDate(0, 0, -1)
+		}
+		return startsAt, endsAt
+	}
+
+	if viewer != nil {
+		e.ViewerTimes = &EventTimes{Timezone: viewer.String()}
+		e.ViewerTimes.StartsAt, e.ViewerTimes.EndsAt = times(viewer)
+	}
+
+	e.StartsAt, e.EndsAt = times(e.Loc())
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	v, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestEventNormalize(t *testing.T) {
+	tests := []struct {
+		name         string
+		timezone     string
+		allDay       bool
+		startsAt     string
+		endsAt       string
+		wantStartsAt string
+		wantEndsAt   string
+		wantErr      bool
+	}{
+		{
+			name:         "timed event with offset",
+			timezone:     "Europe/Berlin",
+			startsAt:     "2026-06-01T18:00:00+02:00",
+			endsAt:       "2026-06-01T22:30:00+02:00",
+			wantStartsAt: "2026-06-01T16:00:00Z",
+			wantEndsAt:   "2026-06-01T20:30:00Z",
+		},
+		{
+			name:         "timed event across the spring change",
+			timezone:     "Europe/Berlin",
+			startsAt:     "2026-03-28T20:00:00+01:00",
+			endsAt:       "2026-03-29T20:00:00+02:00",
+			wantStartsAt: "2026-03-28T19:00:00Z",
+			wantEndsAt:   "2026-03-29T18:00:00Z",
+		},
+		{
+			name:         "all-day event",
+			timezone:     "America/New_York",
+			allDay:       true,
+			startsAt:     "2026-07-04T15:00:00Z",
+			endsAt:       "2026-07-04T15:00:00Z",
+			wantStartsAt: "2026-07-04T04:00:00Z",
+			wantEndsAt:   "2026-07-05T04:00:00Z",
+		},
+		{
+			name:         "all-day event on the spring change is 23 hours",
+			timezone:     "Europe/Berlin",
+			allDay:       true,
+			startsAt:     "2026-03-29T00:00:00Z",
+			endsAt:       "2026-03-29T00:00:00Z",
+			wantStartsAt: "2026-03-28T23:00:00Z",
+			wantEndsAt:   "2026-03-29T22:00:00Z",
+		},
+		{
+			name:         "all-day event on the autumn change is 25 hours",
+			timezone:     "Europe/Berlin",
+			allDay:       true,
+			startsAt:     "2026-10-25T00:00:00Z",
+			endsAt:       "2026-10-25T00:00:00Z",
+			wantStartsAt: "2026-10-24T22:00:00Z",
+			wantEndsAt:   "2026-10-25T23:00:00Z",
+		},
+		{
+			name:         "multi-day all-day event",
+			timezone:     "Asia/Tokyo",
+			allDay:       true,
+			startsAt:     "2026-05-01T00:00:00Z",
+			endsAt:       "2026-05-03T00:00:00Z",
+			wantStartsAt: "2026-04-30T15:00:00Z",
+			wantEndsAt:   "2026-05-03T15:00:00Z",
+		},
+		{
+			name:     "unknown time zone",
+			timezone: "Mars/Olympus_Mons",
+			startsAt: "2026-06-01T18:00:00Z",
+			endsAt:   "2026-06-01T20:00:00Z",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Event{
+				Timezone: tt.timezone,
+				AllDay:   tt.allDay,
+				StartsAt: mustParse(t, tt.startsAt),
+				EndsAt:   mustParse(t, tt.endsAt),
+			}
+
+			err := e.Normalize()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Normalize succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := mustParse(t, tt.wantStartsAt); !e.StartsAt.Equal(want) || e.StartsAt.Location() != time.UTC {
+				t.Errorf("StartsAt = %v, want %v", e.StartsAt, want)
+			}
+			if want := mustParse(t, tt.wantEndsAt); !e.EndsAt.Equal(want) || e.EndsAt.Location() != time.UTC {
+				t.Errorf("EndsAt = %v, want %v", e.EndsAt, want)
+			}
+		})
+	}
+}
+
+func TestEventLocalize(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		timezone       string
+		allDay         bool
+		startsAt       string
+		endsAt         string
+		viewer         *time.Location
+		wantStartsAt   string
+		wantEndsAt     string
+		wantViewerFrom string
+		wantViewerTo   string
+	}{
+		{
+			name:         "timed event",
+			timezone:     "Europe/Berlin",
+			startsAt:     "2026-06-01T16:00:00Z",
+			endsAt:       "2026-06-01T20:30:00Z",
+			wantStartsAt: "2026-06-01T18:00:00+02:00",
+			wantEndsAt:   "2026-06-01T22:30:00+02:00",
+		},
+		{
+			name:           "timed event for a viewer elsewhere",
+			timezone:       "Europe/Berlin",
+			startsAt:       "2026-06-01T16:00:00Z",
+			endsAt:         "2026-06-01T20:30:00Z",
+			viewer:         tokyo,
+			wantStartsAt:   "2026-06-01T18:00:00+02:00",
+			wantEndsAt:     "2026-06-01T22:30:00+02:00",
+			wantViewerFrom: "2026-06-02T01:00:00+09:00",
+			wantViewerTo:   "2026-06-02T05:30:00+09:00",
+		},
+		{
+			name:         "all-day event ends at midnight of its last day",
+			timezone:     "Europe/Berlin",
+			allDay:       true,
+			startsAt:     "2026-03-28T23:00:00Z",
+			endsAt:       "2026-03-29T22:00:00Z",
+			wantStartsAt: "2026-03-29T00:00:00+01:00",
+			wantEndsAt:   "2026-03-29T00:00:00+01:00",
+		},
+		{
+			name:         "unknown stored time zone falls back to UTC",
+			timezone:     "Mars/Olympus_Mons",
+			startsAt:     "2026-06-01T16:00:00Z",
+			endsAt:       "2026-06-01T20:30:00Z",
+			wantStartsAt: "2026-06-01T16:00:00Z",
+			wantEndsAt:   "2026-06-01T20:30:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Event{
+				Timezone: tt.timezone,
+				AllDay:   tt.allDay,
+				StartsAt: mustParse(t, tt.startsAt),
+				EndsAt:   mustParse(t, tt.endsAt),
+			}
+			e.Localize(tt.viewer)
+
+			if got := e.StartsAt.Format(time.RFC3339); got != tt.wantStartsAt {
+				t.Errorf("StartsAt = %s, want %s", got, tt.wantStartsAt)
+			}
+			if got := e.EndsAt.Format(time.RFC3339); got != tt.wantEndsAt {
+				t.Errorf("EndsAt = %s, want %s", got, tt.wantEndsAt)
+			}
+
+			if tt.viewer == nil {
+				if e.ViewerTimes != nil {
+					t.Errorf("ViewerTimes = %+v, want nil", e.ViewerTimes)
+				}
+				return
+			}
+			if e.ViewerTimes == nil {
+				t.Fatal("ViewerTimes is nil")
+			}
+			if e.ViewerTimes.Timezone != tt.viewer.String() {
+				t.Errorf("ViewerTimes.Timezone = %s, want %s", e.ViewerTimes.Timezone, tt.viewer)
+			}
+			if got := e.ViewerTimes.StartsAt.Format(time.RFC3339); got != tt.wantViewerFrom {
+				t.Errorf("ViewerTimes.StartsAt = %s, want %s", got, tt.wantViewerFrom)
+			}
+			if got := e.ViewerTimes.EndsAt.Format(time.RFC3339); got != tt.wantViewerTo {
+				t.Errorf("ViewerTimes.EndsAt = %s, want %s", got, tt.wantViewerTo)
+			}
+		})
+	}
+}
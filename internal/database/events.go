@@ -3,6 +3,9 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"time"
 )
 
@@ -10,70 +13,223 @@ type EventModel struct {
 	DB *sql.DB
 }
 
+var ErrEventHasPaidOrders = errors.New("event has paid orders")
+
+const (
+	AttendeeVisibilityPublic    = "public"
+	AttendeeVisibilityAttendees = "attendees"
+	AttendeeVisibilityOwner     = "owner"
+)
+
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
 type Event struct {
 	Id          int    `json:"id"`
 	Name        string `json:"name" binding:"required,min=3"`
 	OwnerID     int    `json:"ownerId"`
 	Description string `json:"description" binding:"required,min=10"`
-	Date        string `json:"date" binding:"required,datetime=2006-01-02|datetime=2006-01-02T15:04:05Z07:00"`
 	Location    string `json:"location" binding:"required,min=3"`
+
+	// StartsAt and EndsAt are stored in UTC and rendered in Timezone, an IANA
+	// time zone name, by Localize.
+	StartsAt time.Time `json:"startsAt" binding:"required"`
+	EndsAt   time.Time `json:"endsAt" binding:"required,gtefield=StartsAt"`
+	Timezone string    `json:"timezone" binding:"required,timezone"`
+	// AllDay events last whole days in their time zone. Only the dates of
+	// StartsAt and EndsAt matter, and EndsAt names the last day.
+	AllDay bool `json:"allDay"`
+	// ViewerTimes holds StartsAt and EndsAt in the time zone of the viewer
+	// when one was requested.
+	ViewerTimes *EventTimes `json:"viewerTimes,omitempty" binding:"-"`
+
+	// AttendeeVisibility controls who may list the attendees of the event.
+	AttendeeVisibility string `json:"attendeeVisibility" binding:"omitempty,oneof=public attendees owner"`
+	// Visibility controls who may find and open the event. Unlisted events
+	// are left out of listings, private events are only reachable by their
+	// organizers, attendees and invited users.
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public unlisted private"`
+	// ApprovalRequired turns RSVPs into join requests that an organizer has
+	// to approve.
+	ApprovalRequired bool `json:"approvalRequired"`
+	// Status can only be chosen when the event is created. Afterwards it
+	// changes through EventModel.Transition.
+	Status string `json:"status" binding:"omitempty,oneof=draft published"`
+
+	// Rating is the average of the ratings attendees gave the event after it
+	// ended, unset until the first one, and Ratings how many there are.
+	Rating  *float64 `json:"rating,omitempty" binding:"-"`
+	Ratings int      `json:"ratings" binding:"-"`
+
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
-func (em *EventModel) Insert(event *Event) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+type EventTimes struct {
+	Timezone string    `json:"timezone"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
 
-	query := `INSERT INTO events (owner_id, name, description, date, location)
-			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+const (
+	PeriodUpcoming = "upcoming"
+	PeriodPast     = "past"
+)
 
-	return em.DB.QueryRowContext(ctx, query,
-		event.OwnerID, event.Name, event.Description, event.Date, event.Location).
-		Scan(&event.Id)
+// EventFilter narrows down the events returned by EventModel.GetAll.
+type EventFilter struct {
+	IncludeCancelled bool
+	// Period restricts the result to events that have not ended yet
+	// (PeriodUpcoming) or that have ended (PeriodPast). Empty means both.
+	Period string
+}
+
+// eventColumns lists the columns scanned by scanEvent. Queries select them
+// from events aliased as e.
+const eventColumns = `e.id, e.owner_id, e.name, e.description, e.location,
+	e.starts_at, e.ends_at, e.timezone, e.all_day,
+	e.attendee_visibility, e.visibility, e.approval_required, e.status, e.rating_total,
+	e.rating_count, e.deleted_at`
+
+// eventDependents lists the tables whose rows belong to an event and are
+// removed together with it when the event is purged. Orders are kept as the
+// record of the payments made for the event.
+var eventDependents = []string{
+	"attendees", "event_status_history", "event_collaborators", "event_ownership_transfers",
+	"event_invites", "event_invite_links", "join_requests", "ticket_types",
+	"promo_codes", "promo_code_ticket_types", "promo_redemptions", "checkins",
+	"checkin_log", "checkin_devices", "checkin_scans", "import_jobs", "registration_forms",
+	"feedback_surveys", "event_feedback", "comment_reports", "comments",
+	"announcement_deliveries", "announcements", "notifications", "notification_digest_items",
+	"chat_messages", "chat_mutes",
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(s scanner, e *Event) error {
+	var ratingTotal int
+	var deletedAt sql.NullTime
+
+	err := s.Scan(&e.Id, &e.OwnerID, &e.Name, &e.Description, &e.Location,
+		&e.StartsAt, &e.EndsAt, &e.Timezone, &e.AllDay,
+		&e.AttendeeVisibility, &e.Visibility, &e.ApprovalRequired, &e.Status, &ratingTotal,
+		&e.Ratings, &deletedAt)
+	if err != nil {
+		return err
+	}
+
+	e.Rating = nil
+	if e.Ratings > 0 {
+		rating := math.Round(float64(ratingTotal)/float64(e.Ratings)*100) / 100
+		e.Rating = &rating
+	}
+
+	if deletedAt.Valid {
+		e.DeletedAt = &deletedAt.Time
+	}
+	return nil
 }
 
-func (em *EventModel) GetAll() ([]*Event, error) {
+func (em *EventModel) Insert(event *Event) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT * FROM events`
-
-	rows, err := em.DB.QueryContext(ctx, query)
+	tx, err := em.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	events := []*Event{}
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return err
+	}
 
-	for rows.Next() {
-		var e Event
+	return tx.Commit()
+}
 
-		err := rows.Scan(&e.Id, &e.OwnerID, &e.Name,
-			&e.Description, &e.Date, &e.Location)
-		if err != nil {
-			return nil, err
-		}
+// insertEvent fills in the defaults of event and inserts it within tx.
+func insertEvent(ctx context.Context, tx *sql.Tx, event *Event) error {
+	if event.AttendeeVisibility == "" {
+		event.AttendeeVisibility = AttendeeVisibilityPublic
+	}
+	if event.Visibility == "" {
+		event.Visibility = VisibilityPublic
+	}
+	if event.Status == "" {
+		event.Status = EventDraft
+	}
+	event.Rating, event.Ratings = nil, 0
 
-		events = append(events, &e)
+	query := `INSERT INTO events (owner_id, name, description, location,
+			  starts_at, ends_at, timezone, all_day, attendee_visibility, visibility,
+			  approval_required, status)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+
+	return tx.QueryRowContext(ctx, query,
+		event.OwnerID, event.Name, event.Description, event.Location,
+		event.StartsAt.UTC(), event.EndsAt.UTC(), event.Timezone, event.AllDay,
+		event.AttendeeVisibility, event.Visibility, event.ApprovalRequired, event.Status).
+		Scan(&event.Id)
+}
+
+// GetAll returns the events that are listed publicly. Drafts, unlisted and
+// private events are never listed, cancelled events only when the filter
+// asks for them.
+func (em *EventModel) GetAll(filter EventFilter) ([]*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events e
+			  WHERE e.deleted_at IS NULL AND e.status != $1 AND e.visibility = $2`
+	args := []any{EventDraft, VisibilityPublic}
+	order := `e.starts_at`
+
+	if !filter.IncludeCancelled {
+		args = append(args, EventCancelled)
+		query += fmt.Sprintf(` AND e.status != $%d`, len(args))
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	switch filter.Period {
+	case PeriodUpcoming:
+		args = append(args, time.Now().UTC())
+		query += fmt.Sprintf(` AND e.ends_at >= $%d`, len(args))
+	case PeriodPast:
+		args = append(args, time.Now().UTC())
+		query += fmt.Sprintf(` AND e.ends_at < $%d`, len(args))
+		order = `e.starts_at DESC`
 	}
 
-	return events, nil
+	return em.getEvents(query+` ORDER BY `+order, args...)
 }
 
+func (em *EventModel) GetByOwner(ownerId int) ([]*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events e
+			  WHERE e.deleted_at IS NULL AND e.owner_id = $1`
+	return em.getEvents(query, ownerId)
+}
+
+// Get returns the event with the given id unless it has been deleted.
 func (em *EventModel) Get(id int) (*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events e
+			  WHERE e.deleted_at IS NULL AND e.id = $1`
+	return em.getEvent(query, id)
+}
+
+// GetDeleted returns the event with the given id only if it has been deleted
+// and not purged yet.
+func (em *EventModel) GetDeleted(id int) (*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events e
+			  WHERE e.deleted_at IS NOT NULL AND e.id = $1`
+	return em.getEvent(query, id)
+}
+
+func (em *EventModel) getEvent(query string, args ...any) (*Event, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT * FROM events WHERE id = $1`
-
 	var event Event
-	err := em.DB.QueryRowContext(ctx, query, id).
-		Scan(&event.Id, &event.OwnerID, &event.Name,
-			&event.Description, &event.Date, &event.Location)
+	err := scanEvent(em.DB.QueryRowContext(ctx, query, args...), &event)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -88,11 +244,16 @@ func (em *EventModel) Update(event *Event) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `UPDATE events SET name = $1, description = $2, date = $3, location = $4
-			  WHERE id = $5`
+	query := `UPDATE events SET name = $1, description = $2, location = $3,
+			  reminded_at = CASE WHEN starts_at = $4 THEN reminded_at END,
+			  starts_at = $4, ends_at = $5, timezone = $6, all_day = $7,
+			  attendee_visibility = $8, visibility = $9, approval_required = $10
+			  WHERE id = $11 AND deleted_at IS NULL`
 
 	_, err := em.DB.ExecContext(ctx, query,
-		event.Name, event.Description, event.Date, event.Location, event.Id)
+		event.Name, event.Description, event.Location,
+		event.StartsAt.UTC(), event.EndsAt.UTC(), event.Timezone, event.AllDay,
+		event.AttendeeVisibility, event.Visibility, event.ApprovalRequired, event.Id)
 	if err != nil {
 		return err
 	}
@@ -100,16 +261,115 @@ func (em *EventModel) Update(event *Event) error {
 	return nil
 }
 
+// Delete soft-deletes an event. The event and its attendees stay in the
+// database until PurgeDeleted removes them, and Restore can bring them back.
+// Events with paid orders cannot be deleted until the orders are refunded,
+// which returns ErrEventHasPaidOrders.
 func (em *EventModel) Delete(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `DELETE FROM events WHERE id = $1`
+	tx, err := em.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	_, err := em.DB.ExecContext(ctx, query, id)
+	var paid bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM orders
+		WHERE event_id = $1 AND status = $2)`, id, OrderPaid).Scan(&paid)
 	if err != nil {
 		return err
 	}
+	if paid {
+		return ErrEventHasPaidOrders
+	}
 
-	return nil
+	query := `UPDATE events SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	_, err = tx.ExecContext(ctx, query, time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (em *EventModel) Restore(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE events SET deleted_at = NULL WHERE id = $1`
+
+	_, err := em.DB.ExecContext(ctx, query, id)
+	return err
+}
+
+// PurgeDeleted permanently removes the events deleted before the given time
+// and returns how many were removed.
+func (em *EventModel) PurgeDeleted(before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := em.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := purgeEvents(ctx, tx, `deleted_at IS NOT NULL AND deleted_at < $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+// purgeEvents permanently deletes the events matching cond, a condition on
+// the events table, together with every row that belongs to them.
+func purgeEvents(ctx context.Context, tx *sql.Tx, cond string, args ...any) (int, error) {
+	for _, table := range eventDependents {
+		query := `DELETE FROM ` + table + ` WHERE event_id IN
+			(SELECT id FROM events WHERE ` + cond + `)`
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM events WHERE `+cond, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (em *EventModel) getEvents(query string, args ...any) ([]*Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := em.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+
+	for rows.Next() {
+		var e Event
+
+		if err := scanEvent(rows, &e); err != nil {
+			return nil, err
+		}
+
+		events = append(events, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
 }
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventDeleteWithOrders(t *testing.T) {
+	db := newTestDB(t)
+	em := EventModel{DB: db}
+
+	tests := []struct {
+		name    string
+		status  string
+		wantErr error
+	}{
+		{"paid order", OrderPaid, ErrEventHasPaidOrders},
+		{"refunded order", OrderRefunded, nil},
+		{"expired order", OrderExpired, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userId, eventId := insertTestEvent(t, db)
+
+			now := time.Now().UTC()
+			var orderId int
+			err := db.QueryRow(`INSERT INTO orders (event_id, user_id, ticket_type_id, quantity,
+				amount, currency, status, provider, payment_id, expires_at, created_at)
+				VALUES ($1, $2, 1, 1, 1500, 'EUR', $3, 'fake', 'pi_1', $4, $4) RETURNING id`,
+				eventId, userId, tt.status, now).Scan(&orderId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := em.Delete(eventId); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// The order outlives the purge of its event.
+			if _, err := em.PurgeDeleted(now.Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			var events, orders int
+			err = db.QueryRow(`SELECT (SELECT COUNT(*) FROM events WHERE id = $1),
+				(SELECT COUNT(*) FROM orders WHERE id = $2)`, eventId, orderId).Scan(&events, &orders)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if events != 0 || orders != 1 {
+				t.Errorf("events = %d, orders = %d after the purge, want 0, 1", events, orders)
+			}
+		})
+	}
+}
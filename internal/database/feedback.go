@@ -0,0 +1,237 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type FeedbackModel struct {
+	DB *sql.DB
+}
+
+var ErrFeedbackGiven = errors.New("user already gave feedback on the event")
+
+// FeedbackSurvey is the set of questions attendees answer along with their
+// rating once an event is over.
+type FeedbackSurvey struct {
+	EventId   int         `json:"eventId"`
+	Questions []*Question `json:"questions" binding:"max=50,dive"`
+	UpdatedAt *time.Time  `json:"updatedAt,omitempty"`
+}
+
+// Validate checks the rules of the survey that binding tags cannot express.
+// It returns why the survey is invalid, or nil.
+func (s *FeedbackSurvey) Validate() error {
+	return validateQuestions(s.Questions)
+}
+
+// Check validates answers against the survey like RegistrationForm.Check.
+func (s *FeedbackSurvey) Check(answers Answers) (Answers, map[string]string) {
+	return checkAnswers(s.Questions, answers, false)
+}
+
+// Feedback is an attendee's rating of an event and their answers to its
+// survey. Every attendee gives feedback at most once.
+type Feedback struct {
+	Id        int       `json:"id"`
+	EventId   int       `json:"eventId"`
+	UserId    int       `json:"userId"`
+	Rating    int       `json:"rating" binding:"required,min=1,max=5"`
+	Answers   Answers   `json:"answers"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const feedbackColumns = `id, event_id, user_id, rating, answers, created_at`
+
+func scanFeedback(s scanner, f *Feedback) error {
+	var answers string
+
+	err := s.Scan(&f.Id, &f.EventId, &f.UserId, &f.Rating, &answers, &f.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	f.Answers, err = decodeAnswers(answers)
+	return err
+}
+
+// GetSurvey returns the feedback survey of an event. Events without questions
+// have a survey with none.
+func (fm *FeedbackModel) GetSurvey(eventId int) (*FeedbackSurvey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT questions, updated_at FROM feedback_surveys WHERE event_id = $1`
+
+	s := &FeedbackSurvey{EventId: eventId, Questions: []*Question{}}
+	var questions string
+	var updatedAt time.Time
+	err := fm.DB.QueryRowContext(ctx, query, eventId).Scan(&questions, &updatedAt)
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.UpdatedAt = &updatedAt
+	return s, json.Unmarshal([]byte(questions), &s.Questions)
+}
+
+// SetSurvey replaces the questions of an event's feedback survey. Answers
+// given before are kept as they were.
+func (fm *FeedbackModel) SetSurvey(s *FeedbackSurvey, updatedBy int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if s.Questions == nil {
+		s.Questions = []*Question{}
+	}
+	questions, err := json.Marshal(s.Questions)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := `INSERT INTO feedback_surveys (event_id, questions, updated_by, updated_at)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (event_id) DO UPDATE SET questions = excluded.questions,
+			  updated_by = excluded.updated_by, updated_at = excluded.updated_at`
+
+	if _, err := fm.DB.ExecContext(ctx, query, s.EventId, string(questions), updatedBy,
+		now); err != nil {
+		return err
+	}
+
+	s.UpdatedAt = &now
+	return nil
+}
+
+// Insert records feedback and adds its rating to the event's average. It
+// returns ErrFeedbackGiven if the user already gave feedback on the event.
+func (fm *FeedbackModel) Insert(f *Feedback) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := fm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	f.CreatedAt = time.Now().UTC()
+	if f.Answers == nil {
+		f.Answers = Answers{}
+	}
+	answers, err := encodeAnswers(f.Answers)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO event_feedback (event_id, user_id, rating, answers, created_at)
+			  VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, f.EventId, f.UserId, f.Rating, answers,
+		f.CreatedAt).Scan(&f.Id)
+	if err == sql.ErrNoRows {
+		return ErrFeedbackGiven
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE events SET rating_total = rating_total + $1,
+		rating_count = rating_count + 1 WHERE id = $2`, f.Rating, f.EventId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByEvent returns the feedback on an event, newest first.
+func (fm *FeedbackModel) GetByEvent(eventId int) ([]*Feedback, error) {
+	return fm.getFeedback(`event_id = $1 ORDER BY id DESC`, eventId)
+}
+
+// GetByUser returns the feedback a user gave, oldest first.
+func (fm *FeedbackModel) GetByUser(userId int) ([]*Feedback, error) {
+	return fm.getFeedback(`user_id = $1 ORDER BY id`, userId)
+}
+
+func (fm *FeedbackModel) getFeedback(where string, args ...any) ([]*Feedback, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + feedbackColumns + ` FROM event_feedback WHERE ` + where
+
+	rows, err := fm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feedback := []*Feedback{}
+
+	for rows.Next() {
+		var f Feedback
+		if err := scanFeedback(rows, &f); err != nil {
+			return nil, err
+		}
+		feedback = append(feedback, &f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+// DueInvites returns the events that ended before endedBefore and whose
+// attendees were not invited to give feedback yet. Cancelled, draft and
+// deleted events are left out.
+func (fm *FeedbackModel) DueInvites(endedBefore time.Time) ([]*Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + eventColumns + ` FROM events e
+			  WHERE e.feedback_invited_at IS NULL AND e.deleted_at IS NULL
+			  AND e.status IN ($1, $2) AND e.ends_at <= $3`
+
+	rows, err := fm.DB.QueryContext(ctx, query, EventPublished, EventCompleted, endedBefore.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+
+	for rows.Next() {
+		var e Event
+		if err := scanEvent(rows, &e); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkInvited records that the attendees of an event were invited to give
+// feedback.
+func (fm *FeedbackModel) MarkInvited(eventId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := fm.DB.ExecContext(ctx, `UPDATE events SET feedback_invited_at = $1 WHERE id = $2`,
+		time.Now().UTC(), eventId)
+	return err
+}
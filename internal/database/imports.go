@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type ImportModel struct {
+	DB *sql.DB
+}
+
+// Kinds of data an import creates.
+const (
+	ImportEvents    = "events"
+	ImportAttendees = "attendees"
+)
+
+const (
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+var (
+	// ErrImportNotRunning is returned when a chunk is written to an import
+	// that is no longer running or was moved on by someone else.
+	ErrImportNotRunning = errors.New("import is not running")
+	ErrImportNotFailed  = errors.New("import has not failed")
+)
+
+// ImportJob is an import of a CSV file. The rows are imported in chunks of
+// ChunkSize, each in its own transaction, so a failed import can be resumed
+// from NextRow.
+type ImportJob struct {
+	Id     int    `json:"id"`
+	UserId int    `json:"userId"`
+	Kind   string `json:"kind"`
+	// EventId is the event attendees are imported into.
+	EventId *int `json:"eventId,omitempty"`
+	// Mapping maps fields to the CSV columns holding them. Fields that are
+	// not mapped are read from the column of the same name.
+	Mapping map[string]string `json:"mapping"`
+	// MatchUsers identifies imported attendees by email instead of user id.
+	MatchUsers bool   `json:"matchUsers"`
+	ChunkSize  int    `json:"chunkSize"`
+	FilePath   string `json:"-"`
+	Status     string `json:"status"`
+	TotalRows  int    `json:"totalRows"`
+	// NextRow counts the rows done, in file order.
+	NextRow     int        `json:"nextRow"`
+	Imported    int        `json:"imported"`
+	Skipped     int        `json:"skipped"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+const importJobColumns = `id, user_id, kind, event_id, mapping, match_users, chunk_size,
+	file_path, status, total_rows, next_row, imported, skipped, error, created_at, completed_at`
+
+func scanImportJob(s scanner, j *ImportJob) error {
+	var eventId sql.NullInt64
+	var mapping string
+	var completedAt sql.NullTime
+
+	err := s.Scan(&j.Id, &j.UserId, &j.Kind, &eventId, &mapping, &j.MatchUsers, &j.ChunkSize,
+		&j.FilePath, &j.Status, &j.TotalRows, &j.NextRow, &j.Imported, &j.Skipped, &j.Error,
+		&j.CreatedAt, &completedAt)
+	if err != nil {
+		return err
+	}
+
+	j.EventId, j.CompletedAt = nil, nil
+	if eventId.Valid {
+		id := int(eventId.Int64)
+		j.EventId = &id
+	}
+	if completedAt.Valid {
+		j.CompletedAt = &completedAt.Time
+	}
+	return json.Unmarshal([]byte(mapping), &j.Mapping)
+}
+
+// Insert records a running import.
+func (im *ImportModel) Insert(j *ImportJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if j.Mapping == nil {
+		j.Mapping = map[string]string{}
+	}
+	mapping, err := json.Marshal(j.Mapping)
+	if err != nil {
+		return err
+	}
+
+	j.Status = ImportRunning
+	j.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO import_jobs (user_id, kind, event_id, mapping, match_users, chunk_size,
+			  file_path, status, total_rows, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+
+	return im.DB.QueryRowContext(ctx, query, j.UserId, j.Kind, j.EventId, string(mapping),
+		j.MatchUsers, j.ChunkSize, j.FilePath, j.Status, j.TotalRows, j.CreatedAt).Scan(&j.Id)
+}
+
+func (im *ImportModel) Get(id int) (*ImportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1`
+
+	var j ImportJob
+	err := scanImportJob(im.DB.QueryRowContext(ctx, query, id), &j)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &j, nil
+}
+
+// GetByUser returns the imports a user started, newest first.
+func (im *ImportModel) GetByUser(userId int) ([]*ImportJob, error) {
+	return im.getImportJobs(`user_id = $1 ORDER BY id DESC`, userId)
+}
+
+// GetRunning returns the imports that are still running, oldest first.
+func (im *ImportModel) GetRunning() ([]*ImportJob, error) {
+	return im.getImportJobs(`status = $1 ORDER BY id`, ImportRunning)
+}
+
+func (im *ImportModel) getImportJobs(where string, args ...any) ([]*ImportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE ` + where
+
+	rows, err := im.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*ImportJob{}
+
+	for rows.Next() {
+		var j ImportJob
+		if err := scanImportJob(rows, &j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &j)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Fail stops a running import with the reason it failed.
+func (im *ImportModel) Fail(id int, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := im.DB.ExecContext(ctx, `UPDATE import_jobs SET status = $1, error = $2
+		WHERE id = $3 AND status = $4`, ImportFailed, reason, id, ImportRunning)
+	return err
+}
+
+// Resume sets a failed import running again from where it stopped. It
+// returns ErrImportNotFailed if the import has not failed.
+func (im *ImportModel) Resume(j *ImportJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE import_jobs SET status = $1, error = '' WHERE id = $2 AND status = $3
+			  RETURNING ` + importJobColumns
+
+	err := scanImportJob(im.DB.QueryRowContext(ctx, query, ImportRunning, j.Id, ImportFailed), j)
+	if err == sql.ErrNoRows {
+		return ErrImportNotFailed
+	}
+	return err
+}
+
+// InsertEvents imports a chunk of events for a running import and moves it on
+// to nextRow, completing it after its last row.
+func (im *ImportModel) InsertEvents(j *ImportJob, events []*Event, nextRow int) error {
+	return im.importChunk(j, nextRow, func(ctx context.Context, tx *sql.Tx) (int, int, error) {
+		for _, e := range events {
+			if err := insertEvent(ctx, tx, e); err != nil {
+				return 0, 0, err
+			}
+		}
+		return len(events), 0, nil
+	})
+}
+
+// InsertAttendees imports a chunk of attendees for a running import and moves
+// it on to nextRow, completing it after its last row. Users who attend the
+// event already are skipped. It returns ErrSoldOut if the tickets run out.
+func (im *ImportModel) InsertAttendees(j *ImportJob, attendees []*Attendee, nextRow int) error {
+	return im.importChunk(j, nextRow, func(ctx context.Context, tx *sql.Tx) (int, int, error) {
+		imported, skipped := 0, 0
+		for _, a := range attendees {
+			var exists bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM attendees
+				WHERE event_id = $1 AND user_id = $2)`, a.EventId, a.UserId).Scan(&exists)
+			if err != nil {
+				return 0, 0, err
+			}
+			if exists {
+				skipped++
+				continue
+			}
+
+			if err := insertAttendee(ctx, tx, a); err != nil {
+				return 0, 0, err
+			}
+			imported++
+		}
+		return imported, skipped, nil
+	})
+}
+
+// importChunk runs insert and records the progress of j in one transaction.
+// Progress is only recorded if no one else moved the import on meanwhile, so
+// a chunk is never imported twice.
+func (im *ImportModel) importChunk(j *ImportJob, nextRow int,
+	insert func(ctx context.Context, tx *sql.Tx) (imported, skipped int, err error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := im.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	imported, skipped, err := insert(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := `UPDATE import_jobs SET next_row = $1, imported = imported + $2,
+			  skipped = skipped + $3,
+			  status = CASE WHEN $1 >= total_rows THEN $4 ELSE status END,
+			  completed_at = CASE WHEN $1 >= total_rows THEN $5 END
+			  WHERE id = $6 AND status = $7 AND next_row = $8
+			  RETURNING ` + importJobColumns
+
+	var updated ImportJob
+	err = scanImportJob(tx.QueryRowContext(ctx, query, nextRow, imported, skipped,
+		ImportCompleted, now, j.Id, ImportRunning, j.NextRow), &updated)
+	if err == sql.ErrNoRows {
+		return ErrImportNotRunning
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*j = updated
+	return nil
+}
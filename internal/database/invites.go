@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type InviteModel struct {
+	DB *sql.DB
+}
+
+// EventInvite lets a user open a private event before they attend it.
+type EventInvite struct {
+	EventId   int       `json:"eventId"`
+	UserId    int       `json:"userId"`
+	InvitedBy int       `json:"invitedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// InviteLink is a shareable link that makes whoever redeems it an attendee
+// of the event. The link itself is a signed token carrying the link's id.
+type InviteLink struct {
+	Id        int        `json:"id"`
+	EventId   int        `json:"eventId"`
+	CreatedBy int        `json:"createdBy"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// MaxUses caps how often the link can be redeemed. Zero means no cap.
+	MaxUses   int        `json:"maxUses"`
+	Uses      int        `json:"uses"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// Token is filled in by the API when the link is shown to its organizers.
+	Token string `json:"token,omitempty"`
+}
+
+var (
+	ErrAlreadyInvited      = errors.New("user is already invited to the event")
+	ErrInviteLinkRevoked   = errors.New("invite link has been revoked")
+	ErrInviteLinkExpired   = errors.New("invite link has expired")
+	ErrInviteLinkExhausted = errors.New("invite link has been used up")
+)
+
+// Check returns why the link can no longer be redeemed, or nil if it can.
+func (l *InviteLink) Check(now time.Time) error {
+	switch {
+	case l.RevokedAt != nil:
+		return ErrInviteLinkRevoked
+	case l.ExpiresAt != nil && !now.Before(*l.ExpiresAt):
+		return ErrInviteLinkExpired
+	case l.MaxUses > 0 && l.Uses >= l.MaxUses:
+		return ErrInviteLinkExhausted
+	}
+
+	return nil
+}
+
+const inviteLinkColumns = `id, event_id, created_by, expires_at, max_uses, uses, created_at, revoked_at`
+
+func scanInviteLink(s scanner, l *InviteLink) error {
+	var expiresAt, revokedAt sql.NullTime
+
+	err := s.Scan(&l.Id, &l.EventId, &l.CreatedBy, &expiresAt, &l.MaxUses, &l.Uses,
+		&l.CreatedAt, &revokedAt)
+	if err != nil {
+		return err
+	}
+
+	if expiresAt.Valid {
+		l.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		l.RevokedAt = &revokedAt.Time
+	}
+	return nil
+}
+
+// Invite records a user invitation. It returns ErrAlreadyInvited if the user
+// is already invited to the event.
+func (im *InviteModel) Invite(i *EventInvite) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	i.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO event_invites (event_id, user_id, invited_by, created_at)
+			  VALUES ($1, $2, $3, $4) ON CONFLICT (event_id, user_id) DO NOTHING`
+
+	result, err := im.DB.ExecContext(ctx, query, i.EventId, i.UserId, i.InvitedBy, i.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAlreadyInvited
+	}
+	return nil
+}
+
+func (im *InviteModel) GetByEvent(eventId int) ([]*EventInvite, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT event_id, user_id, invited_by, created_at FROM event_invites
+			  WHERE event_id = $1 ORDER BY created_at`
+
+	rows, err := im.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []*EventInvite{}
+
+	for rows.Next() {
+		var i EventInvite
+		if err := rows.Scan(&i.EventId, &i.UserId, &i.InvitedBy, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		invites = append(invites, &i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+// Delete withdraws a user invitation. It reports whether one existed.
+func (im *InviteModel) Delete(eventId, userId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := im.DB.ExecContext(ctx,
+		`DELETE FROM event_invites WHERE event_id = $1 AND user_id = $2`, eventId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// HasAccess reports whether the user is invited to or attends the event,
+// which is what it takes to open a private event without organizing it.
+func (im *InviteModel) HasAccess(eventId, userId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT EXISTS (SELECT 1 FROM event_invites WHERE event_id = $1 AND user_id = $2)
+			  OR EXISTS (SELECT 1 FROM attendees WHERE event_id = $1 AND user_id = $2)`
+
+	var ok bool
+	err := im.DB.QueryRowContext(ctx, query, eventId, userId).Scan(&ok)
+	return ok, err
+}
+
+func (im *InviteModel) InsertLink(l *InviteLink) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	l.CreatedAt = time.Now().UTC()
+	if l.ExpiresAt != nil {
+		expiresAt := l.ExpiresAt.UTC()
+		l.ExpiresAt = &expiresAt
+	}
+
+	query := `INSERT INTO event_invite_links (event_id, created_by, expires_at, max_uses, created_at)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	return im.DB.QueryRowContext(ctx, query,
+		l.EventId, l.CreatedBy, l.ExpiresAt, l.MaxUses, l.CreatedAt).Scan(&l.Id)
+}
+
+func (im *InviteModel) GetLink(id int) (*InviteLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + inviteLinkColumns + ` FROM event_invite_links WHERE id = $1`
+
+	var l InviteLink
+	err := scanInviteLink(im.DB.QueryRowContext(ctx, query, id), &l)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+func (im *InviteModel) GetLinksByEvent(eventId int) ([]*InviteLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + inviteLinkColumns + ` FROM event_invite_links
+			  WHERE event_id = $1 ORDER BY created_at DESC`
+
+	rows, err := im.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*InviteLink{}
+
+	for rows.Next() {
+		var l InviteLink
+		if err := scanInviteLink(rows, &l); err != nil {
+			return nil, err
+		}
+		links = append(links, &l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// RevokeLink stops a link from being redeemed. It reports whether an active
+// link with that id existed on the event.
+func (im *InviteModel) RevokeLink(eventId, id int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE event_invite_links SET revoked_at = $1
+			  WHERE id = $2 AND event_id = $3 AND revoked_at IS NULL`
+
+	result, err := im.DB.ExecContext(ctx, query, time.Now().UTC(), id, eventId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Redeem registers a as an attendee of the link's event and counts the use.
+// Users who already attend are returned their existing attendance without
+// using up the link. It returns the error from InviteLink.Check if the link
+// cannot be redeemed, including when it ran out concurrently, and ErrSoldOut
+// if a holds more tickets than are left.
+func (im *InviteModel) Redeem(l *InviteLink, a *Attendee) (*Attendee, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := im.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var existing Attendee
+	err = scanAttendee(tx.QueryRowContext(ctx, `SELECT `+attendeeColumns+` FROM attendees
+		WHERE event_id = $1 AND user_id = $2`, l.EventId, a.UserId), &existing)
+	if err == nil {
+		return &existing, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := l.Check(now); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE event_invite_links SET uses = uses + 1
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		AND (max_uses = 0 OR uses < max_uses)`, l.Id, now)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrInviteLinkExhausted
+	}
+
+	a.EventId = l.EventId
+	if err := insertAttendee(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	l.Uses++
+	return a, tx.Commit()
+}
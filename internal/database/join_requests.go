@@ -0,0 +1,301 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type JoinRequestModel struct {
+	DB *sql.DB
+}
+
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
+
+var (
+	ErrJoinRequestPending = errors.New("user already has a pending join request for the event")
+	ErrJoinRequestDecided = errors.New("join request has already been decided")
+)
+
+// JoinRequest is a user's application to attend an event that requires
+// approval. Approving it creates the Attendee.
+type JoinRequest struct {
+	Id      int    `json:"id"`
+	EventId int    `json:"eventId"`
+	UserId  int    `json:"userId"`
+	Message string `json:"message"`
+	// TicketTypeId and Quantity are the tickets asked for, if the event sells
+	// tickets. They are only reserved once the request is approved.
+	TicketTypeId *int `json:"ticketTypeId,omitempty"`
+	Quantity     int  `json:"quantity"`
+	// Answers are the answers to the event's registration form, which the
+	// attendee takes over once the request is approved.
+	Answers   Answers    `json:"answers"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	DecidedBy *int       `json:"decidedBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	DecidedAt *time.Time `json:"decidedAt,omitempty"`
+}
+
+const joinRequestColumns = `id, event_id, user_id, message, ticket_type_id, quantity, answers,
+	status, reason, decided_by, created_at, decided_at`
+
+func scanJoinRequest(s scanner, r *JoinRequest) error {
+	var ticketTypeId, decidedBy sql.NullInt64
+	var answers string
+	var decidedAt sql.NullTime
+
+	err := s.Scan(&r.Id, &r.EventId, &r.UserId, &r.Message, &ticketTypeId, &r.Quantity, &answers,
+		&r.Status, &r.Reason, &decidedBy, &r.CreatedAt, &decidedAt)
+	if err != nil {
+		return err
+	}
+
+	if ticketTypeId.Valid {
+		id := int(ticketTypeId.Int64)
+		r.TicketTypeId = &id
+	}
+	if decidedBy.Valid {
+		id := int(decidedBy.Int64)
+		r.DecidedBy = &id
+	}
+	if decidedAt.Valid {
+		r.DecidedAt = &decidedAt.Time
+	}
+
+	r.Answers, err = decodeAnswers(answers)
+	return err
+}
+
+// Insert records a pending join request. It returns ErrJoinRequestPending if
+// the user already has one for the event.
+func (jm *JoinRequestModel) Insert(r *JoinRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	r.Status = JoinRequestPending
+	r.CreatedAt = time.Now().UTC()
+	if r.Quantity == 0 {
+		r.Quantity = 1
+	}
+	if r.Answers == nil {
+		r.Answers = Answers{}
+	}
+
+	answers, err := encodeAnswers(r.Answers)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO join_requests (event_id, user_id, message, ticket_type_id, quantity,
+			  answers, status, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING RETURNING id`
+
+	err = jm.DB.QueryRowContext(ctx, query, r.EventId, r.UserId, r.Message,
+		r.TicketTypeId, r.Quantity, answers, r.Status, r.CreatedAt).Scan(&r.Id)
+	if err == sql.ErrNoRows {
+		return ErrJoinRequestPending
+	}
+	return err
+}
+
+func (jm *JoinRequestModel) Get(id int) (*JoinRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + joinRequestColumns + ` FROM join_requests WHERE id = $1`
+
+	var r JoinRequest
+	err := scanJoinRequest(jm.DB.QueryRowContext(ctx, query, id), &r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetByEvent returns the join requests of an event, oldest first. An empty
+// status returns requests in every status.
+func (jm *JoinRequestModel) GetByEvent(eventId int, status string) ([]*JoinRequest, error) {
+	query := `SELECT ` + joinRequestColumns + ` FROM join_requests
+			  WHERE event_id = $1 AND ($2 = '' OR status = $2) ORDER BY created_at`
+	return jm.getJoinRequests(query, eventId, status)
+}
+
+// GetByUser returns the join requests a user has made, newest first.
+func (jm *JoinRequestModel) GetByUser(userId int) ([]*JoinRequest, error) {
+	query := `SELECT ` + joinRequestColumns + ` FROM join_requests
+			  WHERE user_id = $1 ORDER BY created_at DESC`
+	return jm.getJoinRequests(query, userId)
+}
+
+// Approve approves a pending request and makes its user an attendee with the
+// tickets they asked for, unless they already attend. Requests for paid
+// tickets return no attendee: approving them lets the user place an order.
+// It returns ErrJoinRequestDecided if the request is no longer pending and
+// ErrSoldOut if too few tickets are left.
+func (jm *JoinRequestModel) Approve(r *JoinRequest, decidedBy int) (*Attendee, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := jm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := decideJoinRequest(ctx, tx, r, JoinRequestApproved, decidedBy, ""); err != nil {
+		return nil, err
+	}
+
+	if r.TicketTypeId != nil {
+		var price int64
+		err := tx.QueryRowContext(ctx, `SELECT price FROM ticket_types WHERE id = $1`,
+			*r.TicketTypeId).Scan(&price)
+		if err != nil {
+			return nil, err
+		}
+		if price > 0 {
+			return nil, tx.Commit()
+		}
+	}
+
+	var attendee Attendee
+	err = scanAttendee(tx.QueryRowContext(ctx, `SELECT `+attendeeColumns+` FROM attendees
+		WHERE event_id = $1 AND user_id = $2`, r.EventId, r.UserId), &attendee)
+	if err == sql.ErrNoRows {
+		attendee = Attendee{
+			EventId:      r.EventId,
+			UserId:       r.UserId,
+			TicketTypeId: r.TicketTypeId,
+			Quantity:     r.Quantity,
+			Answers:      r.Answers,
+		}
+		err = insertAttendee(ctx, tx, &attendee)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attendee, tx.Commit()
+}
+
+// GetApproved returns the user's approved request for the event, or nil if
+// they have none.
+func (jm *JoinRequestModel) GetApproved(eventId, userId int) (*JoinRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + joinRequestColumns + ` FROM join_requests
+			  WHERE event_id = $1 AND user_id = $2 AND status = $3
+			  ORDER BY decided_at DESC LIMIT 1`
+
+	var r JoinRequest
+	err := scanJoinRequest(jm.DB.QueryRowContext(ctx, query, eventId, userId,
+		JoinRequestApproved), &r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// Reject rejects a pending request. It returns ErrJoinRequestDecided if the
+// request is no longer pending.
+func (jm *JoinRequestModel) Reject(r *JoinRequest, decidedBy int, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := jm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := decideJoinRequest(ctx, tx, r, JoinRequestRejected, decidedBy, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Withdraw deletes a pending request on behalf of its user. It reports
+// whether a pending request existed.
+func (jm *JoinRequestModel) Withdraw(id, userId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM join_requests WHERE id = $1 AND user_id = $2 AND status = $3`
+
+	result, err := jm.DB.ExecContext(ctx, query, id, userId, JoinRequestPending)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// decideJoinRequest moves a pending request to status and updates r accordingly.
+func decideJoinRequest(ctx context.Context, tx *sql.Tx, r *JoinRequest,
+	status string, decidedBy int, reason string) error {
+	now := time.Now().UTC()
+
+	result, err := tx.ExecContext(ctx, `UPDATE join_requests
+		SET status = $1, reason = $2, decided_by = $3, decided_at = $4
+		WHERE id = $5 AND status = $6`,
+		status, reason, decidedBy, now, r.Id, JoinRequestPending)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrJoinRequestDecided
+	}
+
+	r.Status = status
+	r.Reason = reason
+	r.DecidedBy = &decidedBy
+	r.DecidedAt = &now
+	return nil
+}
+
+func (jm *JoinRequestModel) getJoinRequests(query string, args ...any) ([]*JoinRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := jm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*JoinRequest{}
+
+	for rows.Next() {
+		var r JoinRequest
+		if err := scanJoinRequest(rows, &r); err != nil {
+			return nil, err
+		}
+		requests = append(requests, &r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
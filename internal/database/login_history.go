@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type LoginHistoryModel struct {
+	DB *sql.DB
+}
+
+type Login struct {
+	Id        int       `json:"id"`
+	UserId    int       `json:"userId"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (lm *LoginHistoryModel) Insert(l *Login) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO login_history (user_id, ip, user_agent)
+			  VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	return lm.DB.QueryRowContext(ctx, query, l.UserId, l.IP, l.UserAgent).
+		Scan(&l.Id, &l.CreatedAt)
+}
+
+func (lm *LoginHistoryModel) GetByUser(userId int) ([]*Login, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, user_id, ip, user_agent, created_at FROM login_history
+			  WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := lm.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logins := []*Login{}
+
+	for rows.Next() {
+		var l Login
+		if err := rows.Scan(&l.Id, &l.UserId, &l.IP, &l.UserAgent, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		logins = append(logins, &l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return logins, nil
+}
@@ -3,15 +3,55 @@ package database
 import "database/sql"
 
 type Models struct {
-	Users     UserModel
-	Events    EventModel
-	Attendees AttendeeModel
+	Users              UserModel
+	Events             EventModel
+	Attendees          AttendeeModel
+	JoinRequests       JoinRequestModel
+	TicketTypes        TicketTypeModel
+	RegistrationForms  RegistrationFormModel
+	Feedback           FeedbackModel
+	Comments           CommentModel
+	Chat               ChatModel
+	Announcements      AnnouncementModel
+	Preferences        NotificationPreferenceModel
+	Notifications      NotificationModel
+	Orders             OrderModel
+	PromoCodes         PromoCodeModel
+	CheckIns           CheckInModel
+	Imports            ImportModel
+	EmailVerifications EmailVerificationModel
+	LoginHistory       LoginHistoryModel
+	DataExports        DataExportModel
+	Audit              AuditModel
+	Sessions           SessionModel
+	Collaborators      CollaboratorModel
+	Invites            InviteModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Users:     UserModel{DB: db},
-		Events:    EventModel{DB: db},
-		Attendees: AttendeeModel{DB: db},
+		Users:              UserModel{DB: db},
+		Events:             EventModel{DB: db},
+		Attendees:          AttendeeModel{DB: db},
+		JoinRequests:       JoinRequestModel{DB: db},
+		TicketTypes:        TicketTypeModel{DB: db},
+		RegistrationForms:  RegistrationFormModel{DB: db},
+		Feedback:           FeedbackModel{DB: db},
+		Comments:           CommentModel{DB: db},
+		Chat:               ChatModel{DB: db},
+		Announcements:      AnnouncementModel{DB: db},
+		Preferences:        NotificationPreferenceModel{DB: db},
+		Notifications:      NotificationModel{DB: db},
+		Orders:             OrderModel{DB: db},
+		PromoCodes:         PromoCodeModel{DB: db},
+		CheckIns:           CheckInModel{DB: db},
+		Imports:            ImportModel{DB: db},
+		EmailVerifications: EmailVerificationModel{DB: db},
+		LoginHistory:       LoginHistoryModel{DB: db},
+		DataExports:        DataExportModel{DB: db},
+		Audit:              AuditModel{DB: db},
+		Sessions:           SessionModel{DB: db},
+		Collaborators:      CollaboratorModel{DB: db},
+		Invites:            InviteModel{DB: db},
 	}
 }
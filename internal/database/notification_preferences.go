@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type NotificationPreferenceModel struct {
+	DB *sql.DB
+}
+
+// Types of notifications users choose channels for.
+const (
+	NotifyAnnouncement = "announcement"
+	// NotifyAttendeeAdded tells users they attend an event, whether an
+	// organizer added them, their join request was approved or they paid.
+	NotifyAttendeeAdded  = "attendee_added"
+	NotifyEventUpdated   = "event_updated"
+	NotifyEventCancelled = "event_cancelled"
+	NotifyReminder       = "reminder"
+	// NotifyJoinRequest tells applicants about decisions on join requests
+	// that did not make them attendees.
+	NotifyJoinRequest   = "join_request"
+	NotifyOrderRefunded = "order_refunded"
+	NotifyInvite        = "invite"
+	// NotifyCollaboration tells users they are invited to organize an event
+	// or offered its ownership.
+	NotifyCollaboration   = "collaboration"
+	NotifyFeedbackRequest = "feedback_request"
+)
+
+// Channels notifications are delivered through.
+const (
+	// ChannelInApp keeps notifications in the notification center of the
+	// user.
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	// ChannelWebhook posts notifications to the webhook URL in the
+	// NotificationSettings of the user, if there is one.
+	ChannelWebhook = "webhook"
+)
+
+var (
+	NotificationTypes = []string{
+		NotifyAnnouncement, NotifyAttendeeAdded, NotifyEventUpdated, NotifyEventCancelled,
+		NotifyReminder, NotifyJoinRequest, NotifyOrderRefunded, NotifyInvite, NotifyCollaboration,
+		NotifyFeedbackRequest,
+	}
+	NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelWebhook}
+)
+
+// NotificationPreferences maps notification types to whether each channel is
+// enabled for them. Every channel is enabled unless the user turned it off.
+type NotificationPreferences map[string]map[string]bool
+
+// Validate checks that the preferences only name known types and channels.
+func (p NotificationPreferences) Validate() error {
+	for typ, channels := range p {
+		if !slices.Contains(NotificationTypes, typ) {
+			return fmt.Errorf("unknown notification type %q", typ)
+		}
+		for channel := range channels {
+			if !slices.Contains(NotificationChannels, channel) {
+				return fmt.Errorf("unknown notification channel %q", channel)
+			}
+		}
+	}
+	return nil
+}
+
+// Channels returns the channels enabled for typ.
+func (p NotificationPreferences) Channels(typ string) []string {
+	channels := []string{}
+	for _, channel := range NotificationChannels {
+		if p[typ][channel] {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// defaultPreferences enables every channel for every type.
+func defaultPreferences() NotificationPreferences {
+	p := NotificationPreferences{}
+	for _, typ := range NotificationTypes {
+		p[typ] = map[string]bool{}
+		for _, channel := range NotificationChannels {
+			p[typ][channel] = true
+		}
+	}
+	return p
+}
+
+// Get returns the notification preferences of a user, with the defaults for
+// the ones they never chose.
+func (pm *NotificationPreferenceModel) Get(userId int) (NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT type, channel, enabled FROM notification_preferences WHERE user_id = $1`
+
+	rows, err := pm.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p := defaultPreferences()
+
+	for rows.Next() {
+		var typ, channel string
+		var enabled bool
+		if err := rows.Scan(&typ, &channel, &enabled); err != nil {
+			return nil, err
+		}
+		if p[typ] != nil && slices.Contains(NotificationChannels, channel) {
+			p[typ][channel] = enabled
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Set stores the given preferences of a user and leaves the others as they
+// were.
+func (pm *NotificationPreferenceModel) Set(userId int, p NotificationPreferences) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := pm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO notification_preferences (user_id, type, channel, enabled)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (user_id, type, channel) DO UPDATE SET enabled = excluded.enabled`
+
+	for typ, channels := range p {
+		for channel, enabled := range channels {
+			if _, err := tx.ExecContext(ctx, query, userId, typ, channel, enabled); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// NotificationSettings holds the settings of a user that apply to every type
+// of notification.
+type NotificationSettings struct {
+	// WebhookURL receives the notifications sent through ChannelWebhook,
+	// signed with WebhookSecret.
+	WebhookURL    string `json:"webhookUrl" binding:"omitempty,max=2000"`
+	WebhookSecret string `json:"webhookSecret,omitempty" binding:"-"`
+	// Digest collects email notifications into one email a day.
+	Digest       bool       `json:"digest"`
+	DigestSentAt *time.Time `json:"digestSentAt" binding:"-"`
+}
+
+// Validate checks that the webhook URL, if any, is an absolute HTTP URL that
+// does not name a local or private host. Names resolving to such addresses are
+// refused when the webhook is called.
+func (s *NotificationSettings) Validate() error {
+	if s.WebhookURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(s.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhookUrl must be an http or https URL")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("webhookUrl must not point to a local or private address")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !WebhookAddrAllowed(addr) {
+		return fmt.Errorf("webhookUrl must not point to a local or private address")
+	}
+	return nil
+}
+
+// reservedPrefixes are the ranges webhooks may not be sent to besides the
+// loopback, private, link-local and multicast ones.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// WebhookAddrAllowed reports whether webhooks may be sent to addr, which must
+// be a public unicast address.
+func WebhookAddrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSettings returns the notification settings of a user, or the defaults if
+// they never changed them.
+func (pm *NotificationPreferenceModel) GetSettings(userId int) (*NotificationSettings, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT webhook_url, webhook_secret, digest, digest_sent_at
+			  FROM notification_settings WHERE user_id = $1`
+
+	var s NotificationSettings
+	var sentAt sql.NullTime
+
+	err := pm.DB.QueryRowContext(ctx, query, userId).
+		Scan(&s.WebhookURL, &s.WebhookSecret, &s.Digest, &sentAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if sentAt.Valid {
+		s.DigestSentAt = &sentAt.Time
+	}
+	return &s, nil
+}
+
+// SetSettings stores the webhook URL and digest choice of a user. A new
+// webhook secret is generated whenever the webhook URL changes.
+func (pm *NotificationPreferenceModel) SetSettings(userId int, s *NotificationSettings) error {
+	current, err := pm.GetSettings(userId)
+	if err != nil {
+		return err
+	}
+
+	s.WebhookSecret = current.WebhookSecret
+	if s.WebhookURL == "" {
+		s.WebhookSecret = ""
+	} else if s.WebhookURL != current.WebhookURL || s.WebhookSecret == "" {
+		if s.WebhookSecret, err = generateToken(); err != nil {
+			return err
+		}
+	}
+	s.DigestSentAt = current.DigestSentAt
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO notification_settings (user_id, webhook_url, webhook_secret, digest)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (user_id) DO UPDATE SET webhook_url = excluded.webhook_url,
+			  webhook_secret = excluded.webhook_secret, digest = excluded.digest`
+
+	_, err = pm.DB.ExecContext(ctx, query, userId, s.WebhookURL, s.WebhookSecret, s.Digest)
+	return err
+}
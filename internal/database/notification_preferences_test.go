@@ -0,0 +1,36 @@
+package database
+
+import "testing"
+
+func TestNotificationSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"no webhook", "", false},
+		{"public host", "https://hooks.example.com/notify", false},
+		{"public address", "http://93.184.216.34:8080/notify", false},
+		{"not http", "ftp://hooks.example.com/notify", true},
+		{"relative", "/notify", true},
+		{"localhost", "http://localhost:8080/notify", true},
+		{"localhost subdomain", "http://api.localhost./notify", true},
+		{"loopback", "http://127.0.0.1/notify", true},
+		{"ipv6 loopback", "http://[::1]/notify", true},
+		{"mapped loopback", "http://[::ffff:127.0.0.1]/notify", true},
+		{"private", "http://10.0.0.5/notify", true},
+		{"link-local", "http://169.254.169.254/latest/meta-data", true},
+		{"ipv6 link-local", "http://[fe80::1]/notify", true},
+		{"unspecified", "http://0.0.0.0/notify", true},
+		{"shared address space", "http://100.64.0.1/notify", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&NotificationSettings{WebhookURL: tt.url}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
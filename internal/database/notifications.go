@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+// Notification tells a user about something that happened to an event. It is
+// kept in their notification center if they enabled ChannelInApp for its
+// type.
+type Notification struct {
+	// Id is only set for notifications kept in the notification center.
+	Id        int        `json:"id,omitempty"`
+	UserId    int        `json:"userId"`
+	Type      string     `json:"type"`
+	EventId   int        `json:"eventId"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ReadAt    *time.Time `json:"readAt"`
+}
+
+// Digest holds the email notifications of a user that were collected since
+// their last digest, oldest first.
+type Digest struct {
+	UserId        int             `json:"userId"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+const notificationColumns = `id, user_id, type, event_id, title, body, created_at, read_at`
+
+func scanNotification(s scanner, n *Notification) error {
+	var readAt sql.NullTime
+
+	err := s.Scan(&n.Id, &n.UserId, &n.Type, &n.EventId, &n.Title, &n.Body, &n.CreatedAt, &readAt)
+	if err != nil {
+		return err
+	}
+
+	n.ReadAt = nil
+	if readAt.Valid {
+		n.ReadAt = &readAt.Time
+	}
+	return nil
+}
+
+// Insert keeps a notification in the notification center of its user.
+func (nm *NotificationModel) Insert(n *Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO notifications (user_id, type, event_id, title, body, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	return nm.DB.QueryRowContext(ctx, query, n.UserId, n.Type, n.EventId, n.Title, n.Body,
+		n.CreatedAt).Scan(&n.Id)
+}
+
+// GetByUser returns up to limit notifications of a user that came before the
+// notification before, newest first. With unread set, read notifications are
+// left out.
+func (nm *NotificationModel) GetByUser(userId int, unread bool, before, limit int) ([]*Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1
+			  AND (NOT $2 OR read_at IS NULL) AND ($3 = 0 OR id < $3)
+			  ORDER BY id DESC LIMIT $4`
+	return nm.getNotifications(query, userId, unread, before, limit)
+}
+
+// GetAllByUser returns every notification of a user, oldest first.
+func (nm *NotificationModel) GetAllByUser(userId int) ([]*Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1
+			  ORDER BY id`
+	return nm.getNotifications(query, userId)
+}
+
+// CountUnread counts the notifications of a user that were not read yet.
+func (nm *NotificationModel) CountUnread(userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+
+	var n int
+	err := nm.DB.QueryRowContext(ctx, query, userId).Scan(&n)
+	return n, err
+}
+
+// MarkRead marks a notification of a user as read and returns it, or nil if
+// the user has no such notification. Reading it again keeps the time it was
+// first read.
+func (nm *NotificationModel) MarkRead(userId, id int) (*Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, $1)
+			  WHERE id = $2 AND user_id = $3 RETURNING ` + notificationColumns
+
+	var n Notification
+	err := scanNotification(nm.DB.QueryRowContext(ctx, query, time.Now().UTC(), id, userId), &n)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// MarkAllRead marks every notification of a user as read and returns how many
+// were unread.
+func (nm *NotificationModel) MarkAllRead(userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL`
+
+	result, err := nm.DB.ExecContext(ctx, query, time.Now().UTC(), userId)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// QueueDigest keeps an email notification for the next digest of its user.
+func (nm *NotificationModel) QueueDigest(n *Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO notification_digest_items (user_id, type, event_id, title, body,
+			  created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := nm.DB.ExecContext(ctx, query, n.UserId, n.Type, n.EventId, n.Title, n.Body,
+		n.CreatedAt)
+	return err
+}
+
+// DueDigests returns the collected notifications of the users whose last
+// digest went out before sentBefore, or whose oldest notification was
+// collected before it if they never had one. They stay collected until
+// MarkDigestSent records that their digest was sent.
+func (nm *NotificationModel) DueDigests(sentBefore time.Time) ([]*Digest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT d.id, d.user_id, d.type, d.event_id, d.title, d.body, d.created_at, NULL
+			  FROM notification_digest_items d
+			  LEFT JOIN notification_settings s ON s.user_id = d.user_id
+			  WHERE COALESCE(s.digest_sent_at, (SELECT MIN(created_at)
+			  FROM notification_digest_items m WHERE m.user_id = d.user_id)) <= $1
+			  ORDER BY d.user_id, d.id`
+
+	rows, err := nm.DB.QueryContext(ctx, query, sentBefore.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	digests := []*Digest{}
+
+	for rows.Next() {
+		var n Notification
+		if err := scanNotification(rows, &n); err != nil {
+			return nil, err
+		}
+		if len(digests) == 0 || digests[len(digests)-1].UserId != n.UserId {
+			digests = append(digests, &Digest{UserId: n.UserId})
+		}
+		d := digests[len(digests)-1]
+		d.Notifications = append(d.Notifications, &n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return digests, nil
+}
+
+// MarkDigestSent removes the notifications of a digest from the queue and
+// records when it was sent. Notifications collected since it was put together
+// wait for the next one.
+func (nm *NotificationModel) MarkDigestSent(d *Digest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := nm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	last := d.Notifications[len(d.Notifications)-1].Id
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM notification_digest_items WHERE user_id = $1 AND id <= $2`, d.UserId, last)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO notification_settings (user_id, digest_sent_at)
+		VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET digest_sent_at = excluded.digest_sent_at`,
+		d.UserId, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClaimReminders marks the events that start before startsBefore and whose
+// attendees were not reminded yet as reminded, and returns them. Only
+// published events that did not start yet are included.
+func (nm *NotificationModel) ClaimReminders(startsBefore time.Time) ([]*Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := nm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + eventColumns + ` FROM events e
+			  WHERE e.reminded_at IS NULL AND e.deleted_at IS NULL
+			  AND e.status = $1 AND e.starts_at > $2 AND e.starts_at <= $3`
+
+	rows, err := tx.QueryContext(ctx, query, EventPublished, time.Now().UTC(), startsBefore.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+
+	for rows.Next() {
+		var e Event
+		if err := scanEvent(rows, &e); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	now := time.Now().UTC()
+	for _, e := range events {
+		_, err := tx.ExecContext(ctx, `UPDATE events SET reminded_at = $1 WHERE id = $2`, now, e.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return events, tx.Commit()
+}
+
+func (nm *NotificationModel) getNotifications(query string, args ...any) ([]*Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := nm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+
+	for rows.Next() {
+		var n Notification
+		if err := scanNotification(rows, &n); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
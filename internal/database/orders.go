@@ -0,0 +1,454 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type OrderModel struct {
+	DB *sql.DB
+}
+
+const (
+	OrderPending  = "pending"
+	OrderPaid     = "paid"
+	OrderExpired  = "expired"
+	OrderRefunded = "refunded"
+)
+
+var (
+	ErrOrderPending      = errors.New("user already has a pending order for the event")
+	ErrOrderStateChanged = errors.New("order is no longer in the expected status")
+	ErrAlreadyAttending  = errors.New("user already attends the event")
+)
+
+// Order is the purchase of paid tickets. While pending it holds its tickets
+// until ExpiresAt. Paying it issues the Attendee.
+type Order struct {
+	Id           int `json:"id"`
+	EventId      int `json:"eventId"`
+	UserId       int `json:"userId"`
+	TicketTypeId int `json:"ticketTypeId"`
+	Quantity     int `json:"quantity"`
+	// Amount is the total price after Discount, in the minor unit of Currency.
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Status   string `json:"status"`
+	// PromoCodeId is the promo code redeemed on the order and Discount the
+	// amount it took off.
+	PromoCodeId *int  `json:"promoCodeId,omitempty"`
+	Discount    int64 `json:"discount"`
+	// Provider is the payment provider that handles the order, SessionId and
+	// PaymentId are its references for the checkout and the payment.
+	Provider    string `json:"provider"`
+	SessionId   string `json:"-"`
+	CheckoutURL string `json:"checkoutUrl,omitempty"`
+	PaymentId   string `json:"-"`
+	AttendeeId  *int   `json:"attendeeId,omitempty"`
+	// Answers are the buyer's answers to the event's registration form, which
+	// the attendee takes over once the order is paid.
+	Answers Answers `json:"answers"`
+
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	PaidAt     *time.Time `json:"paidAt,omitempty"`
+	RefundedAt *time.Time `json:"refundedAt,omitempty"`
+}
+
+const orderColumns = `id, event_id, user_id, ticket_type_id, quantity, amount, currency, status,
+	provider, session_id, checkout_url, payment_id, attendee_id, promo_code_id, discount, answers,
+	expires_at, created_at, paid_at, refunded_at`
+
+func scanOrder(s scanner, o *Order) error {
+	var attendeeId, promoCodeId sql.NullInt64
+	var answers string
+	var paidAt, refundedAt sql.NullTime
+
+	err := s.Scan(&o.Id, &o.EventId, &o.UserId, &o.TicketTypeId, &o.Quantity, &o.Amount,
+		&o.Currency, &o.Status, &o.Provider, &o.SessionId, &o.CheckoutURL, &o.PaymentId,
+		&attendeeId, &promoCodeId, &o.Discount, &answers, &o.ExpiresAt, &o.CreatedAt, &paidAt,
+		&refundedAt)
+	if err != nil {
+		return err
+	}
+
+	if attendeeId.Valid {
+		id := int(attendeeId.Int64)
+		o.AttendeeId = &id
+	}
+	if promoCodeId.Valid {
+		id := int(promoCodeId.Int64)
+		o.PromoCodeId = &id
+	}
+	if paidAt.Valid {
+		o.PaidAt = &paidAt.Time
+	}
+	if refundedAt.Valid {
+		o.RefundedAt = &refundedAt.Time
+	}
+
+	o.Answers, err = decodeAnswers(answers)
+	return err
+}
+
+// Insert records a pending order and holds its tickets and promo code use. It
+// returns ErrSoldOut if not enough tickets are left, ErrOrderPending if the
+// user already has a pending order for the event, and ErrPromoCodeExhausted or
+// ErrPromoCodeUserLimit if the promo code cannot be redeemed anymore.
+func (om *OrderModel) Insert(o *Order) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := om.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reserveTickets(ctx, tx, o.TicketTypeId, o.Quantity); err != nil {
+		return err
+	}
+
+	o.Status = OrderPending
+	o.CreatedAt = time.Now().UTC()
+	o.ExpiresAt = o.ExpiresAt.UTC()
+	if o.Answers == nil {
+		o.Answers = Answers{}
+	}
+
+	answers, err := encodeAnswers(o.Answers)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO orders (event_id, user_id, ticket_type_id, quantity, amount, currency,
+			  status, provider, promo_code_id, discount, answers, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			  ON CONFLICT DO NOTHING RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, o.EventId, o.UserId, o.TicketTypeId, o.Quantity,
+		o.Amount, o.Currency, o.Status, o.Provider, o.PromoCodeId, o.Discount, answers,
+		o.ExpiresAt, o.CreatedAt).Scan(&o.Id)
+	if err == sql.ErrNoRows {
+		return ErrOrderPending
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only one pending order per user and event exists, so the per-user cap
+	// cannot be raced past.
+	if o.PromoCodeId != nil {
+		if err := redeemPromoCode(ctx, tx, o); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetCheckout stores the provider's checkout of a pending order.
+func (om *OrderModel) SetCheckout(o *Order) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := om.DB.ExecContext(ctx,
+		`UPDATE orders SET session_id = $1, checkout_url = $2 WHERE id = $3`,
+		o.SessionId, o.CheckoutURL, o.Id)
+	return err
+}
+
+func (om *OrderModel) Get(id int) (*Order, error) {
+	return om.getOrder(`id = $1`, id)
+}
+
+// GetBySession returns the order a provider's checkout belongs to.
+func (om *OrderModel) GetBySession(provider, sessionId string) (*Order, error) {
+	return om.getOrder(`provider = $1 AND session_id = $2`, provider, sessionId)
+}
+
+// GetByPayment returns the order a provider's payment belongs to.
+func (om *OrderModel) GetByPayment(provider, paymentId string) (*Order, error) {
+	return om.getOrder(`provider = $1 AND payment_id = $2`, provider, paymentId)
+}
+
+// GetByEvent returns the orders of an event, newest first. An empty status
+// returns orders in every status.
+func (om *OrderModel) GetByEvent(eventId int, status string) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE event_id = $1 AND ($2 = '' OR status = $2) ORDER BY created_at DESC`
+	return om.getOrders(query, eventId, status)
+}
+
+// GetByUser returns the orders a user has placed, newest first.
+func (om *OrderModel) GetByUser(userId int) ([]*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE user_id = $1 ORDER BY created_at DESC`
+	return om.getOrders(query, userId)
+}
+
+// MarkPaid records the payment of an order and issues its attendee. Orders
+// that expired before the payment arrived take their tickets again, and
+// ErrSoldOut is returned if they are gone by then. It returns
+// ErrAlreadyAttending if the user was added to the event in the meantime and
+// ErrOrderStateChanged if the order is neither pending nor expired anymore.
+func (om *OrderModel) MarkPaid(o *Order, paymentId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := om.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if o.Status == OrderExpired {
+		if err := reserveTickets(ctx, tx, o.TicketTypeId, o.Quantity); err != nil {
+			return err
+		}
+		// The buyer paid the discounted amount, so the redemption is honoured
+		// even if the code was used up since.
+		if o.PromoCodeId != nil {
+			if err := restorePromoRedemption(ctx, tx, o); err != nil {
+				return err
+			}
+		}
+	}
+
+	attendee := Attendee{
+		EventId:      o.EventId,
+		UserId:       o.UserId,
+		TicketTypeId: &o.TicketTypeId,
+		Quantity:     o.Quantity,
+		Answers:      o.Answers,
+	}
+	var attending bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM attendees
+		WHERE event_id = $1 AND user_id = $2)`, o.EventId, o.UserId).Scan(&attending)
+	if err != nil {
+		return err
+	}
+	if attending {
+		return ErrAlreadyAttending
+	}
+
+	if err := insertAttendeeRow(ctx, tx, &attendee); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `UPDATE orders
+		SET status = $1, payment_id = $2, attendee_id = $3, paid_at = $4
+		WHERE id = $5 AND status = $6`,
+		OrderPaid, paymentId, attendee.Id, now, o.Id, o.Status)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrOrderStateChanged
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE promo_redemptions SET attendee_id = $1
+		WHERE order_id = $2`, attendee.Id, o.Id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	o.Status = OrderPaid
+	o.PaymentId = paymentId
+	o.AttendeeId = &attendee.Id
+	o.PaidAt = &now
+	return nil
+}
+
+// Expire ends a pending order and releases its tickets. It returns
+// ErrOrderStateChanged if the order is no longer pending.
+func (om *OrderModel) Expire(o *Order) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := om.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := expireOrder(ctx, tx, o.Id, o.TicketTypeId, o.Quantity); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	o.Status = OrderExpired
+	return nil
+}
+
+// ExpireDue expires every pending order whose hold ended before now and
+// returns how many were expired.
+func (om *OrderModel) ExpireDue(now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := om.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, ticket_type_id, quantity FROM orders
+		WHERE status = $1 AND expires_at <= $2`, OrderPending, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	var due []Order
+	for rows.Next() {
+		var o Order
+		if err := rows.Scan(&o.Id, &o.TicketTypeId, &o.Quantity); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, o := range due {
+		if err := expireOrder(ctx, tx, o.Id, o.TicketTypeId, o.Quantity); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), tx.Commit()
+}
+
+// Refund records that an order was paid back and gives back whatever it still
+// holds: its promo code use, and the tickets of a pending order or the
+// attendee of a paid one and with it their tickets. Pending and expired
+// orders are refunded when their payment arrives but cannot be honoured. It
+// returns ErrOrderStateChanged if the order was already refunded or changed
+// status concurrently.
+func (om *OrderModel) Refund(o *Order) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := om.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `UPDATE orders
+		SET status = $1, payment_id = $2, refunded_at = $3
+		WHERE id = $4 AND status = $5`,
+		OrderRefunded, o.PaymentId, now, o.Id, o.Status)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrOrderStateChanged
+	}
+
+	switch {
+	case o.Status == OrderPending:
+		err = releaseTickets(ctx, tx, o.TicketTypeId, o.Quantity)
+	case o.Status == OrderPaid && o.AttendeeId != nil:
+		err = deleteAttendee(ctx, tx, `id = $1`, *o.AttendeeId)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := releasePromoCode(ctx, tx, o.Id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	o.Status = OrderRefunded
+	o.RefundedAt = &now
+	return nil
+}
+
+// expireOrder moves a pending order to expired within tx and releases its
+// tickets and promo code use.
+func expireOrder(ctx context.Context, tx *sql.Tx, id, ticketTypeId, quantity int) error {
+	result, err := tx.ExecContext(ctx,
+		`UPDATE orders SET status = $1 WHERE id = $2 AND status = $3`,
+		OrderExpired, id, OrderPending)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrOrderStateChanged
+	}
+
+	if err := releasePromoCode(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return releaseTickets(ctx, tx, ticketTypeId, quantity)
+}
+
+func (om *OrderModel) getOrder(where string, args ...any) (*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE ` + where
+
+	var o Order
+	err := scanOrder(om.DB.QueryRowContext(ctx, query, args...), &o)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func (om *OrderModel) getOrders(query string, args ...any) ([]*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := om.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*Order{}
+
+	for rows.Next() {
+		var o Order
+		if err := scanOrder(rows, &o); err != nil {
+			return nil, err
+		}
+		orders = append(orders, &o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,500 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+)
+
+type PromoCodeModel struct {
+	DB *sql.DB
+}
+
+const (
+	PromoPercent = "percent"
+	PromoFixed   = "fixed"
+)
+
+var (
+	ErrPromoCodeExists        = errors.New("promo code already exists for the event")
+	ErrPromoCodeInUse         = errors.New("promo code has been redeemed")
+	ErrPromoCodeNotStarted    = errors.New("promo code is not valid yet")
+	ErrPromoCodeExpired       = errors.New("promo code has expired")
+	ErrPromoCodeExhausted     = errors.New("promo code has been used up")
+	ErrPromoCodeUserLimit     = errors.New("promo code has been used up by the user")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply to the ticket type")
+)
+
+// PromoCode takes a discount off orders for an event.
+type PromoCode struct {
+	Id      int    `json:"id"`
+	EventId int    `json:"eventId"`
+	Code    string `json:"code" binding:"required,min=3,max=32"`
+	Kind    string `json:"kind" binding:"required,oneof=percent fixed"`
+	// Value is the percentage taken off for percent codes, and the amount
+	// taken off each order in the minor unit of Currency for fixed codes.
+	Value    int64  `json:"value" binding:"required,min=1"`
+	Currency string `json:"currency,omitempty" binding:"required_if=Kind fixed,omitempty,iso4217"`
+	// TicketTypeIds limits the code to these ticket types. Empty means every
+	// ticket type of the event.
+	TicketTypeIds []int `json:"ticketTypeIds"`
+	// MaxUses and MaxUsesPerUser cap the redemptions overall and per user.
+	// Zero means no cap. Uses counts pending orders too.
+	MaxUses        int        `json:"maxUses" binding:"min=0"`
+	MaxUsesPerUser int        `json:"maxUsesPerUser" binding:"min=0"`
+	Uses           int        `json:"uses" binding:"-"`
+	StartsAt       *time.Time `json:"startsAt,omitempty"`
+	EndsAt         *time.Time `json:"endsAt,omitempty"`
+	CreatedBy      int        `json:"createdBy"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// PromoRedemption records a promo code used on an order. AttendeeId is set
+// once the order is paid. ReleasedAt is set when the order expired or was
+// refunded and gave the use back; released redemptions are kept for the
+// record but no longer count toward the caps of the code.
+type PromoRedemption struct {
+	Id          int        `json:"id"`
+	PromoCodeId int        `json:"promoCodeId"`
+	Code        string     `json:"code"`
+	UserId      int        `json:"userId"`
+	OrderId     int        `json:"orderId"`
+	OrderStatus string     `json:"orderStatus"`
+	AttendeeId  *int       `json:"attendeeId,omitempty"`
+	Discount    int64      `json:"discount"`
+	Currency    string     `json:"currency"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ReleasedAt  *time.Time `json:"releasedAt,omitempty"`
+}
+
+// Check returns why the code cannot be used on tickets of t at now, or nil if
+// it can. The per-user cap is only checked when the code is redeemed.
+func (p *PromoCode) Check(t *TicketType, now time.Time) error {
+	switch {
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return ErrPromoCodeNotStarted
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return ErrPromoCodeExpired
+	case p.MaxUses > 0 && p.Uses >= p.MaxUses:
+		return ErrPromoCodeExhausted
+	case len(p.TicketTypeIds) > 0 && !slices.Contains(p.TicketTypeIds, t.Id):
+		return ErrPromoCodeNotApplicable
+	case p.Kind == PromoFixed && p.Currency != t.Currency:
+		return ErrPromoCodeNotApplicable
+	}
+
+	return nil
+}
+
+// Discount returns how much the code takes off an order of amount.
+func (p *PromoCode) Discount(amount int64) int64 {
+	if p.Kind == PromoPercent {
+		return amount * min(p.Value, 100) / 100
+	}
+	return min(p.Value, amount)
+}
+
+const promoCodeColumns = `id, event_id, code, kind, value, currency, max_uses, max_uses_per_user,
+	uses, starts_at, ends_at, created_by, created_at`
+
+func scanPromoCode(s scanner, p *PromoCode) error {
+	var startsAt, endsAt sql.NullTime
+
+	err := s.Scan(&p.Id, &p.EventId, &p.Code, &p.Kind, &p.Value, &p.Currency, &p.MaxUses,
+		&p.MaxUsesPerUser, &p.Uses, &startsAt, &endsAt, &p.CreatedBy, &p.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	p.StartsAt, p.EndsAt = nil, nil
+	if startsAt.Valid {
+		p.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		p.EndsAt = &endsAt.Time
+	}
+	return nil
+}
+
+// normalize stores times in UTC and drops the currency of percent codes.
+func (p *PromoCode) normalize() {
+	if p.Kind == PromoPercent {
+		p.Currency = ""
+	}
+	if p.TicketTypeIds == nil {
+		p.TicketTypeIds = []int{}
+	}
+	if p.StartsAt != nil {
+		start := p.StartsAt.UTC()
+		p.StartsAt = &start
+	}
+	if p.EndsAt != nil {
+		end := p.EndsAt.UTC()
+		p.EndsAt = &end
+	}
+}
+
+// Insert creates a promo code. It returns ErrPromoCodeExists if the event
+// already has a code with that name.
+func (pm *PromoCodeModel) Insert(p *PromoCode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := pm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p.normalize()
+	p.Uses = 0
+	p.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO promo_codes (event_id, code, kind, value, currency, max_uses,
+			  max_uses_per_user, starts_at, ends_at, created_by, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			  ON CONFLICT (event_id, code) DO NOTHING RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, p.EventId, p.Code, p.Kind, p.Value, p.Currency,
+		p.MaxUses, p.MaxUsesPerUser, p.StartsAt, p.EndsAt, p.CreatedBy, p.CreatedAt).Scan(&p.Id)
+	if err == sql.ErrNoRows {
+		return ErrPromoCodeExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := setPromoTicketTypes(ctx, tx, p); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (pm *PromoCodeModel) Get(id int) (*PromoCode, error) {
+	return pm.getPromoCode(`id = $1`, id)
+}
+
+// GetByCode looks a code up by name. Names are stored upper case.
+func (pm *PromoCodeModel) GetByCode(eventId int, code string) (*PromoCode, error) {
+	return pm.getPromoCode(`event_id = $1 AND code = UPPER($2)`, eventId, code)
+}
+
+// GetByEvent returns the promo codes of an event in the order they were made.
+func (pm *PromoCodeModel) GetByEvent(eventId int) ([]*PromoCode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE event_id = $1 ORDER BY id`
+
+	rows, err := pm.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []*PromoCode{}
+	byId := map[int]*PromoCode{}
+
+	for rows.Next() {
+		var p PromoCode
+		if err := scanPromoCode(rows, &p); err != nil {
+			return nil, err
+		}
+		p.TicketTypeIds = []int{}
+		codes = append(codes, &p)
+		byId[p.Id] = &p
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	links, err := pm.DB.QueryContext(ctx, `SELECT promo_code_id, ticket_type_id
+		FROM promo_code_ticket_types WHERE event_id = $1 ORDER BY ticket_type_id`, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer links.Close()
+
+	for links.Next() {
+		var codeId, ticketTypeId int
+		if err := links.Scan(&codeId, &ticketTypeId); err != nil {
+			return nil, err
+		}
+		if p, ok := byId[codeId]; ok {
+			p.TicketTypeIds = append(p.TicketTypeIds, ticketTypeId)
+		}
+	}
+
+	if err = links.Err(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Update changes a promo code, keeping its uses. It returns
+// ErrPromoCodeExists if the new name is taken by another code of the event.
+func (pm *PromoCodeModel) Update(p *PromoCode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := pm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p.normalize()
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM promo_codes
+		WHERE event_id = $1 AND code = $2 AND id != $3)`, p.EventId, p.Code, p.Id).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrPromoCodeExists
+	}
+
+	query := `UPDATE promo_codes SET code = $1, kind = $2, value = $3, currency = $4,
+			  max_uses = $5, max_uses_per_user = $6, starts_at = $7, ends_at = $8
+			  WHERE id = $9 RETURNING uses, created_by, created_at`
+
+	err = tx.QueryRowContext(ctx, query, p.Code, p.Kind, p.Value, p.Currency, p.MaxUses,
+		p.MaxUsesPerUser, p.StartsAt, p.EndsAt, p.Id).Scan(&p.Uses, &p.CreatedBy, &p.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM promo_code_ticket_types WHERE promo_code_id = $1`, p.Id); err != nil {
+		return err
+	}
+	if err := setPromoTicketTypes(ctx, tx, p); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a promo code that was never redeemed. It returns
+// ErrPromoCodeInUse otherwise; such codes can be ended instead.
+func (pm *PromoCodeModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := pm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM promo_codes WHERE id = $1 AND uses = 0
+		AND NOT EXISTS (SELECT 1 FROM promo_redemptions WHERE promo_code_id = $1)`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPromoCodeInUse
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM promo_code_ticket_types WHERE promo_code_id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountRedemptions returns how often a user has redeemed a code, leaving out
+// released redemptions.
+func (pm *PromoCodeModel) CountRedemptions(promoCodeId, userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var n int
+	err := pm.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM promo_redemptions
+		WHERE promo_code_id = $1 AND user_id = $2 AND released_at IS NULL`,
+		promoCodeId, userId).Scan(&n)
+	return n, err
+}
+
+// GetRedemptions returns the redemptions of every promo code of an event,
+// newest first.
+func (pm *PromoCodeModel) GetRedemptions(eventId int) ([]*PromoRedemption, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT r.id, r.promo_code_id, p.code, r.user_id, r.order_id, o.status,
+			  r.attendee_id, r.discount, r.currency, r.created_at, r.released_at
+			  FROM promo_redemptions r
+			  JOIN promo_codes p ON p.id = r.promo_code_id
+			  JOIN orders o ON o.id = r.order_id
+			  WHERE r.event_id = $1 ORDER BY r.created_at DESC`
+
+	rows, err := pm.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []*PromoRedemption{}
+
+	for rows.Next() {
+		var r PromoRedemption
+		var attendeeId sql.NullInt64
+		var releasedAt sql.NullTime
+
+		err := rows.Scan(&r.Id, &r.PromoCodeId, &r.Code, &r.UserId, &r.OrderId, &r.OrderStatus,
+			&attendeeId, &r.Discount, &r.Currency, &r.CreatedAt, &releasedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if attendeeId.Valid {
+			id := int(attendeeId.Int64)
+			r.AttendeeId = &id
+		}
+		if releasedAt.Valid {
+			r.ReleasedAt = &releasedAt.Time
+		}
+		redemptions = append(redemptions, &r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return redemptions, nil
+}
+
+func (pm *PromoCodeModel) getPromoCode(where string, args ...any) (*PromoCode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE ` + where
+
+	var p PromoCode
+	err := scanPromoCode(pm.DB.QueryRowContext(ctx, query, args...), &p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := pm.DB.QueryContext(ctx, `SELECT ticket_type_id FROM promo_code_ticket_types
+		WHERE promo_code_id = $1 ORDER BY ticket_type_id`, p.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.TicketTypeIds = []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		p.TicketTypeIds = append(p.TicketTypeIds, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func setPromoTicketTypes(ctx context.Context, tx *sql.Tx, p *PromoCode) error {
+	for _, ticketTypeId := range p.TicketTypeIds {
+		_, err := tx.ExecContext(ctx, `INSERT INTO promo_code_ticket_types
+			(promo_code_id, ticket_type_id, event_id) VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`, p.Id, ticketTypeId, p.EventId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redeemPromoCode records the promo code of an order within tx, enforcing its
+// caps. It returns ErrPromoCodeUserLimit if the user has used up their share
+// and ErrPromoCodeExhausted if the code is used up.
+func redeemPromoCode(ctx context.Context, tx *sql.Tx, o *Order) error {
+	var used, perUser int
+	err := tx.QueryRowContext(ctx, `SELECT max_uses_per_user FROM promo_codes WHERE id = $1`,
+		*o.PromoCodeId).Scan(&perUser)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM promo_redemptions
+		WHERE promo_code_id = $1 AND user_id = $2 AND released_at IS NULL`,
+		*o.PromoCodeId, o.UserId).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if perUser > 0 && used >= perUser {
+		return ErrPromoCodeUserLimit
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE promo_codes SET uses = uses + 1
+		WHERE id = $1 AND (max_uses = 0 OR uses < max_uses)`, *o.PromoCodeId)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPromoCodeExhausted
+	}
+
+	return insertPromoRedemption(ctx, tx, o)
+}
+
+// restorePromoRedemption records the promo code of an order again without
+// enforcing its caps, for expired orders whose discounted payment arrived.
+func restorePromoRedemption(ctx context.Context, tx *sql.Tx, o *Order) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE promo_codes SET uses = uses + 1 WHERE id = $1`, *o.PromoCodeId)
+	if err != nil {
+		return err
+	}
+
+	return insertPromoRedemption(ctx, tx, o)
+}
+
+// insertPromoRedemption records the promo code of an order, taking back a
+// redemption the order released before.
+func insertPromoRedemption(ctx context.Context, tx *sql.Tx, o *Order) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO promo_redemptions
+		(promo_code_id, event_id, user_id, order_id, discount, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (order_id) DO UPDATE SET released_at = NULL`,
+		*o.PromoCodeId, o.EventId, o.UserId, o.Id, o.Discount, o.Currency, time.Now().UTC())
+	return err
+}
+
+// releasePromoCode gives back the promo code use of an order, if it had one,
+// and marks its redemption as released.
+func releasePromoCode(ctx context.Context, tx *sql.Tx, orderId int) error {
+	var promoCodeId int
+	err := tx.QueryRowContext(ctx, `UPDATE promo_redemptions SET released_at = $1
+		WHERE order_id = $2 AND released_at IS NULL RETURNING promo_code_id`,
+		time.Now().UTC(), orderId).Scan(&promoCodeId)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE promo_codes SET uses = MAX(uses - 1, 0) WHERE id = $1`, promoCodeId)
+	return err
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRedeemPromoCode(t *testing.T) {
+	db := newTestDB(t)
+	ownerId, eventId := insertTestEvent(t, db)
+	alice := insertTestUser(t, db, "alice@example.com")
+	bob := insertTestUser(t, db, "bob@example.com")
+
+	type redemption struct {
+		userId  int
+		wantErr error
+	}
+
+	tests := []struct {
+		name        string
+		maxUses     int
+		perUser     int
+		redemptions []redemption
+		wantUses    int
+	}{
+		{
+			name: "no caps",
+			redemptions: []redemption{
+				{alice, nil}, {alice, nil}, {bob, nil}, {bob, nil},
+			},
+			wantUses: 4,
+		},
+		{
+			name:    "per-user cap",
+			perUser: 1,
+			redemptions: []redemption{
+				{alice, nil}, {alice, ErrPromoCodeUserLimit}, {bob, nil}, {bob, ErrPromoCodeUserLimit},
+			},
+			wantUses: 2,
+		},
+		{
+			name:    "total cap",
+			maxUses: 2,
+			redemptions: []redemption{
+				{alice, nil}, {bob, nil}, {alice, ErrPromoCodeExhausted}, {bob, ErrPromoCodeExhausted},
+			},
+			wantUses: 2,
+		},
+		{
+			name:    "both caps",
+			maxUses: 3,
+			perUser: 2,
+			redemptions: []redemption{
+				{alice, nil}, {alice, nil}, {alice, ErrPromoCodeUserLimit}, {bob, nil}, {bob, ErrPromoCodeExhausted},
+			},
+			wantUses: 3,
+		},
+	}
+
+	orderId := 0
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := PromoCodeModel{DB: db}
+			p := &PromoCode{
+				EventId:        eventId,
+				Code:           "CODE" + strconv.Itoa(i),
+				Kind:           PromoPercent,
+				Value:          10,
+				MaxUses:        tt.maxUses,
+				MaxUsesPerUser: tt.perUser,
+				CreatedBy:      ownerId,
+			}
+			if err := pm.Insert(p); err != nil {
+				t.Fatal(err)
+			}
+
+			for n, r := range tt.redemptions {
+				orderId++
+				o := &Order{
+					Id:          orderId,
+					EventId:     eventId,
+					UserId:      r.userId,
+					PromoCodeId: &p.Id,
+					Discount:    100,
+					Currency:    "EUR",
+				}
+
+				tx, err := db.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = redeemPromoCode(context.Background(), tx, o)
+				if !errors.Is(err, r.wantErr) {
+					tx.Rollback()
+					t.Fatalf("redemption %d by user %d = %v, want %v", n+1, r.userId, err, r.wantErr)
+				}
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var uses, redeemed int
+			err := db.QueryRow(`SELECT uses, (SELECT COUNT(*) FROM promo_redemptions
+				WHERE promo_code_id = $1) FROM promo_codes WHERE id = $1`, p.Id).Scan(&uses, &redeemed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if uses != tt.wantUses || redeemed != tt.wantUses {
+				t.Errorf("uses = %d, redemptions = %d, want %d", uses, redeemed, tt.wantUses)
+			}
+		})
+	}
+}
+
+func TestReleasePromoCode(t *testing.T) {
+	db := newTestDB(t)
+	ownerId, eventId := insertTestEvent(t, db)
+	userId := insertTestUser(t, db, "alice@example.com")
+	ctx := context.Background()
+
+	pm := PromoCodeModel{DB: db}
+	p := &PromoCode{
+		EventId:        eventId,
+		Code:           "ONCE",
+		Kind:           PromoPercent,
+		Value:          10,
+		MaxUses:        1,
+		MaxUsesPerUser: 1,
+		CreatedBy:      ownerId,
+	}
+	if err := pm.Insert(p); err != nil {
+		t.Fatal(err)
+	}
+
+	newOrder := func(status string) *Order {
+		t.Helper()
+
+		o := &Order{EventId: eventId, UserId: userId, PromoCodeId: &p.Id, Discount: 100, Currency: "EUR"}
+		now := time.Now().UTC()
+		err := db.QueryRow(`INSERT INTO orders (event_id, user_id, ticket_type_id, quantity,
+			amount, currency, status, provider, expires_at, created_at, promo_code_id)
+			VALUES ($1, $2, 1, 1, 900, 'EUR', $3, 'fake', $4, $4, $5) RETURNING id`,
+			eventId, userId, status, now, p.Id).Scan(&o.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+
+	// steps run in order, each within a transaction of its own.
+	first, second := newOrder(OrderExpired), newOrder(OrderPending)
+	steps := []struct {
+		name     string
+		run      func(tx *sql.Tx) error
+		wantErr  error
+		wantUses int
+	}{
+		{"first order redeems", func(tx *sql.Tx) error { return redeemPromoCode(ctx, tx, first) }, nil, 1},
+		{"second order hits the cap", func(tx *sql.Tx) error { return redeemPromoCode(ctx, tx, second) },
+			ErrPromoCodeUserLimit, 1},
+		{"first order expires", func(tx *sql.Tx) error { return releasePromoCode(ctx, tx, first.Id) }, nil, 0},
+		{"releasing again changes nothing", func(tx *sql.Tx) error { return releasePromoCode(ctx, tx, first.Id) },
+			nil, 0},
+		{"second order redeems the released use", func(tx *sql.Tx) error { return redeemPromoCode(ctx, tx, second) },
+			nil, 1},
+		{"late payment of the first order restores it", func(tx *sql.Tx) error {
+			return restorePromoRedemption(ctx, tx, first)
+		}, nil, 2},
+	}
+
+	for _, step := range steps {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := step.run(tx); !errors.Is(err, step.wantErr) {
+			tx.Rollback()
+			t.Fatalf("%s: err = %v, want %v", step.name, err, step.wantErr)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		var uses int
+		if err := db.QueryRow(`SELECT uses FROM promo_codes WHERE id = $1`, p.Id).Scan(&uses); err != nil {
+			t.Fatal(err)
+		}
+		if uses != step.wantUses {
+			t.Errorf("%s: uses = %d, want %d", step.name, uses, step.wantUses)
+		}
+	}
+
+	redemptions, err := pm.GetRedemptions(eventId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(redemptions) != 2 {
+		t.Fatalf("got %d redemptions, want 2", len(redemptions))
+	}
+	for _, r := range redemptions {
+		if r.ReleasedAt != nil {
+			t.Errorf("redemption of order %d is still released", r.OrderId)
+		}
+	}
+
+	// A refund releases the redemption but keeps it in the report.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := releasePromoCode(ctx, tx, second.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	redemptions, err = pm.GetRedemptions(eventId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	released := 0
+	for _, r := range redemptions {
+		if r.ReleasedAt != nil {
+			released++
+			if r.OrderId != second.Id {
+				t.Errorf("redemption of order %d is released, want order %d", r.OrderId, second.Id)
+			}
+		}
+	}
+	if len(redemptions) != 2 || released != 1 {
+		t.Errorf("got %d redemptions with %d released, want 2 with 1", len(redemptions), released)
+	}
+}
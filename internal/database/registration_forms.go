@@ -0,0 +1,365 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type RegistrationFormModel struct {
+	DB *sql.DB
+}
+
+// Types of registration questions.
+const (
+	QuestionText        = "text"
+	QuestionSelect      = "select"
+	QuestionMultiSelect = "multi_select"
+	QuestionCheckbox    = "checkbox"
+	QuestionNumber      = "number"
+)
+
+// questionId is the form of question ids, which are used as keys and column
+// names.
+var questionId = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// maxTextAnswer caps the length of text answers whose question sets no
+// maxLength.
+const maxTextAnswer = 1000
+
+// RegistrationForm is the set of questions users answer when they register
+// for an event, such as their T-shirt size or dietary requirements.
+type RegistrationForm struct {
+	EventId   int         `json:"eventId"`
+	Questions []*Question `json:"questions" binding:"max=50,dive"`
+	UpdatedAt *time.Time  `json:"updatedAt,omitempty"`
+}
+
+// Question is a question of a registration form or feedback survey. Its rules
+// apply depending on its type: lengths and a pattern to text, choice counts to
+// multi-select and bounds to number questions. Required checkboxes must be
+// checked.
+type Question struct {
+	// Id is the key of the answer, which stays the same when the question is
+	// reworded.
+	Id       string   `json:"id" binding:"required,max=64"`
+	Label    string   `json:"label" binding:"required,max=200"`
+	Type     string   `json:"type" binding:"required,oneof=text select multi_select checkbox number"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty" binding:"max=100,dive,required,max=200"`
+
+	MinLength  int      `json:"minLength,omitempty" binding:"min=0"`
+	MaxLength  int      `json:"maxLength,omitempty" binding:"min=0,max=1000"`
+	Pattern    string   `json:"pattern,omitempty" binding:"max=500"`
+	MinChoices int      `json:"minChoices,omitempty" binding:"min=0"`
+	MaxChoices int      `json:"maxChoices,omitempty" binding:"min=0"`
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+	Integer    bool     `json:"integer,omitempty"`
+}
+
+// Answers maps question ids to answers: a string for text and select
+// questions, a list of strings for multi-select questions, a boolean for
+// checkboxes and a number for number questions.
+type Answers map[string]any
+
+// Text renders the answer to a question as a single line, for exports.
+func (a Answers) Text(questionId string) string {
+	switch v := a[questionId].(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, "; ")
+	case []any:
+		choices := make([]string, len(v))
+		for i, c := range v {
+			choices[i] = fmt.Sprint(c)
+		}
+		return strings.Join(choices, "; ")
+	default:
+		return ""
+	}
+}
+
+// Validate checks the rules of the form that binding tags cannot express. It
+// returns why the form is invalid, or nil.
+func (f *RegistrationForm) Validate() error {
+	return validateQuestions(f.Questions)
+}
+
+// Check validates answers against the form. It returns the answers in their
+// stored form, leaving out the questions that were not answered, and the
+// problems found by question id, which is empty if there are none. Required
+// questions may be left out if optional is set, for organizers registering
+// someone else.
+func (f *RegistrationForm) Check(answers Answers, optional bool) (Answers, map[string]string) {
+	return checkAnswers(f.Questions, answers, optional)
+}
+
+func validateQuestions(questions []*Question) error {
+	ids := map[string]bool{}
+	for _, q := range questions {
+		if !questionId.MatchString(q.Id) {
+			return fmt.Errorf("question id %q may only hold letters, digits, _ and -", q.Id)
+		}
+		if ids[q.Id] {
+			return fmt.Errorf("question %s is listed twice", q.Id)
+		}
+		ids[q.Id] = true
+
+		switch q.Type {
+		case QuestionSelect, QuestionMultiSelect:
+			if len(q.Options) == 0 {
+				return fmt.Errorf("question %s needs options", q.Id)
+			}
+			seen := map[string]bool{}
+			for _, o := range q.Options {
+				if seen[o] {
+					return fmt.Errorf("question %s lists option %q twice", q.Id, o)
+				}
+				seen[o] = true
+			}
+		default:
+			if len(q.Options) > 0 {
+				return fmt.Errorf("only select questions have options, not %s", q.Id)
+			}
+		}
+
+		if q.MaxLength > 0 && q.MinLength > q.MaxLength {
+			return fmt.Errorf("question %s has a minLength above its maxLength", q.Id)
+		}
+		if q.Pattern != "" {
+			if _, err := regexp.Compile(q.Pattern); err != nil {
+				return fmt.Errorf("question %s has an invalid pattern: %v", q.Id, err)
+			}
+		}
+		if q.MaxChoices > 0 && q.MinChoices > q.MaxChoices {
+			return fmt.Errorf("question %s has a minChoices above its maxChoices", q.Id)
+		}
+		if q.MaxChoices > len(q.Options) {
+			return fmt.Errorf("question %s allows more choices than it has options", q.Id)
+		}
+		if q.Min != nil && q.Max != nil && *q.Min > *q.Max {
+			return fmt.Errorf("question %s has a min above its max", q.Id)
+		}
+	}
+
+	return nil
+}
+
+func checkAnswers(questions []*Question, answers Answers,
+	optional bool) (Answers, map[string]string) {
+	checked := Answers{}
+	problems := map[string]string{}
+
+	for id := range answers {
+		if !slices.ContainsFunc(questions, func(q *Question) bool { return q.Id == id }) {
+			problems[id] = "is not a question of this event"
+		}
+	}
+
+	for _, q := range questions {
+		v, err := q.check(answers[q.Id])
+		if err != "" {
+			problems[q.Id] = err
+			continue
+		}
+		if v == nil {
+			if q.Required && !optional {
+				problems[q.Id] = "is required"
+			}
+			continue
+		}
+		checked[q.Id] = v
+	}
+
+	return checked, problems
+}
+
+// check validates the answer to q. It returns the answer in its stored form,
+// or nil if the question was not answered, and why the answer is invalid.
+func (q *Question) check(v any) (any, string) {
+	if v == nil {
+		return nil, ""
+	}
+
+	switch q.Type {
+	case QuestionText:
+		s, ok := v.(string)
+		if !ok {
+			return nil, "must be text"
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, ""
+		}
+		maxLength := q.MaxLength
+		if maxLength == 0 {
+			maxLength = maxTextAnswer
+		}
+		if n := utf8.RuneCountInString(s); n < q.MinLength || n > maxLength {
+			return nil, fmt.Sprintf("must be between %d and %d characters long",
+				q.MinLength, maxLength)
+		}
+		if q.Pattern != "" {
+			re, err := regexp.Compile(`^(?:` + q.Pattern + `)$`)
+			if err != nil || !re.MatchString(s) {
+				return nil, "is not in the expected format"
+			}
+		}
+		return s, ""
+
+	case QuestionSelect:
+		s, ok := v.(string)
+		if !ok {
+			return nil, "must be one of the options"
+		}
+		if s == "" {
+			return nil, ""
+		}
+		if !slices.Contains(q.Options, s) {
+			return nil, "must be one of the options"
+		}
+		return s, ""
+
+	case QuestionMultiSelect:
+		list, ok := v.([]any)
+		if !ok {
+			return nil, "must be a list of options"
+		}
+		if len(list) == 0 {
+			return nil, ""
+		}
+		choices := make([]string, 0, len(list))
+		for _, c := range list {
+			s, ok := c.(string)
+			if !ok || !slices.Contains(q.Options, s) {
+				return nil, "must only list options"
+			}
+			if slices.Contains(choices, s) {
+				return nil, "lists an option twice"
+			}
+			choices = append(choices, s)
+		}
+		if len(choices) < q.MinChoices {
+			return nil, fmt.Sprintf("must list at least %d options", q.MinChoices)
+		}
+		if q.MaxChoices > 0 && len(choices) > q.MaxChoices {
+			return nil, fmt.Sprintf("must list at most %d options", q.MaxChoices)
+		}
+		return choices, ""
+
+	case QuestionCheckbox:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, "must be true or false"
+		}
+		if !b && q.Required {
+			return nil, "must be checked"
+		}
+		return b, ""
+
+	case QuestionNumber:
+		n, ok := v.(float64)
+		if !ok || math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, "must be a number"
+		}
+		if q.Integer && n != math.Trunc(n) {
+			return nil, "must be a whole number"
+		}
+		if q.Min != nil && n < *q.Min {
+			return nil, "must be at least " + strconv.FormatFloat(*q.Min, 'f', -1, 64)
+		}
+		if q.Max != nil && n > *q.Max {
+			return nil, "must be at most " + strconv.FormatFloat(*q.Max, 'f', -1, 64)
+		}
+		return n, ""
+	}
+
+	return nil, "has an unknown type"
+}
+
+// encodeAnswers and decodeAnswers convert answers to and from the JSON they
+// are stored as.
+func encodeAnswers(a Answers) (string, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+func decodeAnswers(s string) (Answers, error) {
+	a := Answers{}
+	if err := json.Unmarshal([]byte(s), &a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get returns the registration form of an event. Events without questions
+// have a form with none.
+func (fm *RegistrationFormModel) Get(eventId int) (*RegistrationForm, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT questions, updated_at FROM registration_forms WHERE event_id = $1`
+
+	f := &RegistrationForm{EventId: eventId, Questions: []*Question{}}
+	var questions string
+	var updatedAt time.Time
+	err := fm.DB.QueryRowContext(ctx, query, eventId).Scan(&questions, &updatedAt)
+	if err == sql.ErrNoRows {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.UpdatedAt = &updatedAt
+	return f, json.Unmarshal([]byte(questions), &f.Questions)
+}
+
+// Set replaces the questions of an event's registration form. Answers given
+// before are kept as they were.
+func (fm *RegistrationFormModel) Set(f *RegistrationForm, updatedBy int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if f.Questions == nil {
+		f.Questions = []*Question{}
+	}
+	questions, err := json.Marshal(f.Questions)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := `INSERT INTO registration_forms (event_id, questions, updated_by, updated_at)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (event_id) DO UPDATE SET questions = excluded.questions,
+			  updated_by = excluded.updated_by, updated_at = excluded.updated_at`
+
+	if _, err := fm.DB.ExecContext(ctx, query, f.EventId, string(questions), updatedBy,
+		now); err != nil {
+		return err
+	}
+
+	f.UpdatedAt = &now
+	return nil
+}
@@ -0,0 +1,254 @@
+package database
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRegistrationFormCheck(t *testing.T) {
+	form := &RegistrationForm{Questions: []*Question{
+		{Id: "company", Label: "Company", Type: QuestionText, MaxLength: 20},
+		{Id: "code", Label: "Member code", Type: QuestionText, Pattern: `[A-Z]{2}[0-9]{3}`},
+		{Id: "size", Label: "T-shirt size", Type: QuestionSelect, Required: true,
+			Options: []string{"S", "M", "L"}},
+		{Id: "diet", Label: "Dietary requirements", Type: QuestionMultiSelect,
+			Options: []string{"vegetarian", "vegan", "gluten-free"}, MaxChoices: 2},
+		{Id: "terms", Label: "I accept the terms", Type: QuestionCheckbox, Required: true},
+		{Id: "guests", Label: "Guests", Type: QuestionNumber, Integer: true,
+			Min: ptr(0.0), Max: ptr(3.0)},
+	}}
+
+	tests := []struct {
+		name         string
+		answers      string
+		optional     bool
+		wantAnswers  string
+		wantProblems map[string]string
+	}{
+		{
+			name:        "all answered",
+			answers:     `{"company": " Acme ", "code": "AB123", "size": "M", "diet": ["vegan", "gluten-free"], "terms": true, "guests": 2}`,
+			wantAnswers: `{"company": "Acme", "code": "AB123", "size": "M", "diet": ["vegan", "gluten-free"], "terms": true, "guests": 2}`,
+		},
+		{
+			name:        "only required answered",
+			answers:     `{"size": "S", "terms": true, "company": "  ", "diet": []}`,
+			wantAnswers: `{"size": "S", "terms": true}`,
+		},
+		{
+			name:    "required left out",
+			answers: `{}`,
+			wantProblems: map[string]string{
+				"size":  "is required",
+				"terms": "is required",
+			},
+		},
+		{
+			name:        "required left out by an organizer",
+			answers:     `{"guests": 1}`,
+			optional:    true,
+			wantAnswers: `{"guests": 1}`,
+		},
+		{
+			name:    "unknown question",
+			answers: `{"size": "S", "terms": true, "age": 30}`,
+			wantProblems: map[string]string{
+				"age": "is not a question of this event",
+			},
+		},
+		{
+			name:    "text rules",
+			answers: `{"size": "S", "terms": true, "company": "A very long company name", "code": "AB12"}`,
+			wantProblems: map[string]string{
+				"company": "must be between 0 and 20 characters long",
+				"code":    "is not in the expected format",
+			},
+		},
+		{
+			name:    "pattern must match the whole answer",
+			answers: `{"size": "S", "terms": true, "code": "xAB123x"}`,
+			wantProblems: map[string]string{
+				"code": "is not in the expected format",
+			},
+		},
+		{
+			name:    "choices outside the options",
+			answers: `{"size": "XL", "terms": true, "diet": ["vegan", "pescetarian"]}`,
+			wantProblems: map[string]string{
+				"size": "must be one of the options",
+				"diet": "must only list options",
+			},
+		},
+		{
+			name:    "repeated choice",
+			answers: `{"size": "S", "terms": true, "diet": ["vegan", "vegan"]}`,
+			wantProblems: map[string]string{
+				"diet": "lists an option twice",
+			},
+		},
+		{
+			name:    "more choices than allowed",
+			answers: `{"size": "S", "terms": true, "diet": ["vegan", "vegetarian", "gluten-free"]}`,
+			wantProblems: map[string]string{
+				"diet": "must list at most 2 options",
+			},
+		},
+		{
+			name:    "required checkbox unchecked",
+			answers: `{"size": "S", "terms": false}`,
+			wantProblems: map[string]string{
+				"terms": "must be checked",
+			},
+		},
+		{
+			name:    "wrong types",
+			answers: `{"size": 1, "terms": "yes", "company": 5, "diet": "vegan", "guests": "two"}`,
+			wantProblems: map[string]string{
+				"size":    "must be one of the options",
+				"terms":   "must be true or false",
+				"company": "must be text",
+				"diet":    "must be a list of options",
+				"guests":  "must be a number",
+			},
+		},
+		{
+			name:    "number rules",
+			answers: `{"size": "S", "terms": true, "guests": 1.5}`,
+			wantProblems: map[string]string{
+				"guests": "must be a whole number",
+			},
+		},
+		{
+			name:    "number above max",
+			answers: `{"size": "S", "terms": true, "guests": 4}`,
+			wantProblems: map[string]string{
+				"guests": "must be at most 3",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var answers Answers
+			if err := json.Unmarshal([]byte(tt.answers), &answers); err != nil {
+				t.Fatal(err)
+			}
+
+			checked, problems := form.Check(answers, tt.optional)
+
+			wantProblems := tt.wantProblems
+			if wantProblems == nil {
+				wantProblems = map[string]string{}
+			}
+			if !reflect.DeepEqual(problems, wantProblems) {
+				t.Errorf("problems = %v, want %v", problems, wantProblems)
+			}
+			if len(wantProblems) > 0 {
+				return
+			}
+
+			// Compare the answers as they are stored.
+			got, err := encodeAnswers(checked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Answers{}
+			if err := json.Unmarshal([]byte(tt.wantAnswers), &want); err != nil {
+				t.Fatal(err)
+			}
+			wantJSON, _ := encodeAnswers(want)
+			if got != wantJSON {
+				t.Errorf("answers = %s, want %s", got, wantJSON)
+			}
+		})
+	}
+}
+
+func TestRegistrationFormValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		questions []*Question
+		wantErr   string
+	}{
+		{
+			name: "valid",
+			questions: []*Question{
+				{Id: "size", Type: QuestionSelect, Options: []string{"S", "M"}},
+				{Id: "diet", Type: QuestionMultiSelect, Options: []string{"vegan", "vegetarian"},
+					MinChoices: 1, MaxChoices: 2},
+				{Id: "company", Type: QuestionText, MinLength: 2, MaxLength: 50, Pattern: `\w+`},
+				{Id: "guests", Type: QuestionNumber, Min: ptr(0.0), Max: ptr(5.0)},
+			},
+		},
+		{
+			name:      "invalid id",
+			questions: []*Question{{Id: "t-shirt size", Type: QuestionText}},
+			wantErr:   "may only hold",
+		},
+		{
+			name: "duplicate id",
+			questions: []*Question{
+				{Id: "size", Type: QuestionText},
+				{Id: "size", Type: QuestionText},
+			},
+			wantErr: "listed twice",
+		},
+		{
+			name:      "select without options",
+			questions: []*Question{{Id: "size", Type: QuestionSelect}},
+			wantErr:   "needs options",
+		},
+		{
+			name:      "repeated option",
+			questions: []*Question{{Id: "size", Type: QuestionSelect, Options: []string{"S", "S"}}},
+			wantErr:   "twice",
+		},
+		{
+			name:      "options on a text question",
+			questions: []*Question{{Id: "company", Type: QuestionText, Options: []string{"Acme"}}},
+			wantErr:   "only select questions have options",
+		},
+		{
+			name:      "min length above max length",
+			questions: []*Question{{Id: "company", Type: QuestionText, MinLength: 10, MaxLength: 5}},
+			wantErr:   "minLength above its maxLength",
+		},
+		{
+			name:      "invalid pattern",
+			questions: []*Question{{Id: "code", Type: QuestionText, Pattern: `[A-Z`}},
+			wantErr:   "invalid pattern",
+		},
+		{
+			name: "more choices than options",
+			questions: []*Question{{Id: "diet", Type: QuestionMultiSelect,
+				Options: []string{"vegan"}, MaxChoices: 2}},
+			wantErr: "more choices than it has options",
+		},
+		{
+			name:      "min above max",
+			questions: []*Question{{Id: "guests", Type: QuestionNumber, Min: ptr(5.0), Max: ptr(1.0)}},
+			wantErr:   "min above its max",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RegistrationForm{Questions: tt.questions}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SessionModel struct {
+	DB *sql.DB
+}
+
+// Session is a signed-in device. Every issued token carries the id of the
+// session it belongs to and stops working once the session is revoked.
+type Session struct {
+	Id         string     `json:"id"`
+	UserId     int        `json:"userId"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"userAgent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+const sessionColumns = `id, user_id, device, user_agent, ip, created_at, last_seen_at, revoked_at`
+
+func scanSession(s scanner, session *Session) error {
+	var revokedAt sql.NullTime
+
+	err := s.Scan(&session.Id, &session.UserId, &session.Device, &session.UserAgent,
+		&session.IP, &session.CreatedAt, &session.LastSeenAt, &revokedAt)
+	if err != nil {
+		return err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return nil
+}
+
+func (sm *SessionModel) Insert(s *Session) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	id, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	s.Id = id
+	s.CreatedAt = time.Now().UTC()
+	s.LastSeenAt = s.CreatedAt
+
+	query := `INSERT INTO sessions (id, user_id, device, user_agent, ip, created_at, last_seen_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = sm.DB.ExecContext(ctx, query,
+		s.Id, s.UserId, s.Device, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt)
+	return err
+}
+
+func (sm *SessionModel) Get(id string) (*Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+
+	var s Session
+	err := scanSession(sm.DB.QueryRowContext(ctx, query, id), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// GetActiveByUser returns the user's sessions that have not been revoked,
+// most recently used first.
+func (sm *SessionModel) GetActiveByUser(userId int) ([]*Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions
+			  WHERE user_id = $1 AND revoked_at IS NULL
+			  ORDER BY last_seen_at DESC`
+
+	rows, err := sm.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		var s Session
+		if err := scanSession(rows, &s); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (sm *SessionModel) Touch(id, ip string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE sessions SET last_seen_at = $1, ip = $2 WHERE id = $3`
+
+	_, err := sm.DB.ExecContext(ctx, query, at.UTC(), ip, id)
+	return err
+}
+
+// Revoke revokes a single session of the user. It reports whether an active
+// session with that id existed.
+func (sm *SessionModel) Revoke(userId int, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = $1
+			  WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+
+	result, err := sm.DB.ExecContext(ctx, query, time.Now().UTC(), id, userId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeAllExcept revokes every active session of the user except keepId and
+// returns how many were revoked.
+func (sm *SessionModel) RevokeAllExcept(userId int, keepId string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = $1
+			  WHERE user_id = $2 AND id != $3 AND revoked_at IS NULL`
+
+	result, err := sm.DB.ExecContext(ctx, query, time.Now().UTC(), userId, keepId)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
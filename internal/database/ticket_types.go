@@ -0,0 +1,322 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type TicketTypeModel struct {
+	DB *sql.DB
+}
+
+// TicketType is a kind of ticket sold for an event, such as Early Bird or
+// VIP. Registrations for an event with ticket types must name one.
+type TicketType struct {
+	Id          int    `json:"id"`
+	EventId     int    `json:"eventId"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	// Price is in the minor unit of Currency, e.g. cents for EUR.
+	Price    int64  `json:"price" binding:"min=0"`
+	Currency string `json:"currency" binding:"required,iso4217"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+	// Sold counts the tickets that are taken, which includes tickets held
+	// for registrations that are not final yet.
+	Sold int `json:"-"`
+	// Remaining is filled in when the ticket type is read.
+	Remaining int `json:"remaining" binding:"-"`
+
+	// SalesStart and SalesEnd bound when the ticket type can be bought. Either
+	// may be left open.
+	SalesStart  *time.Time `json:"salesStart,omitempty"`
+	SalesEnd    *time.Time `json:"salesEnd,omitempty"`
+	MinPerOrder int        `json:"minPerOrder" binding:"omitempty,min=1"`
+	MaxPerOrder int        `json:"maxPerOrder" binding:"omitempty,min=1,gtefield=MinPerOrder"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TicketSales summarizes the sales of a ticket type for its organizers.
+type TicketSales struct {
+	TicketTypeId int    `json:"ticketTypeId"`
+	Name         string `json:"name"`
+	Currency     string `json:"currency"`
+	Price        int64  `json:"price"`
+	Quantity     int    `json:"quantity"`
+	Sold         int    `json:"sold"`
+	Remaining    int    `json:"remaining"`
+	// Revenue is what the paid orders of the ticket type brought in after
+	// discounts, in the minor unit of Currency. Refunded orders are left out.
+	Revenue int64 `json:"revenue"`
+}
+
+var (
+	ErrSoldOut          = errors.New("not enough tickets left")
+	ErrSalesNotStarted  = errors.New("ticket sales have not started")
+	ErrSalesEnded       = errors.New("ticket sales have ended")
+	ErrOrderLimit       = errors.New("ticket quantity outside the per-order limits")
+	ErrTicketTypeInUse  = errors.New("ticket type has sold tickets")
+	ErrQuantityTooSmall = errors.New("quantity is lower than the tickets already sold")
+)
+
+// CheckOrder returns why quantity tickets of this type cannot be bought at
+// now, or nil if they can as far as the sales window and the per-order limits
+// go. Whether enough tickets are left is only known when they are reserved.
+func (t *TicketType) CheckOrder(quantity int, now time.Time) error {
+	switch {
+	case t.SalesStart != nil && now.Before(*t.SalesStart):
+		return ErrSalesNotStarted
+	case t.SalesEnd != nil && !now.Before(*t.SalesEnd):
+		return ErrSalesEnded
+	case quantity < t.MinPerOrder || quantity > t.MaxPerOrder:
+		return ErrOrderLimit
+	}
+
+	return nil
+}
+
+const ticketTypeColumns = `id, event_id, name, description, price, currency, quantity, sold,
+	sales_start, sales_end, min_per_order, max_per_order, created_at`
+
+func scanTicketType(s scanner, t *TicketType) error {
+	var salesStart, salesEnd sql.NullTime
+
+	err := s.Scan(&t.Id, &t.EventId, &t.Name, &t.Description, &t.Price, &t.Currency,
+		&t.Quantity, &t.Sold, &salesStart, &salesEnd, &t.MinPerOrder, &t.MaxPerOrder,
+		&t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	t.SalesStart, t.SalesEnd = nil, nil
+	if salesStart.Valid {
+		t.SalesStart = &salesStart.Time
+	}
+	if salesEnd.Valid {
+		t.SalesEnd = &salesEnd.Time
+	}
+	t.Remaining = t.Quantity - t.Sold
+	return nil
+}
+
+// normalize fills in the default per-order limits and stores times in UTC.
+func (t *TicketType) normalize() {
+	if t.MinPerOrder == 0 {
+		t.MinPerOrder = 1
+	}
+	if t.MaxPerOrder == 0 {
+		t.MaxPerOrder = max(10, t.MinPerOrder)
+	}
+	if t.SalesStart != nil {
+		start := t.SalesStart.UTC()
+		t.SalesStart = &start
+	}
+	if t.SalesEnd != nil {
+		end := t.SalesEnd.UTC()
+		t.SalesEnd = &end
+	}
+}
+
+func (tm *TicketTypeModel) Insert(t *TicketType) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t.normalize()
+	t.Sold = 0
+	t.Remaining = t.Quantity
+	t.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO ticket_types (event_id, name, description, price, currency, quantity,
+			  sales_start, sales_end, min_per_order, max_per_order, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+
+	return tm.DB.QueryRowContext(ctx, query,
+		t.EventId, t.Name, t.Description, t.Price, t.Currency, t.Quantity,
+		t.SalesStart, t.SalesEnd, t.MinPerOrder, t.MaxPerOrder, t.CreatedAt).Scan(&t.Id)
+}
+
+func (tm *TicketTypeModel) Get(id int) (*TicketType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + ticketTypeColumns + ` FROM ticket_types WHERE id = $1`
+
+	var t TicketType
+	err := scanTicketType(tm.DB.QueryRowContext(ctx, query, id), &t)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// GetByEvent returns the ticket types of an event, cheapest first.
+func (tm *TicketTypeModel) GetByEvent(eventId int) ([]*TicketType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + ticketTypeColumns + ` FROM ticket_types
+			  WHERE event_id = $1 ORDER BY price, id`
+
+	rows, err := tm.DB.QueryContext(ctx, query, eventId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ticketTypes := []*TicketType{}
+
+	for rows.Next() {
+		var t TicketType
+		if err := scanTicketType(rows, &t); err != nil {
+			return nil, err
+		}
+		ticketTypes = append(ticketTypes, &t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ticketTypes, nil
+}
+
+// Update changes a ticket type. It returns ErrQuantityTooSmall if the new
+// quantity is below the number of tickets already sold.
+func (tm *TicketTypeModel) Update(t *TicketType) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t.normalize()
+
+	query := `UPDATE ticket_types SET name = $1, description = $2, price = $3, currency = $4,
+			  quantity = $5, sales_start = $6, sales_end = $7, min_per_order = $8,
+			  max_per_order = $9
+			  WHERE id = $10 AND sold <= $5
+			  RETURNING sold, created_at`
+
+	err := tm.DB.QueryRowContext(ctx, query,
+		t.Name, t.Description, t.Price, t.Currency, t.Quantity,
+		t.SalesStart, t.SalesEnd, t.MinPerOrder, t.MaxPerOrder, t.Id).
+		Scan(&t.Sold, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrQuantityTooSmall
+	}
+	if err != nil {
+		return err
+	}
+
+	t.Remaining = t.Quantity - t.Sold
+	return nil
+}
+
+// Delete removes a ticket type that has not sold any ticket. It returns
+// ErrTicketTypeInUse otherwise.
+func (tm *TicketTypeModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tm.DB.ExecContext(ctx,
+		`DELETE FROM ticket_types WHERE id = $1 AND sold = 0`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrTicketTypeInUse
+	}
+	return nil
+}
+
+// GetSales returns the sales of every ticket type of an event.
+func (tm *TicketTypeModel) GetSales(eventId int) ([]*TicketSales, error) {
+	ticketTypes, err := tm.GetByEvent(eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	revenue, err := tm.revenue(eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make([]*TicketSales, 0, len(ticketTypes))
+	for _, t := range ticketTypes {
+		sales = append(sales, &TicketSales{
+			TicketTypeId: t.Id,
+			Name:         t.Name,
+			Currency:     t.Currency,
+			Price:        t.Price,
+			Quantity:     t.Quantity,
+			Sold:         t.Sold,
+			Remaining:    t.Remaining,
+			Revenue:      revenue[t.Id],
+		})
+	}
+
+	return sales, nil
+}
+
+// revenue sums the amounts of the paid orders of an event by ticket type.
+func (tm *TicketTypeModel) revenue(eventId int) (map[int]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ticket_type_id, SUM(amount) FROM orders
+			  WHERE event_id = $1 AND status = $2 GROUP BY ticket_type_id`
+
+	rows, err := tm.DB.QueryContext(ctx, query, eventId, OrderPaid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revenue := map[int]int64{}
+
+	for rows.Next() {
+		var ticketTypeId int
+		var amount int64
+		if err := rows.Scan(&ticketTypeId, &amount); err != nil {
+			return nil, err
+		}
+		revenue[ticketTypeId] = amount
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revenue, nil
+}
+
+// reserveTickets takes quantity tickets of a ticket type. The check and the
+// update happen in one statement, so concurrent registrations cannot sell
+// more tickets than there are. It returns ErrSoldOut if too few are left.
+func reserveTickets(ctx context.Context, tx *sql.Tx, ticketTypeId, quantity int) error {
+	result, err := tx.ExecContext(ctx, `UPDATE ticket_types SET sold = sold + $1
+		WHERE id = $2 AND sold + $1 <= quantity`, quantity, ticketTypeId)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrSoldOut
+	}
+	return nil
+}
+
+// releaseTickets gives back tickets taken by reserveTickets.
+func releaseTickets(ctx context.Context, tx *sql.Tx, ticketTypeId, quantity int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE ticket_types SET sold = MAX(sold - $1, 0)
+		WHERE id = $2`, quantity, ticketTypeId)
+	return err
+}
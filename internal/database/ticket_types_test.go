@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReserveTickets(t *testing.T) {
+	db := newTestDB(t)
+	_, eventId := insertTestEvent(t, db)
+
+	tm := TicketTypeModel{DB: db}
+	tt := &TicketType{EventId: eventId, Name: "General", Currency: "EUR", Quantity: 5}
+	if err := tm.Insert(tt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The steps run in order against the same ticket type.
+	steps := []struct {
+		name     string
+		quantity int
+		wantErr  error
+		wantSold int
+	}{
+		{"first order", 3, nil, 3},
+		{"more than remain", 3, ErrSoldOut, 3},
+		{"exactly what remains", 2, nil, 5},
+		{"sold out", 1, ErrSoldOut, 5},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			err = reserveTickets(context.Background(), tx, tt.Id, step.quantity)
+			if !errors.Is(err, step.wantErr) {
+				t.Fatalf("reserveTickets(%d) = %v, want %v", step.quantity, err, step.wantErr)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			var sold int
+			if err := db.QueryRow(`SELECT sold FROM ticket_types WHERE id = $1`, tt.Id).Scan(&sold); err != nil {
+				t.Fatal(err)
+			}
+			if sold != step.wantSold {
+				t.Errorf("sold = %d, want %d", sold, step.wantSold)
+			}
+		})
+	}
+
+	t.Run("unknown ticket type", func(t *testing.T) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		if err := reserveTickets(context.Background(), tx, tt.Id+1, 1); !errors.Is(err, ErrSoldOut) {
+			t.Errorf("reserveTickets = %v, want %v", err, ErrSoldOut)
+		}
+	})
+}
@@ -16,6 +16,35 @@ type User struct {
 	Email    string `json:"email"`
 	Name     string `json:"name"`
 	Password string `json:"-"`
+
+	// Anonymous hides the user's identity from attendee lists shown to
+	// anyone but event owners and admins.
+	Anonymous bool `json:"anonymous"`
+	IsAdmin   bool `json:"isAdmin"`
+
+	TokenVersion int `json:"-"`
+}
+
+// PublicUser is the projection of a User that is safe to show to anyone.
+type PublicUser struct {
+	Id        int    `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Anonymous bool   `json:"anonymous,omitempty"`
+}
+
+func (u *User) Public() *PublicUser {
+	if u.Anonymous {
+		return &PublicUser{Name: "Anonymous", Anonymous: true}
+	}
+
+	return &PublicUser{Id: u.Id, Name: u.Name}
+}
+
+const userColumns = `id, email, name, password, anonymous, is_admin, token_version`
+
+func scanUser(s scanner, u *User) error {
+	return s.Scan(&u.Id, &u.Email, &u.Name, &u.Password,
+		&u.Anonymous, &u.IsAdmin, &u.TokenVersion)
 }
 
 func (um *UserModel) Insert(u *User) error {
@@ -31,7 +60,7 @@ func (um *UserModel) GetAll() ([]*User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT * FROM users`
+	query := `SELECT ` + userColumns + ` FROM users`
 	rows, err := um.DB.QueryContext(ctx, query)
 	if err != nil {
 		log.Println(err)
@@ -44,8 +73,7 @@ func (um *UserModel) GetAll() ([]*User, error) {
 	for rows.Next() {
 		var u User
 
-		err := rows.Scan(&u.Id, &u.Email, &u.Name, &u.Password)
-		if err != nil {
+		if err := scanUser(rows, &u); err != nil {
 			log.Println(err)
 			return nil, err
 		}
@@ -62,22 +90,235 @@ func (um *UserModel) GetAll() ([]*User, error) {
 }
 
 func (um *UserModel) Get(id int) (*User, error) {
-	query := `SELECT id, email, name, password FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 	return um.getUser(query, id)
 }
 
 func (um *UserModel) GetByEmail(email string) (*User, error) {
-	query := `SELECT id, email, name, password FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 	return um.getUser(query, email)
 }
 
+func (um *UserModel) Update(u *User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE users SET name = $1, email = $2, anonymous = $3 WHERE id = $4`
+
+	_, err := um.DB.ExecContext(ctx, query, u.Name, u.Email, u.Anonymous, u.Id)
+	return err
+}
+
+// UpdatePassword stores a new password hash and bumps the token version so
+// that every token issued before the change stops being accepted.
+func (um *UserModel) UpdatePassword(id int, hash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE users SET password = $1, token_version = token_version + 1
+			  WHERE id = $2`
+
+	_, err := um.DB.ExecContext(ctx, query, hash, id)
+	return err
+}
+
+// UpdatePasswordHash replaces the stored hash of an unchanged password, for
+// example after upgrading the hashing algorithm. Issued tokens stay valid.
+// Nothing is updated if the stored hash no longer equals oldHash, so a
+// concurrent password change is never overwritten.
+func (um *UserModel) UpdatePasswordHash(id int, oldHash, newHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE users SET password = $1 WHERE id = $2 AND password = $3`
+
+	_, err := um.DB.ExecContext(ctx, query, newHash, id, oldHash)
+	return err
+}
+
+// Delete removes a user and their attendances. Events owned by the user are
+// handed over to transferToId. When transferToId is zero, each event goes to
+// its longest-standing co-owner instead, and drafts without one are soft
+// deleted. If the user still owns events after that, their row is erased like
+// by Anonymize rather than deleted, so that the events keep an owner and stay
+// visible to their attendees. Pending orders are dropped, while settled
+// orders are kept as a record of the payments.
+func (um *UserModel) Delete(id, transferToId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := um.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if transferToId != 0 {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE events SET owner_id = $1 WHERE owner_id = $2`, transferToId, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM event_collaborators WHERE user_id = $1
+			AND event_id IN (SELECT id FROM events WHERE owner_id = $1)`, transferToId)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE events SET owner_id = (SELECT c.user_id
+			FROM event_collaborators c WHERE c.event_id = events.id AND c.role = $1
+			AND c.status = $2 ORDER BY c.accepted_at, c.user_id LIMIT 1)
+			WHERE owner_id = $3 AND EXISTS (SELECT 1 FROM event_collaborators c
+			WHERE c.event_id = events.id AND c.role = $1 AND c.status = $2)`,
+			RoleCoOwner, CollaboratorAccepted, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM event_collaborators WHERE EXISTS
+			(SELECT 1 FROM events e WHERE e.id = event_collaborators.event_id
+			AND e.owner_id = event_collaborators.user_id)`)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE events SET deleted_at = $1
+			WHERE owner_id = $2 AND status = $3 AND deleted_at IS NULL
+			AND id NOT IN (SELECT event_id FROM orders WHERE status = $4)`,
+			time.Now().UTC(), id, EventDraft, OrderPaid)
+		if err != nil {
+			return err
+		}
+	}
+
+	queries := []string{
+		`UPDATE ticket_types SET sold = sold - (SELECT COALESCE(SUM(a.quantity), 0)
+			FROM attendees a WHERE a.ticket_type_id = ticket_types.id AND a.user_id = $1)
+			WHERE id IN (SELECT ticket_type_id FROM attendees WHERE user_id = $1)`,
+		`INSERT INTO checkin_log (event_id, attendee_id, created_at)
+			SELECT event_id, id, CURRENT_TIMESTAMP FROM attendees WHERE user_id = $1`,
+		`DELETE FROM attendees WHERE user_id = $1`,
+		`UPDATE ticket_types SET sold = sold - (SELECT COALESCE(SUM(o.quantity), 0)
+			FROM orders o WHERE o.ticket_type_id = ticket_types.id AND o.user_id = $1
+			AND o.status = 'pending')
+			WHERE id IN (SELECT ticket_type_id FROM orders WHERE user_id = $1 AND status = 'pending')`,
+		`UPDATE promo_codes SET uses = MAX(uses - (SELECT COUNT(*) FROM promo_redemptions r
+			JOIN orders o ON o.id = r.order_id WHERE r.promo_code_id = promo_codes.id
+			AND o.user_id = $1 AND o.status = 'pending'), 0)
+			WHERE id IN (SELECT promo_code_id FROM orders WHERE user_id = $1 AND status = 'pending')`,
+		`DELETE FROM promo_redemptions WHERE order_id IN
+			(SELECT id FROM orders WHERE user_id = $1 AND status = 'pending')`,
+		`DELETE FROM orders WHERE user_id = $1 AND status = 'pending'`,
+		`UPDATE orders SET answers = '{}' WHERE user_id = $1`,
+		`UPDATE event_feedback SET answers = '{}' WHERE user_id = $1`,
+		`UPDATE comments SET body = '', html = '', pinned_at = NULL, deleted_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND deleted_at IS NULL`,
+		`DELETE FROM comment_reports WHERE user_id = $1`,
+		`UPDATE chat_messages SET body = '', deleted_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND deleted_at IS NULL`,
+		`DELETE FROM chat_mutes WHERE user_id = $1`,
+		`DELETE FROM notification_preferences WHERE user_id = $1`,
+		`DELETE FROM notification_settings WHERE user_id = $1`,
+		`DELETE FROM notification_digest_items WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM email_verifications WHERE user_id = $1`,
+		`DELETE FROM login_history WHERE user_id = $1`,
+		`DELETE FROM data_exports WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM event_collaborators WHERE user_id = $1`,
+		`DELETE FROM event_ownership_transfers WHERE from_user_id = $1 OR to_user_id = $1`,
+		`DELETE FROM event_invites WHERE user_id = $1`,
+		`DELETE FROM join_requests WHERE user_id = $1`,
+		`DELETE FROM checkins WHERE user_id = $1`,
+		`DELETE FROM import_jobs WHERE user_id = $1`,
+		`DELETE FROM announcement_deliveries WHERE user_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	var owner bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM events WHERE owner_id = $1)`,
+		id).Scan(&owner)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM users WHERE id = $1`
+	if owner {
+		query = anonymizeUser
+	}
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// anonymizeUser erases the personal data in the row of a user and locks them
+// out.
+const anonymizeUser = `UPDATE users SET name = 'Deleted user', email = 'erased-' || id || '@invalid',
+	password = '', anonymous = 1, is_admin = 0, token_version = token_version + 1
+	WHERE id = $1`
+
+// Anonymize erases a user's personal data while keeping the row, so that the
+// events they own and attend keep their counts. The account can no longer be
+// logged into and all of its tokens are revoked.
+func (um *UserModel) Anonymize(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := um.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, anonymizeUser, id); err != nil {
+		return err
+	}
+
+	queries := []string{
+		`UPDATE attendees SET answers = '{}' WHERE user_id = $1`,
+		`UPDATE orders SET answers = '{}' WHERE user_id = $1`,
+		`UPDATE event_feedback SET answers = '{}' WHERE user_id = $1`,
+		`UPDATE comments SET body = '', html = '', pinned_at = NULL, deleted_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND deleted_at IS NULL`,
+		`DELETE FROM comment_reports WHERE user_id = $1`,
+		`UPDATE chat_messages SET body = '', deleted_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND deleted_at IS NULL`,
+		`DELETE FROM chat_mutes WHERE user_id = $1`,
+		`DELETE FROM notification_preferences WHERE user_id = $1`,
+		`DELETE FROM notification_settings WHERE user_id = $1`,
+		`DELETE FROM notification_digest_items WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM email_verifications WHERE user_id = $1`,
+		`DELETE FROM login_history WHERE user_id = $1`,
+		`DELETE FROM data_exports WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM event_collaborators WHERE user_id = $1`,
+		`DELETE FROM event_ownership_transfers WHERE from_user_id = $1 OR to_user_id = $1`,
+		`DELETE FROM event_invites WHERE user_id = $1`,
+		`DELETE FROM join_requests WHERE user_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (um *UserModel) getUser(query string, args ...any) (*User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	var u User
-	err := um.DB.QueryRowContext(ctx, query, args...).
-		Scan(&u.Id, &u.Email, &u.Name, &u.Password)
+	err := scanUser(um.DB.QueryRowContext(ctx, query, args...), &u)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestUserDeleteKeepsOwnedEvents(t *testing.T) {
+	db := newTestDB(t)
+	um := UserModel{DB: db}
+
+	ownerId, eventId := insertTestEvent(t, db)
+	coOwnerId := insertTestUser(t, db, "co-owner@example.com")
+	editorId := insertTestUser(t, db, "editor@example.com")
+
+	insertEvent := func(status string) int {
+		t.Helper()
+
+		var id int
+		err := db.QueryRow(`INSERT INTO events (owner_id, name, description, location, status)
+			VALUES ($1, 'Event', '', 'Online', $2) RETURNING id`, ownerId, status).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	addCollaborator := func(eventId, userId int, role string) {
+		t.Helper()
+
+		_, err := db.Exec(`INSERT INTO event_collaborators (event_id, user_id, role, status,
+			invited_by, created_at, accepted_at) VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+			eventId, userId, role, CollaboratorAccepted, ownerId, time.Now().UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// eventId has only an editor, shared has a co-owner.
+	addCollaborator(eventId, editorId, RoleEditor)
+	shared := insertEvent(EventCancelled)
+	addCollaborator(shared, editorId, RoleEditor)
+	addCollaborator(shared, coOwnerId, RoleCoOwner)
+	draft := insertEvent(EventDraft)
+
+	if err := um.Delete(ownerId, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		eventId     int
+		wantOwner   int
+		wantDeleted bool
+	}{
+		{"event without a co-owner stays with the erased owner", eventId, ownerId, false},
+		{"co-owner takes over", shared, coOwnerId, false},
+		{"draft without a co-owner is deleted", draft, ownerId, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var owner int
+			var deletedAt sql.NullTime
+			err := db.QueryRow(`SELECT owner_id, deleted_at FROM events WHERE id = $1`,
+				tt.eventId).Scan(&owner, &deletedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner != tt.wantOwner {
+				t.Errorf("owner = %d, want %d", owner, tt.wantOwner)
+			}
+			if deletedAt.Valid != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deletedAt.Valid, tt.wantDeleted)
+			}
+		})
+	}
+
+	var collaborators int
+	err := db.QueryRow(`SELECT COUNT(*) FROM event_collaborators WHERE event_id = $1 AND user_id = $2`,
+		shared, coOwnerId).Scan(&collaborators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collaborators != 0 {
+		t.Error("the new owner is still listed as a co-owner")
+	}
+
+	owner, err := um.Get(ownerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner == nil || !owner.Anonymous || owner.Name != "Deleted user" || owner.Password != "" {
+		t.Errorf("owner = %+v, want an erased row", owner)
+	}
+
+	// Users who own nothing are deleted for good.
+	if err := um.Delete(editorId, 0); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := um.Get(editorId); err != nil || u != nil {
+		t.Errorf("editor = %v, %v; want nil", u, err)
+	}
+}
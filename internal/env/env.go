@@ -7,7 +7,7 @@ import (
 )
 
 func GetEnvInt(key string, defaultValue int) int {
-	envStr, ok := os.LookupEnv("PORT")
+	envStr, ok := os.LookupEnv(key)
 	if !ok {
 		log.Printf("Environment variable %s not set, using default value: %d", key, defaultValue)
 		return defaultValue
@@ -24,7 +24,7 @@ func GetEnvInt(key string, defaultValue int) int {
 }
 
 func GetEnvString(key, defaultValue string) string {
-	env, ok := os.LookupEnv("JWT_SECRET")
+	env, ok := os.LookupEnv(key)
 	if !ok {
 		log.Printf("Environment variable %s not set, using default value: %s", key, defaultValue)
 		return defaultValue
@@ -32,3 +32,20 @@ func GetEnvString(key, defaultValue string) string {
 
 	return env
 }
+
+func GetEnvBool(key string, defaultValue bool) bool {
+	envStr, ok := os.LookupEnv(key)
+	if !ok {
+		log.Printf("Environment variable %s not set, using default value: %t", key, defaultValue)
+		return defaultValue
+	}
+	envBool, err := strconv.ParseBool(envStr)
+	if err != nil {
+		log.Printf(
+			"Error converting environment variable %s to bool: %v, using default value: %t",
+			key, err, defaultValue)
+		return defaultValue
+	}
+
+	return envBool
+}
@@ -0,0 +1,159 @@
+// Package gdpr implements the data subject requests shared by the API and the
+// admin command: exporting everything stored about a user and erasing it.
+package gdpr
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+)
+
+// WriteExport writes a zip archive to w holding one JSON file per kind of
+// data stored about the user.
+func WriteExport(models *database.Models, userId int, w io.Writer) error {
+	user, err := models.Users.Get(userId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user %d not found", userId)
+	}
+
+	ownedEvents, err := models.Events.GetByOwner(userId)
+	if err != nil {
+		return err
+	}
+
+	attendances, err := models.Attendees.GetEventsByUserId(userId)
+	if err != nil {
+		return err
+	}
+
+	registrations, err := models.Attendees.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	logins, err := models.LoginHistory.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	orders, err := models.Orders.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	feedback, err := models.Feedback.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	comments, err := models.Comments.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	chat, err := models.Chat.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	preferences, err := models.Preferences.Get(userId)
+	if err != nil {
+		return err
+	}
+
+	settings, err := models.Preferences.GetSettings(userId)
+	if err != nil {
+		return err
+	}
+
+	notifications, err := models.Notifications.GetAllByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", user},
+		{"owned_events.json", ownedEvents},
+		{"attendances.json", attendances},
+		{"registrations.json", registrations},
+		{"login_history.json", logins},
+		{"orders.json", orders},
+		{"feedback.json", feedback},
+		{"comments.json", comments},
+		{"chat_messages.json", chat},
+		{"notification_preferences.json", preferences},
+		{"notification_settings.json", settings},
+		{"notifications.json", notifications},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// ExportToFile writes the export of a user into dir and returns the path of
+// the created archive.
+func ExportToFile(models *database.Models, userId, exportId int, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("export-%d-%d.zip", userId, exportId))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	if err := WriteExport(models, userId, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, f.Close()
+}
+
+// Erase anonymizes a user and removes any export archives built for them.
+func Erase(models *database.Models, userId int) error {
+	exports, err := models.DataExports.GetByUser(userId)
+	if err != nil {
+		return err
+	}
+
+	if err := models.Users.Anonymize(userId); err != nil {
+		return err
+	}
+
+	for _, e := range exports {
+		if e.FilePath != "" {
+			if err := os.Remove(e.FilePath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+// Package importer imports events and attendee lists from CSV files. It is
+// shared by the API and the admin command.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+)
+
+// DefaultChunkSize is how many rows are imported in one transaction. Files of
+// up to that many rows are imported in a single transaction.
+const DefaultChunkSize = 500
+
+// maxReportedErrors caps the row errors listed in a Report.
+const maxReportedErrors = 1000
+
+// ErrInvalidFile is returned when a file cannot be imported at all, as
+// opposed to having invalid rows.
+var ErrInvalidFile = errors.New("invalid import file")
+
+// Fields lists the fields each kind of import reads.
+var Fields = map[string][]string{
+	database.ImportEvents: {
+		"name", "description", "location", "startsAt", "endsAt", "timezone", "allDay",
+		"attendeeVisibility", "visibility", "approvalRequired", "status",
+	},
+	database.ImportAttendees: {"userId", "email", "ticketTypeId", "quantity"},
+}
+
+// requiredFields lists the fields whose column must be in the file, the
+// attendee's user being identified by email or user id depending on the
+// import.
+var requiredFields = map[string][]string{
+	database.ImportEvents: {"name", "description", "location", "startsAt", "endsAt", "timezone"},
+}
+
+// RowError is a problem with a row of a file.
+type RowError struct {
+	// Line is the line of the file the row starts on, the header being line 1.
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *RowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s %s", e.Line, e.Field, e.Message)
+}
+
+// Report is the outcome of validating a file.
+type Report struct {
+	Rows        int `json:"rows"`
+	InvalidRows int `json:"invalidRows"`
+	// Errors lists the problems found, at most the first thousand.
+	Errors []*RowError `json:"errors"`
+}
+
+// Validate checks every row of the file of an import that has not started as
+// it would be imported, without importing anything. It returns an error
+// wrapping ErrInvalidFile if the file cannot be imported at all.
+func Validate(models *database.Models, job *database.ImportJob) (*Report, error) {
+	f, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := newReader(f, job)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newParser(models, job, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Errors: []*RowError{}}
+	for {
+		rec, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rowErrs, err := p.parse(rec)
+		if err != nil {
+			return nil, err
+		}
+		p.reset()
+
+		report.Rows++
+		if len(rowErrs) > 0 {
+			report.InvalidRows++
+			n := min(len(rowErrs), maxReportedErrors-len(report.Errors))
+			report.Errors = append(report.Errors, rowErrs[:n]...)
+		}
+	}
+
+	return report, nil
+}
+
+// Run imports the rows of a running import from its NextRow on, a chunk at a
+// time. If a chunk cannot be imported the import fails with the reason and
+// can be resumed from that chunk later.
+func Run(models *database.Models, job *database.ImportJob) error {
+	err := run(models, job)
+	if err != nil {
+		if failErr := models.Imports.Fail(job.Id, err.Error()); failErr != nil {
+			return errors.Join(err, failErr)
+		}
+	}
+
+	return err
+}
+
+func run(models *database.Models, job *database.ImportJob) error {
+	f, err := os.Open(job.FilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := newReader(f, job)
+	if err != nil {
+		return err
+	}
+	p, err := newParser(models, job, false)
+	if err != nil {
+		return err
+	}
+
+	// Skip the rows imported before the import was interrupted.
+	for range job.NextRow {
+		if _, err := r.next(); err != nil {
+			return fmt.Errorf("skipping imported rows: %w", err)
+		}
+	}
+
+	chunkSize := job.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var firstLine, lastLine int
+	flush := func() error {
+		var err error
+		if job.Kind == database.ImportEvents {
+			err = models.Imports.InsertEvents(job, p.events, job.NextRow+p.rows())
+		} else {
+			err = models.Imports.InsertAttendees(job, p.attendees, job.NextRow+p.rows())
+		}
+		if err != nil {
+			return fmt.Errorf("importing lines %d to %d: %w", firstLine, lastLine, err)
+		}
+
+		p.reset()
+		return nil
+	}
+
+	for {
+		rec, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rowErrs, err := p.parse(rec)
+		if err != nil {
+			return err
+		}
+		// The file was valid when the import started, but the data it refers
+		// to may have changed since.
+		if len(rowErrs) > 0 {
+			return rowErrs[0]
+		}
+
+		if p.rows() == 1 {
+			firstLine = rec.line
+		}
+		lastLine = rec.line
+
+		if p.rows() == chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.rows() > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if job.Status != database.ImportCompleted {
+		return fmt.Errorf("the file has %d rows instead of %d", job.NextRow, job.TotalRows)
+	}
+
+	return nil
+}
+
+// record is a row of a file.
+type record struct {
+	fields  []string
+	columns map[string]int
+	line    int
+}
+
+// get returns the trimmed value of field, or the empty string if the file
+// has no column for it.
+func (rec *record) get(field string) string {
+	i, ok := rec.columns[field]
+	if !ok || i >= len(rec.fields) {
+		return ""
+	}
+	return strings.TrimSpace(rec.fields[i])
+}
+
+type reader struct {
+	r *csv.Reader
+	// columns maps the fields to the index of their column.
+	columns map[string]int
+}
+
+// newReader reads the header of a file and resolves the columns of the
+// fields of job through its mapping.
+func newReader(f io.Reader, job *database.ImportJob) (*reader, error) {
+	fields, ok := Fields[job.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidFile, job.Kind)
+	}
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	index := map[string]int{}
+	for i, h := range header {
+		if i == 0 {
+			// Spreadsheets like to start their CSV files with a byte order mark.
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	r := &reader{r: cr, columns: map[string]int{}}
+	for field, column := range job.Mapping {
+		if !slices.Contains(fields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q in the mapping", ErrInvalidFile, field)
+		}
+		if _, ok := index[strings.ToLower(strings.TrimSpace(column))]; !ok {
+			return nil, fmt.Errorf("%w: no column %q for field %q", ErrInvalidFile, column, field)
+		}
+	}
+	for _, field := range fields {
+		column, ok := job.Mapping[field]
+		if !ok {
+			column = field
+		}
+		if i, ok := index[strings.ToLower(strings.TrimSpace(column))]; ok {
+			r.columns[field] = i
+		}
+	}
+
+	required := requiredFields[job.Kind]
+	if job.Kind == database.ImportAttendees {
+		required = []string{"userId"}
+		if job.MatchUsers {
+			required = []string{"email"}
+		}
+	}
+	for _, field := range required {
+		if _, ok := r.columns[field]; !ok {
+			return nil, fmt.Errorf("%w: no column for field %q", ErrInvalidFile, field)
+		}
+	}
+
+	return r, nil
+}
+
+// next returns the next row, or io.EOF after the last one.
+func (r *reader) next() (*record, error) {
+	fields, err := r.r.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	line, _ := r.r.FieldPos(0)
+	return &record{fields: fields, columns: r.columns, line: line}, nil
+}
@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+)
+
+const eventHeader = "name,description,location,startsAt,endsAt,timezone,allDay,status\n"
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "import.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		mapping     map[string]string
+		wantRows    int
+		wantInvalid int
+		wantErrors  []string
+	}{
+		{
+			name: "valid rows",
+			file: eventHeader +
+				"Go meetup,Talks about Go and pizza,Berlin,2026-06-01 18:00,2026-06-01 21:00,Europe/Berlin,,\n" +
+				"Summer fair,A whole day of stalls and music,Hamburg,2026-07-04,2026-07-05,Europe/Berlin,true,draft\n",
+			wantRows: 2,
+		},
+		{
+			name: "byte order mark and mapped columns",
+			file: "\ufeffTitle,About,Where,From,To,Zone\n" +
+				"Go meetup,Talks about Go and pizza,Berlin,2026-06-01T18:00:00Z,2026-06-01T21:00:00Z,UTC\n",
+			mapping: map[string]string{
+				"name": "title", "description": "about", "location": "where",
+				"startsAt": "from", "endsAt": "to", "timezone": "zone",
+			},
+			wantRows: 1,
+		},
+		{
+			name: "binding rules",
+			file: eventHeader +
+				"Go,Too short,,2026-06-01 18:00,2026-06-01 21:00,Europe/Berlin,,\n",
+			wantRows:    1,
+			wantInvalid: 1,
+			wantErrors: []string{
+				"line 2: name must be at least 3 characters long",
+				"line 2: description must be at least 10 characters long",
+				"line 2: location is required",
+			},
+		},
+		{
+			name: "unreadable values are reported once",
+			file: eventHeader +
+				"Go meetup,Talks about Go and pizza,Berlin,next friday,2026-06-01 21:00,Mars/Base,maybe,\n",
+			wantRows:    1,
+			wantInvalid: 1,
+			wantErrors: []string{
+				"line 2: startsAt must be a time like 2006-01-02T15:04:05Z07:00 or 2006-01-02 15:04",
+				"line 2: timezone must be an IANA time zone",
+				"line 2: allDay must be true or false",
+			},
+		},
+		{
+			name: "end before start",
+			file: eventHeader +
+				"Go meetup,Talks about Go and pizza,Berlin,2026-06-01 18:00,2026-06-01 17:00,UTC,,\n",
+			wantRows:    1,
+			wantInvalid: 1,
+			wantErrors:  []string{"line 2: endsAt must not be before startsAt"},
+		},
+		{
+			name: "status other than draft or published",
+			file: eventHeader +
+				"Go meetup,Talks about Go and pizza,Berlin,2026-06-01 18:00,2026-06-01 21:00,UTC,,cancelled\n",
+			wantRows:    1,
+			wantInvalid: 1,
+			wantErrors:  []string{"line 2: status must be one of draft, published"},
+		},
+		{
+			name: "lines of quoted multi-line rows",
+			file: eventHeader +
+				"Go meetup,\"Talks about Go\nand pizza\",Berlin,2026-06-01 18:00,2026-06-01 21:00,UTC,,\n" +
+				"Go,Talks about Go and pizza,Berlin,2026-06-01 18:00,2026-06-01 21:00,UTC,,\n",
+			wantRows:    2,
+			wantInvalid: 1,
+			wantErrors:  []string{"line 4: name must be at least 3 characters long"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &database.ImportJob{
+				Kind:     database.ImportEvents,
+				FilePath: writeFile(t, tt.file),
+				Mapping:  tt.mapping,
+			}
+
+			report, err := Validate(&database.Models{}, job)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Rows != tt.wantRows || report.InvalidRows != tt.wantInvalid {
+				t.Errorf("rows = %d, invalid = %d, want %d, %d",
+					report.Rows, report.InvalidRows, tt.wantRows, tt.wantInvalid)
+			}
+
+			var got []string
+			for _, e := range report.Errors {
+				got = append(got, e.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantErrors, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.wantErrors, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateInvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		file    string
+		mapping map[string]string
+	}{
+		{
+			name: "empty file",
+			kind: database.ImportEvents,
+			file: "",
+		},
+		{
+			name: "missing required column",
+			kind: database.ImportEvents,
+			file: "name,description,location,startsAt,endsAt\n",
+		},
+		{
+			name:    "mapping to a missing column",
+			kind:    database.ImportEvents,
+			file:    eventHeader,
+			mapping: map[string]string{"name": "title"},
+		},
+		{
+			name:    "mapping of an unknown field",
+			kind:    database.ImportEvents,
+			file:    eventHeader,
+			mapping: map[string]string{"owner": "name"},
+		},
+		{
+			name: "unknown kind",
+			kind: "tickets",
+			file: eventHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &database.ImportJob{
+				Kind:     tt.kind,
+				FilePath: writeFile(t, tt.file),
+				Mapping:  tt.mapping,
+			}
+
+			_, err := Validate(&database.Models{}, job)
+			if !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// timeLayouts are the layouts times are read in. Times without an offset are
+// in the event's time zone.
+var timeLayouts = []string{
+	time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", time.DateOnly,
+}
+
+// parser turns the rows of a file into the events or attendees they import,
+// collecting them until the chunk is written.
+type parser struct {
+	models *database.Models
+	job    *database.ImportJob
+
+	event       *database.Event
+	ticketTypes map[int]*database.TicketType
+
+	// validating is set while checking a file before the import, which also
+	// looks for rows that would clash with each other or the event.
+	validating bool
+	remaining  map[int]int
+	seen       map[int]int
+
+	events    []*database.Event
+	attendees []*database.Attendee
+}
+
+func newParser(models *database.Models, job *database.ImportJob, validating bool) (*parser, error) {
+	p := &parser{
+		models:     models,
+		job:        job,
+		validating: validating,
+		remaining:  map[int]int{},
+		seen:       map[int]int{},
+	}
+	if job.Kind != database.ImportAttendees {
+		return p, nil
+	}
+
+	if job.EventId == nil {
+		return nil, fmt.Errorf("%w: attendees need an event", ErrInvalidFile)
+	}
+	event, err := models.Events.Get(*job.EventId)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("%w: event %d not found", ErrInvalidFile, *job.EventId)
+	}
+	if event.IsFinal() {
+		return nil, fmt.Errorf("%w: attendees cannot be added to cancelled or completed events",
+			ErrInvalidFile)
+	}
+	p.event = event
+
+	ticketTypes, err := models.TicketTypes.GetByEvent(event.Id)
+	if err != nil {
+		return nil, err
+	}
+	p.ticketTypes = map[int]*database.TicketType{}
+	for _, t := range ticketTypes {
+		p.ticketTypes[t.Id] = t
+		p.remaining[t.Id] = t.Remaining
+	}
+
+	return p, nil
+}
+
+// parse reads rec and adds what it imports to the chunk. It returns the
+// problems with the row, if any, in which case nothing is added.
+func (p *parser) parse(rec *record) ([]*RowError, error) {
+	if p.job.Kind == database.ImportEvents {
+		return p.parseEvent(rec), nil
+	}
+	return p.parseAttendee(rec)
+}
+
+// rows returns how many rows are in the chunk.
+func (p *parser) rows() int {
+	return len(p.events) + len(p.attendees)
+}
+
+// reset starts the next chunk.
+func (p *parser) reset() {
+	p.events, p.attendees = nil, nil
+}
+
+func (p *parser) parseEvent(rec *record) []*RowError {
+	var errs []*RowError
+	fail := func(field, message string) {
+		errs = append(errs, &RowError{Line: rec.line, Field: field, Message: message})
+	}
+
+	e := &database.Event{
+		OwnerID:            p.job.UserId,
+		Name:               rec.get("name"),
+		Description:        rec.get("description"),
+		Location:           rec.get("location"),
+		Timezone:           rec.get("timezone"),
+		AttendeeVisibility: rec.get("attendeeVisibility"),
+		Visibility:         rec.get("visibility"),
+		Status:             rec.get("status"),
+	}
+
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	for _, f := range []struct {
+		field string
+		t     *time.Time
+	}{{"startsAt", &e.StartsAt}, {"endsAt", &e.EndsAt}} {
+		v := rec.get(f.field)
+		if v == "" {
+			continue
+		}
+		t, ok := parseTime(v, loc)
+		if !ok {
+			fail(f.field, "must be a time like 2006-01-02T15:04:05Z07:00 or 2006-01-02 15:04")
+			continue
+		}
+		*f.t = t
+	}
+	for _, f := range []struct {
+		field string
+		b     *bool
+	}{{"allDay", &e.AllDay}, {"approvalRequired", &e.ApprovalRequired}} {
+		v := rec.get(f.field)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			fail(f.field, "must be true or false")
+			continue
+		}
+		*f.b = b
+	}
+
+	// Checked by the same rules as events created through the API, leaving
+	// out fields that could not be read in the first place.
+	if err := binding.Validator.ValidateStruct(e); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			fail("", err.Error())
+		}
+		for _, fe := range verrs {
+			field := lowerFirst(fe.Field())
+			if !hasError(errs, field) {
+				fail(field, ruleMessage(fe))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sortErrors(errs)
+		return errs
+	}
+
+	if err := e.Normalize(); err != nil {
+		fail("timezone", "must be an IANA time zone")
+		return errs
+	}
+
+	p.events = append(p.events, e)
+	return nil
+}
+
+// errUnmatchedEmail is the row error of emails that cannot be imported, the
+// same whatever the reason so that imports do not tell which emails have an
+// account.
+const errUnmatchedEmail = "does not match a user who can be added"
+
+func (p *parser) parseAttendee(rec *record) ([]*RowError, error) {
+	var errs []*RowError
+	fail := func(field, message string) {
+		errs = append(errs, &RowError{Line: rec.line, Field: field, Message: message})
+	}
+
+	var user *database.User
+	userField := "userId"
+	if p.job.MatchUsers {
+		userField = "email"
+		if email := rec.get("email"); email == "" {
+			fail("email", "is required")
+		} else {
+			u, err := p.models.Users.GetByEmail(email)
+			if err != nil {
+				return nil, err
+			}
+			if u == nil {
+				fail("email", errUnmatchedEmail)
+			}
+			user = u
+		}
+	} else {
+		if id, err := strconv.Atoi(rec.get("userId")); err != nil {
+			fail("userId", "must be a user ID")
+		} else {
+			u, err := p.models.Users.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			if u == nil {
+				fail("userId", "does not belong to any user")
+			}
+			user = u
+		}
+	}
+
+	a := &database.Attendee{EventId: p.event.Id, Quantity: 1}
+	if v := rec.get("quantity"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			fail("quantity", "must be a whole number of at least 1")
+		}
+		a.Quantity = n
+	}
+
+	if v := rec.get("ticketTypeId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || p.ticketTypes[id] == nil {
+			fail("ticketTypeId", "is not a ticket type of the event")
+		} else {
+			a.TicketTypeId = &id
+		}
+	} else if len(p.ticketTypes) > 0 {
+		fail("ticketTypeId", "is required for this event")
+	} else if a.Quantity != 1 {
+		fail("quantity", "must be 1 for events without ticket types")
+	}
+
+	if len(errs) > 0 {
+		return errs, nil
+	}
+	a.UserId = user.Id
+
+	if p.validating {
+		if line, ok := p.seen[user.Id]; ok {
+			fail(userField, fmt.Sprintf("is listed before, on line %d", line))
+			return errs, nil
+		}
+		p.seen[user.Id] = rec.line
+
+		existing, err := p.models.Attendees.GetByEventAndUser(p.event.Id, user.Id)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			fail(userField, "already attends the event")
+			return errs, nil
+		}
+
+		if a.TicketTypeId != nil {
+			p.remaining[*a.TicketTypeId] -= a.Quantity
+			if p.remaining[*a.TicketTypeId] < 0 {
+				fail("quantity", "is more than the tickets left")
+				return errs, nil
+			}
+		}
+	}
+
+	p.attendees = append(p.attendees, a)
+	return nil, nil
+}
+
+// parseTime reads a time in one of timeLayouts.
+func parseTime(v string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ruleMessage describes the binding rule a field broke.
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "timezone":
+		return "must be an IANA time zone"
+	case "gtefield":
+		return "must not be before " + lowerFirst(fe.Param())
+	default:
+		return fmt.Sprintf("breaks the %s rule", fe.Tag())
+	}
+}
+
+func hasError(errs []*RowError, field string) bool {
+	for _, e := range errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// sortErrors orders the errors of an event row by field, as fields that
+// cannot be read are reported before the rules are checked.
+func sortErrors(errs []*RowError) {
+	fields := Fields[database.ImportEvents]
+	slices.SortStableFunc(errs, func(a, b *RowError) int {
+		return slices.Index(fields, a.Field) - slices.Index(fields, b.Field)
+	})
+}
+
+// lowerFirst turns a struct field name into the name of the JSON field.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
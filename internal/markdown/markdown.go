@@ -0,0 +1,17 @@
+// Package markdown renders text written by users, such as comments, to HTML
+// that is safe to embed in a page.
+package markdown
+
+import (
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+)
+
+// policy allows the elements markdown produces and strips scripts, event
+// handlers and unsafe links, including from HTML written into the markdown.
+var policy = bluemonday.UGCPolicy().RequireNoFollowOnLinks(true)
+
+// Render converts markdown to sanitized HTML.
+func Render(src string) string {
+	return string(policy.SanitizeBytes(blackfriday.Run([]byte(src))))
+}
@@ -0,0 +1,141 @@
+// Package notifier delivers notifications to users through the channels they
+// can enable in their notification preferences.
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/broker"
+	"github.com/Aergiaaa/gin-event/internal/database"
+	"github.com/Aergiaaa/gin-event/internal/mailer"
+)
+
+// Sender delivers notifications through one channel.
+type Sender interface {
+	Send(to *Recipient, n *database.Notification) error
+}
+
+// Recipient is a user together with their notification settings.
+type Recipient struct {
+	*database.User
+	Settings *database.NotificationSettings
+}
+
+// InApp keeps notifications in the notification center of their users and
+// publishes them to the users' topics.
+type InApp struct {
+	Notifications *database.NotificationModel
+	Broker        broker.Broker
+}
+
+func (s InApp) Send(to *Recipient, n *database.Notification) error {
+	if err := s.Notifications.Insert(n); err != nil {
+		return err
+	}
+
+	s.Broker.Publish(broker.UserTopic(n.UserId), "notification.created", n)
+	return nil
+}
+
+// Email emails notifications, or keeps them for the daily digest of users
+// who chose one.
+type Email struct {
+	Mailer        mailer.Mailer
+	Notifications *database.NotificationModel
+}
+
+func (s Email) Send(to *Recipient, n *database.Notification) error {
+	if to.Settings.Digest {
+		return s.Notifications.QueueDigest(n)
+	}
+	return s.Mailer.Send(to.Email, n.Title, n.Body)
+}
+
+// Webhook posts notifications as JSON to the webhook URL of their users. The
+// X-Signature header holds the hex encoded HMAC-SHA256 of the body, keyed with
+// the webhook secret of the user.
+type Webhook struct {
+	Client *http.Client
+}
+
+var (
+	ErrNoWebhook         = errors.New("no webhook URL set")
+	ErrWebhookAddress    = errors.New("webhook URL resolves to a local or private address")
+	ErrWebhookRedirected = errors.New("webhook responded with a redirect")
+)
+
+// NewWebhookClient returns the client for Webhook. It only connects to
+// addresses database.WebhookAddrAllowed allows, checked after the host name
+// is resolved, and does not follow redirects.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !database.WebhookAddrAllowed(addr.Addr()) {
+				return ErrWebhookAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect to the webhook in place of the dialer.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return ErrWebhookRedirected
+		},
+	}
+}
+
+func (s Webhook) Send(to *Recipient, n *database.Notification) error {
+	if to.Settings.WebhookURL == "" {
+		return ErrNoWebhook
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, to.Settings.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", "sha256="+Sign(to.Settings.WebhookSecret, body))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature of a webhook body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
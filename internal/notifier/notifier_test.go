@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Aergiaaa/gin-event/internal/database"
+)
+
+func TestWebhookClient(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	s := Webhook{Client: NewWebhookClient(time.Second)}
+	to := &Recipient{Settings: &database.NotificationSettings{WebhookURL: srv.URL}}
+
+	// The test server listens on a loopback address.
+	err := s.Send(to, &database.Notification{Title: "Hello"})
+	if !errors.Is(err, ErrWebhookAddress) {
+		t.Errorf("Send = %v, want %v", err, ErrWebhookAddress)
+	}
+	if called {
+		t.Error("webhook on a loopback address was called")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "https://hooks.example.com/notify", nil)
+	if err := s.Client.CheckRedirect(req, nil); !errors.Is(err, ErrWebhookRedirected) {
+		t.Errorf("CheckRedirect = %v, want %v", err, ErrWebhookRedirected)
+	}
+}
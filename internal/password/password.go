@@ -0,0 +1,81 @@
+// Package password hashes and verifies user passwords. Hashes are stored as
+// self-describing strings that name the algorithm and its parameters, so the
+// algorithm or its cost can change without invalidating existing hashes.
+package password
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidHash = errors.New("password: invalid or unsupported hash")
+
+// Algorithm is a password hashing scheme.
+type Algorithm interface {
+	// Name is the identifier the algorithm writes at the start of its hashes.
+	Name() string
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether encoded was produced with parameters that
+	// differ from the algorithm's current ones.
+	NeedsRehash(encoded string) bool
+}
+
+// Hasher hashes new passwords with its default algorithm and verifies
+// hashes produced by any of its registered algorithms.
+type Hasher struct {
+	def        Algorithm
+	algorithms map[string]Algorithm
+}
+
+// NewHasher returns a Hasher that hashes with def and can also verify hashes
+// produced by legacy.
+func NewHasher(def Algorithm, legacy ...Algorithm) *Hasher {
+	h := &Hasher{
+		def:        def,
+		algorithms: map[string]Algorithm{def.Name(): def},
+	}
+
+	for _, a := range legacy {
+		h.algorithms[a.Name()] = a
+	}
+
+	return h
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.def.Hash(password)
+}
+
+// Verify checks password against encoded. When the password matches, rehash
+// reports whether encoded should be replaced by a fresh hash from Hash.
+func (h *Hasher) Verify(password, encoded string) (match, rehash bool, err error) {
+	a, ok := h.algorithms[identify(encoded)]
+	if !ok {
+		return false, false, ErrInvalidHash
+	}
+
+	match, err = a.Verify(password, encoded)
+	if err != nil || !match {
+		return false, false, err
+	}
+
+	return true, a != h.def || a.NeedsRehash(encoded), nil
+}
+
+// identify returns the algorithm name of a hash in PHC string format
+// ($name$params...), treating the bcrypt variants $2a$, $2b$ and $2y$ as
+// "bcrypt".
+func identify(encoded string) string {
+	parts := strings.SplitN(encoded, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+
+	switch parts[1] {
+	case "2a", "2b", "2y":
+		return "bcrypt"
+	}
+
+	return parts[1]
+}
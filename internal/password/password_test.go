@@ -0,0 +1,122 @@
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+// testArgon2id keeps the tests fast; its parameters are far below what
+// production hashes use.
+var testArgon2id = Argon2id{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func mustHash(t *testing.T, a Algorithm, password string) string {
+	t.Helper()
+
+	hash, err := a.Hash(password)
+	if err != nil {
+		t.Fatalf("hashing with %s: %v", a.Name(), err)
+	}
+	return hash
+}
+
+func TestHasherVerify(t *testing.T) {
+	const pw = "correct horse battery staple"
+
+	h := NewHasher(testArgon2id, Bcrypt{Cost: 4})
+
+	stronger := testArgon2id
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name       string
+		password   string
+		encoded    string
+		wantMatch  bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{
+			name:      "current argon2id hash",
+			password:  pw,
+			encoded:   mustHash(t, testArgon2id, pw),
+			wantMatch: true,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong password",
+			encoded:  mustHash(t, testArgon2id, pw),
+		},
+		{
+			name:       "legacy bcrypt hash",
+			password:   pw,
+			encoded:    mustHash(t, Bcrypt{Cost: 4}, pw),
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:     "wrong password for legacy bcrypt hash",
+			password: "wrong password",
+			encoded:  mustHash(t, Bcrypt{Cost: 4}, pw),
+		},
+		{
+			name:       "argon2id hash with other parameters",
+			password:   pw,
+			encoded:    mustHash(t, stronger, pw),
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:     "unregistered algorithm",
+			password: pw,
+			encoded:  "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5",
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "not a hash",
+			password: pw,
+			encoded:  pw,
+			wantErr:  ErrInvalidHash,
+		},
+		{
+			name:     "malformed argon2id hash",
+			password: pw,
+			encoded:  "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+			wantErr:  ErrInvalidHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := h.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("match = %v, want %v", match, tt.wantMatch)
+			}
+			if rehash != tt.wantRehash {
+				t.Errorf("rehash = %v, want %v", rehash, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestHasherHashRoundTrip(t *testing.T) {
+	h := NewHasher(testArgon2id, Bcrypt{Cost: 4})
+
+	hash, err := h.Hash("s3cret-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match, rehash, err := h.Verify("s3cret-passphrase", hash)
+	if err != nil || !match || rehash {
+		t.Errorf("Verify = %v, %v, %v; want true, false, nil", match, rehash, err)
+	}
+}
@@ -0,0 +1,54 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Fake is a provider for tests and local development that never moves money.
+// With Settle set every checkout is paid on the spot. Otherwise checkouts stay
+// open until a webhook reports them, and its webhooks are plain unsigned JSON
+// events such as {"type": "paid", "sessionId": "..."}. It must never be used
+// in production, since anyone can send it a webhook.
+type Fake struct {
+	Settle bool
+}
+
+func (Fake) Name() string {
+	return "fake"
+}
+
+func (f Fake) CreateCheckout(ctx context.Context, checkout Checkout) (*Session, error) {
+	session := &Session{Id: randomId("fake_cs_")}
+	if f.Settle {
+		session.PaymentId = randomId("fake_pay_")
+	} else {
+		session.URL = "https://checkout.invalid/" + session.Id
+	}
+
+	return session, nil
+}
+
+func (Fake) ParseWebhook(payload []byte, header http.Header) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	switch event.Type {
+	case EventPaid:
+		if event.PaymentId == "" {
+			event.PaymentId = randomId("fake_pay_")
+		}
+	case EventExpired, EventRefunded:
+	default:
+		return nil, nil
+	}
+
+	return &event, nil
+}
+
+func (Fake) Refund(ctx context.Context, paymentId string) error {
+	return nil
+}
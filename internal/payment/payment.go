@@ -0,0 +1,75 @@
+// Package payment takes payments for orders through a pluggable provider. The
+// API only talks to the PaymentProvider interface, so providers can be swapped
+// by configuration.
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type PaymentProvider interface {
+	// Name identifies the provider on the orders it handles.
+	Name() string
+	// CreateCheckout starts the payment of an order.
+	CreateCheckout(ctx context.Context, checkout Checkout) (*Session, error)
+	// ParseWebhook checks that a webhook request was sent by the provider and
+	// returns the event it reports. It returns ErrInvalidSignature if the
+	// request cannot be trusted and a nil event if it needs no action.
+	ParseWebhook(payload []byte, header http.Header) (*Event, error)
+	// Refund pays a settled payment back in full.
+	Refund(ctx context.Context, paymentId string) error
+}
+
+// Checkout describes what the buyer of an order pays for.
+type Checkout struct {
+	OrderId     int
+	Description string
+	// Amount is the total to pay, in the minor unit of Currency.
+	Amount        int64
+	Currency      string
+	CustomerEmail string
+	// ExpiresAt is when the order stops holding its tickets. Providers should
+	// not accept the payment after it where they support it.
+	ExpiresAt time.Time
+}
+
+// Session is a started checkout.
+type Session struct {
+	Id string
+	// URL is where the buyer completes the payment. It is empty if the
+	// provider settled the payment right away, in which case PaymentId is set.
+	URL       string
+	PaymentId string
+}
+
+type EventType string
+
+const (
+	// EventPaid reports that the checkout SessionId was paid with PaymentId.
+	EventPaid EventType = "paid"
+	// EventExpired reports that the checkout SessionId can no longer be paid.
+	EventExpired EventType = "expired"
+	// EventRefunded reports that PaymentId was refunded in full.
+	EventRefunded EventType = "refunded"
+)
+
+// Event is something a provider reports through a webhook.
+type Event struct {
+	Type      EventType `json:"type"`
+	SessionId string    `json:"sessionId"`
+	PaymentId string    `json:"paymentId"`
+}
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// randomId returns a random identifier with the given prefix.
+func randomId(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
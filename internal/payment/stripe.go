@@ -0,0 +1,199 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPI = "https://api.stripe.com/v1"
+
+// Stripe takes payments through Stripe Checkout. Its webhook endpoint must
+// send the checkout.session.completed, checkout.session.async_payment_succeeded,
+// checkout.session.expired and charge.refunded events.
+type Stripe struct {
+	SecretKey     string
+	WebhookSecret string
+	// SuccessURL and CancelURL are where Stripe sends the buyer after the
+	// checkout. They may contain {CHECKOUT_SESSION_ID}.
+	SuccessURL string
+	CancelURL  string
+	// Tolerance is how old a webhook may be before it is rejected as a
+	// replay. It defaults to five minutes.
+	Tolerance time.Duration
+	Client    *http.Client
+}
+
+func (*Stripe) Name() string {
+	return "stripe"
+}
+
+func (s *Stripe) CreateCheckout(ctx context.Context, checkout Checkout) (*Session, error) {
+	// Stripe only lets checkouts expire between 30 minutes and a day from
+	// now. Payments that arrive after the order expired are still handled.
+	expiresAt := checkout.ExpiresAt
+	if earliest := time.Now().Add(31 * time.Minute); expiresAt.Before(earliest) {
+		expiresAt = earliest
+	}
+
+	form := url.Values{
+		"mode":                                   {"payment"},
+		"client_reference_id":                    {strconv.Itoa(checkout.OrderId)},
+		"metadata[order_id]":                     {strconv.Itoa(checkout.OrderId)},
+		"success_url":                            {s.SuccessURL},
+		"cancel_url":                             {s.CancelURL},
+		"expires_at":                             {strconv.FormatInt(expiresAt.Unix(), 10)},
+		"line_items[0][quantity]":                {"1"},
+		"line_items[0][price_data][currency]":    {strings.ToLower(checkout.Currency)},
+		"line_items[0][price_data][unit_amount]": {strconv.FormatInt(checkout.Amount, 10)},
+		"line_items[0][price_data][product_data][name]": {checkout.Description},
+	}
+	if checkout.CustomerEmail != "" {
+		form.Set("customer_email", checkout.CustomerEmail)
+	}
+
+	var session struct {
+		Id  string `json:"id"`
+		URL string `json:"url"`
+	}
+	err := s.post(ctx, "/checkout/sessions", form, fmt.Sprintf("order-%d", checkout.OrderId), &session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{Id: session.Id, URL: session.URL}, nil
+}
+
+func (s *Stripe) ParseWebhook(payload []byte, header http.Header) (*Event, error) {
+	if err := s.verify(payload, header.Get("Stripe-Signature"), time.Now()); err != nil {
+		return nil, err
+	}
+
+	var webhook struct {
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				Id            string `json:"id"`
+				PaymentIntent string `json:"payment_intent"`
+				PaymentStatus string `json:"payment_status"`
+				Refunded      bool   `json:"refunded"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, err
+	}
+
+	object := webhook.Data.Object
+	switch webhook.Type {
+	case "checkout.session.completed", "checkout.session.async_payment_succeeded":
+		// Delayed payment methods complete the checkout before the money
+		// arrives and report it with async_payment_succeeded later.
+		if object.PaymentStatus != "paid" {
+			return nil, nil
+		}
+		return &Event{Type: EventPaid, SessionId: object.Id, PaymentId: object.PaymentIntent}, nil
+	case "checkout.session.expired":
+		return &Event{Type: EventExpired, SessionId: object.Id}, nil
+	case "charge.refunded":
+		if !object.Refunded {
+			return nil, nil
+		}
+		return &Event{Type: EventRefunded, PaymentId: object.PaymentIntent}, nil
+	}
+
+	return nil, nil
+}
+
+func (s *Stripe) Refund(ctx context.Context, paymentId string) error {
+	form := url.Values{"payment_intent": {paymentId}}
+	return s.post(ctx, "/refunds", form, "refund-"+paymentId, nil)
+}
+
+// verify checks a Stripe-Signature header of the form t=<unix>,v1=<hex>,
+// where the signature is an HMAC-SHA256 of "<t>.<payload>".
+func (s *Stripe) verify(payload []byte, header string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	tolerance := s.Tolerance
+	if tolerance == 0 {
+		tolerance = 5 * time.Minute
+	}
+	if age := now.Sub(time.Unix(t, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, sig := range signatures {
+		if got, err := hex.DecodeString(sig); err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// post sends a form to the Stripe API and decodes the response into out. The
+// idempotency key makes retries of the same request safe.
+func (s *Stripe) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stripeAPI+path,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.SecretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("stripe: %s %s: %d %s", http.MethodPost, path, resp.StatusCode, body.Error.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,115 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func stripeSignature(secret string, t int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", t)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestStripeVerify(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"checkout.session.completed"}`)
+	now := time.Unix(1700000000, 0)
+	ts := now.Unix()
+
+	valid := stripeSignature(secret, ts, payload)
+	wrong := stripeSignature("whsec_other", ts, payload)
+
+	tests := []struct {
+		name      string
+		tolerance time.Duration
+		payload   []byte
+		header    string
+		wantErr   error
+	}{
+		{
+			name:   "valid signature",
+			header: fmt.Sprintf("t=%d,v1=%s", ts, valid),
+		},
+		{
+			name:    "wrong secret",
+			header:  fmt.Sprintf("t=%d,v1=%s", ts, wrong),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered payload",
+			payload: []byte(`{"id":"evt_2","type":"checkout.session.completed"}`),
+			header:  fmt.Sprintf("t=%d,v1=%s", ts, valid),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "stale timestamp",
+			header:  fmt.Sprintf("t=%d,v1=%s", ts-301, stripeSignature(secret, ts-301, payload)),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:   "timestamp within the tolerance",
+			header: fmt.Sprintf("t=%d,v1=%s", ts-299, stripeSignature(secret, ts-299, payload)),
+		},
+		{
+			name:    "timestamp in the future",
+			header:  fmt.Sprintf("t=%d,v1=%s", ts+301, stripeSignature(secret, ts+301, payload)),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:      "custom tolerance",
+			tolerance: time.Hour,
+			header:    fmt.Sprintf("t=%d,v1=%s", ts-1800, stripeSignature(secret, ts-1800, payload)),
+		},
+		{
+			name:    "signed timestamp swapped",
+			header:  fmt.Sprintf("t=%d,v1=%s", ts-1, valid),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:   "multiple v1 values with one valid",
+			header: fmt.Sprintf("t=%d,v1=%s,v1=%s", ts, wrong, valid),
+		},
+		{
+			name:    "multiple v1 values with none valid",
+			header:  fmt.Sprintf("t=%d,v1=%s,v1=%s", ts, wrong, "not-hex"),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "only a v0 signature",
+			header:  fmt.Sprintf("t=%d,v0=%s", ts, valid),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "missing timestamp",
+			header:  "v1=" + valid,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "empty header",
+			header:  "",
+			wantErr: ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Stripe{WebhookSecret: secret, Tolerance: tt.tolerance}
+
+			body := payload
+			if tt.payload != nil {
+				body = tt.payload
+			}
+
+			if err := s.verify(body, tt.header, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("verify(%q) = %v, want %v", tt.header, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Package ratelimit limits how often something may be done per key, such as
+// per user, within a sliding window. Limits are kept in memory, so they only
+// hold for a single server and reset when it restarts.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many attempts pass between sweeps of the keys whose
+// attempts have all expired.
+const sweepEvery = 1000
+
+// Limiter allows up to a number of attempts per key within a window.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+	calls    int
+}
+
+// New returns a Limiter that allows limit attempts per key within window.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, attempts: map[string][]time.Time{}}
+}
+
+// Allow records an attempt by key and reports whether it is within the limit.
+// Rejected attempts are not recorded. If the attempt is rejected it also
+// returns how long until the next one is allowed.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	attempts := l.recent(key, now)
+	if len(attempts) >= l.limit {
+		return false, attempts[0].Add(l.window).Sub(now)
+	}
+
+	l.attempts[key] = append(attempts, now)
+	return true, 0
+}
+
+// recent returns the attempts by key still within the window, oldest first.
+func (l *Limiter) recent(key string, now time.Time) []time.Time {
+	attempts := l.attempts[key]
+	i := 0
+	for i < len(attempts) && now.Sub(attempts[i]) >= l.window {
+		i++
+	}
+	return attempts[i:]
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key := range l.attempts {
+		if len(l.recent(key, now)) == 0 {
+			delete(l.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package signing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignerVerify(t *testing.T) {
+	s := New("secret", "invite")
+	token := s.Sign("42")
+
+	encoded, sig, _ := strings.Cut(token, ".")
+	forged := New("secret", "invite").Sign("43")
+	_, forgedSig, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name        string
+		signer      *Signer
+		token       string
+		wantPayload string
+		wantOk      bool
+	}{
+		{
+			name:        "valid token",
+			signer:      s,
+			token:       token,
+			wantPayload: "42",
+			wantOk:      true,
+		},
+		{
+			name:        "empty payload",
+			signer:      s,
+			token:       s.Sign(""),
+			wantPayload: "",
+			wantOk:      true,
+		},
+		{
+			name:   "other purpose",
+			signer: New("secret", "unsubscribe"),
+			token:  token,
+		},
+		{
+			name:   "other secret",
+			signer: New("other secret", "invite"),
+			token:  token,
+		},
+		{
+			name:   "payload swapped",
+			signer: s,
+			token:  encoded + "." + forgedSig,
+		},
+		{
+			name:   "signature truncated",
+			signer: s,
+			token:  encoded + "." + sig[:len(sig)-2],
+		},
+		{
+			name:   "signature not base64",
+			signer: s,
+			token:  encoded + ".!!!",
+		},
+		{
+			name:   "no separator",
+			signer: s,
+			token:  encoded + sig,
+		},
+		{
+			name:   "empty token",
+			signer: s,
+			token:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, ok := tt.signer.Verify(tt.token)
+			if ok != tt.wantOk || payload != tt.wantPayload {
+				t.Errorf("Verify(%q) = %q, %v; want %q, %v", tt.token, payload, ok, tt.wantPayload, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSignURLSafe(t *testing.T) {
+	token := New("secret", "invite").Sign("a/b+c?d=e&f")
+	if strings.ContainsAny(token, "/+=?&") {
+		t.Errorf("token %q is not URL-safe", token)
+	}
+}